@@ -0,0 +1,214 @@
+// Аккаунты игроков: регистрация, вход, профиль (имя, цвет, скин).
+// Вход выдает токен сессии, с ним узел проходит рукопожатие с transmitter,
+// и ID узла в реестре и матчах - это ID аккаунта
+package account
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultSessionTTL - сколько живет токен без повторного входа
+const DefaultSessionTTL = 24 * time.Hour
+
+const (
+	minPasswordLength = 8
+	// bcrypt учитывает только первые 72 байта пароля
+	maxPasswordLength = 72
+	maxNameLength     = 32
+)
+
+var (
+	ErrLoginTaken         = errors.New("account: login is already taken")
+	ErrNotFound           = errors.New("account: not found")
+	ErrInvalidCredentials = errors.New("account: invalid login or password")
+	ErrInvalidSession     = errors.New("account: invalid or expired session")
+	ErrInvalidLogin       = errors.New("account: login must be 3-32 latin letters, digits, '_' or '-'")
+	ErrInvalidPassword    = fmt.Errorf("account: password must be %d-%d bytes", minPasswordLength, maxPasswordLength)
+	ErrInvalidProfile     = errors.New("account: invalid profile")
+)
+
+var (
+	loginPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Profile - то, как игрока видят другие
+type Profile struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Skin  string `json:"skin"`
+}
+
+// DefaultProfile - профиль нового аккаунта, имя совпадает с логином
+func DefaultProfile(login string) Profile {
+	return Profile{Name: login, Color: "#3366ff"}
+}
+
+func (p Profile) validate() error {
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > maxNameLength {
+		return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidProfile, maxNameLength)
+	}
+
+	if !colorPattern.MatchString(p.Color) {
+		return fmt.Errorf("%w: color must look like #rrggbb", ErrInvalidProfile)
+	}
+
+	if len(p.Skin) > maxNameLength {
+		return fmt.Errorf("%w: skin must be at most %d bytes", ErrInvalidProfile, maxNameLength)
+	}
+
+	return nil
+}
+
+type Account struct {
+	// ID - он же ID узла в transmitter и матчах
+	ID           string    `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash []byte    `json:"passwordHash"`
+	Profile      Profile   `json:"profile"`
+	Created      time.Time `json:"created"`
+}
+
+// Session - выданный при входе токен и аккаунт, к которому он привязан
+type Session struct {
+	Token     string    `json:"token"`
+	AccountID string    `json:"accountId"`
+	Expires   time.Time `json:"expires"`
+}
+
+// Service - аккаунты в Store и сессии в Sessions, по умолчанию в памяти
+type Service struct {
+	store    Store
+	sessions Sessions
+	ttl      time.Duration
+}
+
+func NewService(store Store, ttl time.Duration) *Service {
+	return &Service{store: store, sessions: NewMemorySessions(), ttl: ttl}
+}
+
+// SetSessions заменяет хранилище сессий, вызывается до первого входа
+func (s *Service) SetSessions(sessions Sessions) {
+	s.sessions = sessions
+}
+
+func (s *Service) Register(login, password string) (Account, error) {
+	if !loginPattern.MatchString(login) {
+		return Account{}, ErrInvalidLogin
+	}
+
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return Account{}, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return Account{}, err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return Account{}, err
+	}
+
+	account := Account{
+		ID:           "acc-" + id,
+		Login:        login,
+		PasswordHash: hash,
+		Profile:      DefaultProfile(login),
+		Created:      time.Now(),
+	}
+
+	if err := s.store.Create(account); err != nil {
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+// Login проверяет пароль и выдает новую сессию
+func (s *Service) Login(login, password string) (Session, error) {
+	account, err := s.store.ByLogin(login)
+	if errors.Is(err, ErrNotFound) {
+		// Хешируем впустую, чтобы по времени ответа нельзя было отличить несуществующий логин
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password)) //nolint:errcheck
+		return Session{}, ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return Session{}, err
+	}
+
+	if bcrypt.CompareHashAndPassword(account.PasswordHash, []byte(password)) != nil {
+		return Session{}, ErrInvalidCredentials
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return Session{}, err
+	}
+
+	session := Session{Token: token, AccountID: account.ID, Expires: time.Now().Add(s.ttl)}
+
+	if err := s.sessions.Put(session); err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+func (s *Service) Logout(token string) error {
+	return s.sessions.Delete(token)
+}
+
+// Authenticate возвращает аккаунт по токену сессии
+func (s *Service) Authenticate(token string) (Account, error) {
+	session, err := s.sessions.Get(token)
+	if err != nil {
+		return Account{}, err
+	}
+
+	return s.store.ByID(session.AccountID)
+}
+
+func (s *Service) Account(id string) (Account, error) {
+	return s.store.ByID(id)
+}
+
+func (s *Service) UpdateProfile(id string, profile Profile) (Account, error) {
+	if err := profile.validate(); err != nil {
+		return Account{}, err
+	}
+
+	account, err := s.store.ByID(id)
+	if err != nil {
+		return Account{}, err
+	}
+
+	account.Profile = profile
+
+	return account, s.store.Update(account)
+}
+
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,312 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryConn - подделка Redis с командами, которые использует Redis
+type memoryConn struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newMemoryConn() *memoryConn {
+	return &memoryConn{values: map[string]string{}, expires: map[string]time.Time{}}
+}
+
+func (c *memoryConn) Do(args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := args[1]
+	if expires, ok := c.expires[key]; ok && time.Now().After(expires) {
+		delete(c.values, key)
+		delete(c.expires, key)
+	}
+
+	switch args[0] {
+	case "SET":
+		_, exists := c.values[key]
+
+		var ttl time.Duration
+
+		for i := 3; i < len(args); i++ {
+			switch args[i] {
+			case "NX":
+				if exists {
+					return nil, nil
+				}
+			case "XX":
+				if !exists {
+					return nil, nil
+				}
+			case "PX":
+				i++
+
+				ms, err := strconv.ParseInt(args[i], 10, 64)
+				if err != nil {
+					return nil, err
+				}
+
+				ttl = time.Duration(ms) * time.Millisecond
+			}
+		}
+
+		c.values[key] = args[2]
+		delete(c.expires, key)
+
+		if ttl > 0 {
+			c.expires[key] = time.Now().Add(ttl)
+		}
+
+		return "OK", nil
+	case "GET":
+		value, ok := c.values[key]
+		if !ok {
+			return nil, nil
+		}
+
+		return value, nil
+	case "DEL":
+		delete(c.values, key)
+		delete(c.expires, key)
+
+		return int64(1), nil
+	default:
+		return nil, fmt.Errorf("unsupported command %s", args[0])
+	}
+}
+
+// Узел входит на одном экземпляре transmitter, а рукопожатие проходит на другом
+func TestSharedSessionsAcrossInstances(t *testing.T) {
+	conn := newMemoryConn()
+
+	instance := func() *Service {
+		shared := NewRedis(conn)
+		s := NewService(shared, time.Hour)
+		s.SetSessions(shared)
+
+		return s
+	}
+
+	first, second := instance(), instance()
+
+	a, err := first.Register("player", "password1")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if _, err := second.Register("player", "password2"); !errors.Is(err, ErrLoginTaken) {
+		t.Fatalf("Register of taken login on another instance error = %v, want %v", err, ErrLoginTaken)
+	}
+
+	session, err := first.Login("player", "password1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	got, err := second.Authenticate(session.Token)
+	if err != nil || got.ID != a.ID {
+		t.Fatalf("Authenticate on another instance = %s, %v, want %s", got.ID, err, a.ID)
+	}
+
+	if err := second.Logout(session.Token); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	if _, err := first.Authenticate(session.Token); !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("Authenticate after Logout on another instance error = %v, want %v", err, ErrInvalidSession)
+	}
+}
+
+// services - одинаковые проверки для аккаунтов в bbolt с сессиями в памяти и для всего в Redis
+func services(t *testing.T, ttl time.Duration) map[string]*Service {
+	bolt, err := OpenBolt(filepath.Join(t.TempDir(), "accounts.db"))
+	if err != nil {
+		t.Fatalf("OpenBolt: %v", err)
+	}
+
+	t.Cleanup(func() { bolt.Close() })
+
+	shared := NewRedis(newMemoryConn())
+	redis := NewService(shared, ttl)
+	redis.SetSessions(shared)
+
+	return map[string]*Service{"bolt": NewService(bolt, ttl), "redis": redis}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		wantErr  error
+	}{
+		{"valid", "player_1", "password", nil},
+		{"taken", "taken", "password", ErrLoginTaken},
+		{"short login", "ab", "password", ErrInvalidLogin},
+		{"bad login", "pl@yer", "password", ErrInvalidLogin},
+		{"short password", "player_2", "short", ErrInvalidPassword},
+		{"long password", "player_3", strings.Repeat("p", 73), ErrInvalidPassword},
+	}
+
+	for name, s := range services(t, time.Hour) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Register("taken", "password"); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					a, err := s.Register(test.login, test.password)
+					if !errors.Is(err, test.wantErr) {
+						t.Fatalf("Register error = %v, want %v", err, test.wantErr)
+					}
+
+					if err == nil && (a.Profile != DefaultProfile(test.login) || !strings.HasPrefix(a.ID, "acc-")) {
+						t.Fatalf("Register = %+v, want a new account with the default profile", a)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		wantErr  error
+	}{
+		{"valid", "player", "password", nil},
+		{"wrong password", "player", "password2", ErrInvalidCredentials},
+		// Несуществующий логин неотличим от неверного пароля
+		{"unknown login", "nobody", "password", ErrInvalidCredentials},
+	}
+
+	for name, s := range services(t, time.Hour) {
+		t.Run(name, func(t *testing.T) {
+			a, err := s.Register("player", "password")
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					session, err := s.Login(test.login, test.password)
+					if !errors.Is(err, test.wantErr) {
+						t.Fatalf("Login error = %v, want %v", err, test.wantErr)
+					}
+
+					if err != nil {
+						return
+					}
+
+					if got, err := s.Authenticate(session.Token); err != nil || got.ID != a.ID {
+						t.Fatalf("Authenticate = %s, %v, want %s", got.ID, err, a.ID)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		logout  bool
+		token   func(Session) string
+		wantErr error
+	}{
+		{"valid", time.Hour, false, func(s Session) string { return s.Token }, nil},
+		{"expired", time.Millisecond, false, func(s Session) string { return s.Token }, ErrInvalidSession},
+		{"logged out", time.Hour, true, func(s Session) string { return s.Token }, ErrInvalidSession},
+		{"unknown token", time.Hour, false, func(Session) string { return "unknown" }, ErrInvalidSession},
+	}
+
+	for _, test := range tests {
+		for name, s := range services(t, test.ttl) {
+			t.Run(test.name+"/"+name, func(t *testing.T) {
+				if _, err := s.Register("player", "password"); err != nil {
+					t.Fatalf("Register: %v", err)
+				}
+
+				session, err := s.Login("player", "password")
+				if err != nil {
+					t.Fatalf("Login: %v", err)
+				}
+
+				if test.logout {
+					if err := s.Logout(session.Token); err != nil {
+						t.Fatalf("Logout: %v", err)
+					}
+				}
+
+				time.Sleep(5 * time.Millisecond)
+
+				if _, err := s.Authenticate(test.token(session)); !errors.Is(err, test.wantErr) {
+					t.Fatalf("Authenticate error = %v, want %v", err, test.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		wantErr error
+	}{
+		{"valid", Profile{Name: "Игрок", Color: "#00ff00", Skin: "cat"}, nil},
+		{"empty name", Profile{Color: "#00ff00"}, ErrInvalidProfile},
+		{"long name", Profile{Name: strings.Repeat("я", 33), Color: "#00ff00"}, ErrInvalidProfile},
+		{"bad color", Profile{Name: "player", Color: "green"}, ErrInvalidProfile},
+		{"long skin", Profile{Name: "player", Color: "#00ff00", Skin: strings.Repeat("s", 33)}, ErrInvalidProfile},
+	}
+
+	for name, s := range services(t, time.Hour) {
+		t.Run(name, func(t *testing.T) {
+			a, err := s.Register("player", "password")
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					_, err := s.UpdateProfile(a.ID, test.profile)
+					if !errors.Is(err, test.wantErr) {
+						t.Fatalf("UpdateProfile error = %v, want %v", err, test.wantErr)
+					}
+
+					got, err := s.Account(a.ID)
+					if err != nil {
+						t.Fatalf("Account: %v", err)
+					}
+
+					if test.wantErr == nil && got.Profile != test.profile {
+						t.Fatalf("profile = %+v, want %+v", got.Profile, test.profile)
+					}
+
+					if test.wantErr != nil && got.Profile == test.profile {
+						t.Fatal("invalid profile was saved")
+					}
+				})
+			}
+		})
+	}
+
+	if _, err := NewService(NewRedis(newMemoryConn()), time.Hour).UpdateProfile("acc-missing", DefaultProfile("player")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateProfile of unknown account error = %v, want %v", err, ErrNotFound)
+	}
+}
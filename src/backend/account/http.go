@@ -0,0 +1,144 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+type credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// ProfileResponse - аккаунт без хеша пароля
+type ProfileResponse struct {
+	ID      string  `json:"id"`
+	Login   string  `json:"login"`
+	Profile Profile `json:"profile"`
+}
+
+func profileResponse(a Account) ProfileResponse {
+	return ProfileResponse{ID: a.ID, Login: a.Login, Profile: a.Profile}
+}
+
+// Handler - HTTP API аккаунтов. Токен сессии передается в заголовке
+// "Authorization: Bearer <token>"
+func Handler(s *Service) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /account/register", func(w http.ResponseWriter, r *http.Request) {
+		var req credentials
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		a, err := s.Register(req.Login, req.Password)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, profileResponse(a))
+	})
+
+	mux.HandleFunc("POST /account/login", func(w http.ResponseWriter, r *http.Request) {
+		var req credentials
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		session, err := s.Login(req.Login, req.Password)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, session)
+	})
+
+	mux.HandleFunc("POST /account/logout", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Logout(bearer(r)); err != nil {
+			writeError(w, err)
+		}
+	})
+
+	mux.HandleFunc("GET /account/profile", func(w http.ResponseWriter, r *http.Request) {
+		a, err := s.Authenticate(bearer(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, profileResponse(a))
+	})
+
+	mux.HandleFunc("PUT /account/profile", func(w http.ResponseWriter, r *http.Request) {
+		a, err := s.Authenticate(bearer(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var profile Profile
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if a, err = s.UpdateProfile(a.ID, profile); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, profileResponse(a))
+	})
+
+	// Профили других игроков открыты: по ним рендер рисует имена и цвета
+	mux.HandleFunc("GET /account/profile/{id}", func(w http.ResponseWriter, r *http.Request) {
+		a, err := s.Account(r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, profileResponse(a))
+	})
+
+	return mux
+}
+
+func bearer(r *http.Request) string {
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidLogin), errors.Is(err, ErrInvalidPassword), errors.Is(err, ErrInvalidProfile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrInvalidSession):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, ErrLoginTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		slog.Error("account request failed", logging.Err(err))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("cannot write response", logging.Err(err))
+	}
+}
@@ -0,0 +1,162 @@
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/directory"
+)
+
+// Ключи аккаунтов и сессий в Redis, рядом с ключами каталога
+const (
+	keyPrefix = "p2pmp:"
+	// accountKey - JSON аккаунта по ID
+	accountKey = keyPrefix + "account:"
+	// loginKey - ID аккаунта по логину
+	loginKey = keyPrefix + "login:"
+	// sessionKey - JSON сессии по токену с TTL до ее истечения
+	sessionKey = keyPrefix + "session:"
+)
+
+// Redis - аккаунты и сессии в общем каталоге (см. directory.OpenRedis).
+// С ним узел входит на одном экземпляре transmitter, а после перенаправления,
+// бутстрапа или миграции проходит рукопожатие на другом
+type Redis struct {
+	conn directory.Conn
+}
+
+var (
+	_ Store    = (*Redis)(nil)
+	_ Sessions = (*Redis)(nil)
+)
+
+func NewRedis(conn directory.Conn) *Redis {
+	return &Redis{conn: conn}
+}
+
+// Create занимает логин через SET NX, поэтому два экземпляра не создадут
+// аккаунты с одним логином
+func (r *Redis) Create(account Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	reply, err := r.conn.Do("SET", loginKey+account.Login, account.ID, "NX")
+	if err != nil {
+		return err
+	}
+
+	if reply == nil {
+		return ErrLoginTaken
+	}
+
+	_, err = r.conn.Do("SET", accountKey+account.ID, string(data))
+
+	return err
+}
+
+// Update перезаписывает аккаунт, логин менять нельзя
+func (r *Redis) Update(account Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	reply, err := r.conn.Do("SET", accountKey+account.ID, string(data), "XX")
+	if err != nil {
+		return err
+	}
+
+	if reply == nil {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Redis) ByID(id string) (Account, error) {
+	var account Account
+
+	return account, r.get(accountKey+id, ErrNotFound, &account)
+}
+
+func (r *Redis) ByLogin(login string) (Account, error) {
+	var id string
+
+	reply, err := r.conn.Do("GET", loginKey+login)
+	if err != nil {
+		return Account{}, err
+	}
+
+	switch value := reply.(type) {
+	case nil:
+		return Account{}, ErrNotFound
+	case string:
+		id = value
+	default:
+		return Account{}, fmt.Errorf("account: unexpected GET reply %T", reply)
+	}
+
+	return r.ByID(id)
+}
+
+// Close ничего не делает: соединение принадлежит вызывающему
+func (r *Redis) Close() error {
+	return nil
+}
+
+// Put сохраняет сессию с TTL до ее истечения, Redis удаляет ее сам
+func (r *Redis) Put(session Session) error {
+	ttl := time.Until(session.Expires)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn.Do("SET", sessionKey+session.Token, string(data), "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+
+	return err
+}
+
+func (r *Redis) Get(token string) (Session, error) {
+	var session Session
+
+	if err := r.get(sessionKey+token, ErrInvalidSession, &session); err != nil {
+		return Session{}, err
+	}
+
+	if time.Now().After(session.Expires) {
+		return Session{}, ErrInvalidSession
+	}
+
+	return session, nil
+}
+
+func (r *Redis) Delete(token string) error {
+	_, err := r.conn.Do("DEL", sessionKey+token)
+	return err
+}
+
+// get читает JSON из key в v, missing - ошибка для пустого ключа
+func (r *Redis) get(key string, missing error, v any) error {
+	reply, err := r.conn.Do("GET", key)
+	if err != nil {
+		return err
+	}
+
+	switch value := reply.(type) {
+	case nil:
+		return missing
+	case string:
+		return json.Unmarshal([]byte(value), v)
+	default:
+		return fmt.Errorf("account: unexpected GET reply %T", reply)
+	}
+}
@@ -0,0 +1,70 @@
+package account
+
+import (
+	"sync"
+	"time"
+)
+
+// Sessions хранит выданные при входе сессии
+type Sessions interface {
+	Put(session Session) error
+	// Get возвращает действующую сессию, ErrInvalidSession - если ее нет или она истекла
+	Get(token string) (Session, error)
+	Delete(token string) error
+}
+
+// MemorySessions - сессии в памяти процесса: после перезапуска нужно войти
+// заново, а другой экземпляр transmitter токен не примет
+type MemorySessions struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+var _ Sessions = (*MemorySessions)(nil)
+
+func NewMemorySessions() *MemorySessions {
+	return &MemorySessions{sessions: map[string]Session{}}
+}
+
+func (m *MemorySessions) Put(session Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	for token, s := range m.sessions {
+		if now.After(s.Expires) {
+			delete(m.sessions, token)
+		}
+	}
+
+	m.sessions[session.Token] = session
+
+	return nil
+}
+
+func (m *MemorySessions) Get(token string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[token]
+	if !ok {
+		return Session{}, ErrInvalidSession
+	}
+
+	if time.Now().After(session.Expires) {
+		delete(m.sessions, token)
+		return Session{}, ErrInvalidSession
+	}
+
+	return session, nil
+}
+
+func (m *MemorySessions) Delete(token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, token)
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package account
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store хранит аккаунты. По умолчанию BoltStore, как и у stats
+type Store interface {
+	// Create сохраняет новый аккаунт, ErrLoginTaken - если логин уже занят
+	Create(account Account) error
+	Update(account Account) error
+	ByID(id string) (Account, error)
+	ByLogin(login string) (Account, error)
+	Close() error
+}
+
+var (
+	accountsBucket = []byte("accounts")
+	// loginsBucket - индекс логин -> ID аккаунта
+	loginsBucket = []byte("logins")
+)
+
+// BoltStore - Store во встроенной базе bbolt
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{accountsBucket, loginsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Create(account Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		logins := tx.Bucket(loginsBucket)
+		if logins.Get([]byte(account.Login)) != nil {
+			return ErrLoginTaken
+		}
+
+		if err := logins.Put([]byte(account.Login), []byte(account.ID)); err != nil {
+			return err
+		}
+
+		return tx.Bucket(accountsBucket).Put([]byte(account.ID), data)
+	})
+}
+
+// Update перезаписывает аккаунт, логин менять нельзя
+func (s *BoltStore) Update(account Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		accounts := tx.Bucket(accountsBucket)
+		if accounts.Get([]byte(account.ID)) == nil {
+			return ErrNotFound
+		}
+
+		return accounts.Put([]byte(account.ID), data)
+	})
+}
+
+func (s *BoltStore) ByID(id string) (Account, error) {
+	var account Account
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return get(tx, id, &account)
+	})
+
+	return account, err
+}
+
+func (s *BoltStore) ByLogin(login string) (Account, error) {
+	var account Account
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(loginsBucket).Get([]byte(login))
+		if id == nil {
+			return ErrNotFound
+		}
+
+		return get(tx, string(id), &account)
+	})
+
+	return account, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func get(tx *bolt.Tx, id string, account *Account) error {
+	data := tx.Bucket(accountsBucket).Get([]byte(id))
+	if data == nil {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal(data, account)
+}
@@ -0,0 +1,266 @@
+// Серверная проверка ввода игроков. Клиент присылает только намерение (направление),
+// движение и рост считает бэкенд, а этот пакет отсекает битый и лишний ввод,
+// ограничивает перемещение за тик и копит нарушения по ID узла, выгоняя
+// или блокируя нарушителей через transmitter
+package anticheat
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+type Violation string
+
+const (
+	ViolationMalformedInput Violation = "malformed_input"
+	ViolationStaleInput     Violation = "stale_input"
+	ViolationInputFlood     Violation = "input_flood"
+	ViolationSpeed          Violation = "speed"
+)
+
+// Enforcer применяет наказания, реализуется реестром узлов transmitter
+type Enforcer interface {
+	Kick(nodeID string, reason string) error
+	Ban(nodeID string, reason string, duration time.Duration) error
+}
+
+type Config struct {
+	// InputsPerTick - сколько вводов за тик в среднем принимается от игрока. Узел
+	// шлет один ввод за тик независимо от частоты кадров рендера (gateway.RunInput)
+	InputsPerTick int
+	// InputBurst - сколько вводов сверх InputsPerTick можно накопить: сеть
+	// приносит вводы пачками после задержек
+	InputBurst int
+	// SpeedTolerance - допуск к максимальному перемещению за тик на погрешности округления
+	SpeedTolerance float32
+	// KickThreshold и BanThreshold - число нарушений до кика и бана, 0 отключает наказание
+	KickThreshold int
+	BanThreshold  int
+	BanDuration   time.Duration
+	// ViolationDecay - через сколько времени без нарушений счетчик узла обнуляется
+	ViolationDecay time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		InputsPerTick:  1,
+		InputBurst:     8,
+		SpeedTolerance: 1.05,
+		KickThreshold:  50,
+		BanThreshold:   200,
+		BanDuration:    time.Hour,
+		ViolationDecay: time.Minute,
+	}
+}
+
+type player struct {
+	nodeID  string
+	lastSeq uint32
+	// credit - сколько вводов еще можно принять, пополняется каждый тик
+	credit int
+	// inputs - сколько вводов принято за текущий тик
+	inputs int
+	// x, y и size - положение и размер на начало тика
+	x    game.Fixed
+	y    game.Fixed
+	size game.Fixed
+}
+
+type record struct {
+	count  int
+	last   time.Time
+	kicked bool
+	banned bool
+}
+
+type Validator struct {
+	mu       sync.Mutex
+	config   Config
+	enforcer Enforcer
+	players  map[uint32]*player
+	records  map[string]*record
+}
+
+func New(config Config, enforcer Enforcer) *Validator {
+	return &Validator{
+		config:   config,
+		enforcer: enforcer,
+		players:  map[uint32]*player{},
+		records:  map[string]*record{},
+	}
+}
+
+// Register связывает игрока в симуляции с узлом, от которого приходит его ввод
+func (v *Validator) Register(nodeID string, p *game.Player) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.players[p.ID] = &player{
+		nodeID:  nodeID,
+		lastSeq: p.LastInput,
+		credit:  v.config.InputsPerTick + v.config.InputBurst,
+		x:       p.X,
+		y:       p.Y,
+		size:    p.Size,
+	}
+}
+
+func (v *Validator) Unregister(playerID uint32) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.players, playerID)
+}
+
+// LastInput возвращает номер последнего принятого ввода игрока
+func (v *Validator) LastInput(playerID uint32) uint32 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if p, ok := v.players[playerID]; ok {
+		return p.lastSeq
+	}
+
+	return 0
+}
+
+// Input проверяет ввод перед постановкой в очередь симуляции. Возвращает
+// нормализованный ввод и false, если ввод нужно отбросить
+func (v *Validator) Input(playerID uint32, in game.Input) (game.Input, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	p, ok := v.players[playerID]
+	if !ok {
+		return in, false
+	}
+
+	if !finite(in.DirX) || !finite(in.DirY) {
+		v.violation(p.nodeID, ViolationMalformedInput)
+		return in, false
+	}
+
+	if int32(in.Seq-p.lastSeq) <= 0 {
+		v.violation(p.nodeID, ViolationStaleInput)
+		return in, false
+	}
+
+	if p.credit <= 0 {
+		v.violation(p.nodeID, ViolationInputFlood)
+		return in, false
+	}
+
+	p.credit--
+	p.inputs++
+	p.lastSeq = in.Seq
+
+	// Важно только направление, длина вектора не влияет на скорость
+	if length := float32(math.Hypot(float64(in.DirX), float64(in.DirY))); length > 0 {
+		in.DirX /= length
+		in.DirY /= length
+	}
+
+	return in, true
+}
+
+// Check вызывается после World.Step: ограничивает перемещение игроков за тик
+// числом принятых вводов и пополняет лимит ввода на следующий тик. Скорость
+// считается по размеру на начало тика (еда съедается после движения), а сдвиг
+// от границы карты при росте у края не считается перемещением. Возвращает ID
+// игроков, положение которых пришлось поправить
+func (v *Validator) Check(w *game.World) []uint32 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var corrected []uint32
+
+	for id, p := range v.players {
+		wp, ok := w.Players[id]
+		if !ok {
+			continue
+		}
+
+		limit := (game.SpeedFor(p.size) * game.Fixed(p.inputs)).Mul(game.FixedFromFloat(v.config.SpeedTolerance))
+		// Apply прижимает к границам с размером на начало тика, вырос игрок у края
+		// в прошлом тике - первый же ввод сдвинет его внутрь карты
+		x, y := p.x, p.y
+		if p.inputs > 0 {
+			x, y = game.ClampPosition(x, y, p.size)
+		}
+
+		dx, dy := wp.X-x, wp.Y-y
+
+		if moved := game.Hypot(dx, dy); moved > limit {
+			v.violation(p.nodeID, ViolationSpeed)
+			wp.X, wp.Y = x, y
+
+			if moved > 0 && limit > 0 {
+				wp.X += dx.Mul(limit).Div(moved)
+				wp.Y += dy.Mul(limit).Div(moved)
+			}
+
+			corrected = append(corrected, id)
+		}
+
+		p.x, p.y, p.size = wp.X, wp.Y, wp.Size
+		p.inputs = 0
+		p.credit = min(p.credit+v.config.InputsPerTick, v.config.InputsPerTick+v.config.InputBurst)
+	}
+
+	return corrected
+}
+
+// Violations возвращает текущее число нарушений узла
+func (v *Validator) Violations(nodeID string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if r, ok := v.records[nodeID]; ok {
+		return r.count
+	}
+
+	return 0
+}
+
+func (v *Validator) violation(nodeID string, kind Violation) {
+	now := time.Now()
+
+	r, ok := v.records[nodeID]
+	if !ok || (v.config.ViolationDecay > 0 && now.Sub(r.last) > v.config.ViolationDecay) {
+		r = &record{}
+		v.records[nodeID] = r
+	}
+
+	r.count++
+	r.last = now
+
+	if v.enforcer == nil {
+		return
+	}
+
+	switch {
+	case v.config.BanThreshold > 0 && r.count >= v.config.BanThreshold && !r.banned:
+		r.banned = true
+		slog.Warn("banning node", logging.Node(nodeID), "violations", r.count, "last", kind)
+
+		if err := v.enforcer.Ban(nodeID, string(kind), v.config.BanDuration); err != nil {
+			slog.Error("cannot ban node", logging.Node(nodeID), logging.Err(err))
+		}
+	case v.config.KickThreshold > 0 && r.count >= v.config.KickThreshold && !r.kicked:
+		r.kicked = true
+		slog.Warn("kicking node", logging.Node(nodeID), "violations", r.count, "last", kind)
+
+		if err := v.enforcer.Kick(nodeID, string(kind)); err != nil {
+			slog.Error("cannot kick node", logging.Node(nodeID), logging.Err(err))
+		}
+	}
+}
+
+func finite(f float32) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
@@ -0,0 +1,194 @@
+package anticheat
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+// enforcer запоминает наказания
+type enforcer struct {
+	kicked []string
+	banned []string
+}
+
+func (e *enforcer) Kick(nodeID string, reason string) error {
+	e.kicked = append(e.kicked, nodeID)
+	return nil
+}
+
+func (e *enforcer) Ban(nodeID string, reason string, duration time.Duration) error {
+	e.banned = append(e.banned, nodeID)
+	return nil
+}
+
+func TestValidatorInput(t *testing.T) {
+	tests := []struct {
+		name           string
+		last           uint32
+		inputs         []game.Input
+		wantAccepted   int
+		wantViolations int
+	}{
+		{"in order", 0, []game.Input{{Seq: 1, DirX: 1}, {Seq: 2, DirX: 1}}, 2, 0},
+		{"stale", 0, []game.Input{{Seq: 2, DirX: 1}, {Seq: 1, DirX: 1}, {Seq: 2, DirX: 1}}, 1, 2},
+		{"nan", 0, []game.Input{{Seq: 1, DirX: float32(math.NaN())}}, 0, 1},
+		{"infinity", 0, []game.Input{{Seq: 1, DirY: float32(math.Inf(-1))}}, 0, 1},
+		{"seq wraps", math.MaxUint32 - 1, []game.Input{{Seq: math.MaxUint32, DirX: 1}, {Seq: 0, DirX: 1}}, 2, 0},
+		// InputsPerTick + InputBurst = 3 ввода, остальные отбрасываются
+		{"flood", 0, []game.Input{{Seq: 1}, {Seq: 2}, {Seq: 3}, {Seq: 4}, {Seq: 5}}, 3, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := New(Config{InputsPerTick: 1, InputBurst: 2}, nil)
+			p := game.NewPlayer(1, 0, 0)
+			p.LastInput = test.last
+			v.Register("a", p)
+
+			accepted := 0
+			for _, in := range test.inputs {
+				if _, ok := v.Input(1, in); ok {
+					accepted++
+				}
+			}
+
+			if accepted != test.wantAccepted || v.Violations("a") != test.wantViolations {
+				t.Fatalf("accepted %d with %d violations, want %d with %d",
+					accepted, v.Violations("a"), test.wantAccepted, test.wantViolations)
+			}
+		})
+	}
+}
+
+func TestValidatorNormalizesDirection(t *testing.T) {
+	v := New(DefaultConfig(), nil)
+	v.Register("a", game.NewPlayer(1, 0, 0))
+
+	in, ok := v.Input(1, game.Input{Seq: 1, DirX: 300, DirY: -400})
+	if !ok || in.DirX != 0.6 || in.DirY != -0.8 {
+		t.Fatalf("Input = %+v, %v, want direction (0.6, -0.8)", in, ok)
+	}
+}
+
+func TestValidatorCheck(t *testing.T) {
+	center := game.FixedFromInt(game.MapWidth / 2)
+	size := game.FixedFromInt(game.InitialPlayerSize)
+	speed := game.SpeedFor(size)
+
+	tests := []struct {
+		name          string
+		x, y          game.Fixed
+		inputs        int
+		moveX         game.Fixed
+		grow          game.Fixed
+		wantCorrected bool
+		wantX         game.Fixed
+	}{
+		{"one step", center, center, 1, speed, 0, false, center + speed},
+		{"two steps", center, center, 2, 2 * speed, 0, false, center + 2*speed},
+		{"teleport", center, center, 1, 100 * speed, 0, true, center + speed.Mul(game.FixedFromFloat(1.05))},
+		{"moved without input", center, center, 0, speed, 0, true, center},
+		// Игрок вырос у края, граница сдвинула его внутрь карты
+		{"pushed by the edge", size, center, 1, size, size, false, 2 * size},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := New(DefaultConfig(), nil)
+			w := game.NewWorld(1)
+			p := w.AddPlayer()
+			p.X, p.Y = test.x, test.y
+
+			// Рост в прошлом тике: проверка видит размер на начало тика
+			p.Size += test.grow
+			v.Register("a", p)
+
+			for seq := range test.inputs {
+				if _, ok := v.Input(p.ID, game.Input{Seq: uint32(seq + 1), DirX: 1}); !ok {
+					t.Fatalf("input %d rejected", seq+1)
+				}
+			}
+
+			p.X += test.moveX
+
+			corrected := v.Check(w)
+
+			if got := slices.Contains(corrected, p.ID); got != test.wantCorrected {
+				t.Fatalf("corrected = %v, want %v", got, test.wantCorrected)
+			}
+
+			if p.X != test.wantX || p.Y != test.y {
+				t.Fatalf("position = (%v, %v), want (%v, %v)", p.X.Float(), p.Y.Float(), test.wantX.Float(), test.y.Float())
+			}
+		})
+	}
+}
+
+func TestValidatorEnforcement(t *testing.T) {
+	tests := []struct {
+		name       string
+		violations int
+		wantKicked bool
+		wantBanned bool
+	}{
+		{"below thresholds", 2, false, false},
+		{"kick", 3, true, false},
+		{"ban", 5, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := &enforcer{}
+			v := New(Config{InputsPerTick: 1, KickThreshold: 3, BanThreshold: 5, ViolationDecay: time.Minute}, e)
+			v.Register("a", game.NewPlayer(1, 0, 0))
+
+			for range test.violations {
+				v.Input(1, game.Input{DirX: float32(math.NaN())})
+			}
+
+			if kicked := slices.Contains(e.kicked, "a"); kicked != test.wantKicked {
+				t.Fatalf("kicked = %v, want %v", kicked, test.wantKicked)
+			}
+
+			if banned := slices.Contains(e.banned, "a"); banned != test.wantBanned {
+				t.Fatalf("banned = %v, want %v", banned, test.wantBanned)
+			}
+
+			// Повторных наказаний за те же нарушения нет
+			if len(e.kicked) > 1 || len(e.banned) > 1 {
+				t.Fatalf("kicked %v, banned %v, want each node at most once", e.kicked, e.banned)
+			}
+		})
+	}
+}
+
+func TestValidatorCreditRefill(t *testing.T) {
+	v := New(Config{InputsPerTick: 1, InputBurst: 1}, nil)
+	w := game.NewWorld(1)
+	p := w.AddPlayer()
+	v.Register("a", p)
+
+	seq := uint32(0)
+	send := func() bool {
+		seq++
+		_, ok := v.Input(p.ID, game.Input{Seq: seq})
+		return ok
+	}
+
+	// Запас в 2 ввода расходуется, за тик пополняется один
+	for i, want := range []bool{true, true, false} {
+		if got := send(); got != want {
+			t.Fatalf("input %d accepted = %v, want %v", i+1, got, want)
+		}
+	}
+
+	v.Check(w)
+
+	if !send() || send() {
+		t.Fatal("after one tick exactly one more input should be accepted")
+	}
+}
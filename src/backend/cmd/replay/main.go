@@ -0,0 +1,69 @@
+// Проверка записи матча: проигрывает повтор и сверяет хеши состояния
+// с записанными, а с -tick показывает состояние мира на нужном тике
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/matelq/p2pmp/src/backend/replay"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+func main() {
+	tick := flag.Int("tick", -1, "Print the world state at this tick instead of verifying the whole replay.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-tick N] file.replay\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fatal("cannot open replay", logging.Err(err))
+	}
+
+	playback, err := replay.Load(f)
+	f.Close()
+
+	if err != nil {
+		fatal("cannot load replay", logging.Err(err))
+	}
+
+	h := playback.Header()
+	slog.Info("replay loaded", logging.Room(h.MatchID), "version", h.Version, "seed", h.Seed, "width", h.MapWidth, "height", h.MapHeight, "tick_rate", h.TickRate)
+
+	if *tick >= 0 {
+		if err := playback.Seek(uint32(*tick)); err != nil {
+			fatal("cannot seek", "tick", *tick, logging.Err(err))
+		}
+
+		w := playback.World()
+		fmt.Printf("tick %d, hash %016x\n", w.Tick, w.Hash())
+
+		for _, e := range w.Snapshot().Entities {
+			fmt.Printf("%d\t%d\t%.2f\t%.2f\t%.2f\n", e.ID, e.Kind, e.X, e.Y, e.Size)
+		}
+
+		return
+	}
+
+	ticks, err := playback.Verify()
+	if err != nil {
+		fatal("replay diverged", "ticks", ticks, logging.Err(err))
+	}
+
+	slog.Info("replay verified", "ticks", ticks)
+}
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,88 @@
+package game
+
+import "math"
+
+// Fixed - число с фиксированной точкой (16 бит дробной части).
+// Симуляция считает только в Fixed, чтобы на всех узлах в lockstep
+// результат совпадал бит в бит независимо от платформы и компилятора
+type Fixed int64
+
+const (
+	fixedShift = 16
+	FixedOne   = Fixed(1 << fixedShift)
+)
+
+func FixedFromInt(v int) Fixed {
+	return Fixed(v) << fixedShift
+}
+
+// FixedFromFloat переводит значение из float (ввод, снимки) в Fixed.
+// Одно умножение и отбрасывание дробной части детерминированы в IEEE 754
+func FixedFromFloat(v float32) Fixed {
+	if !finite(v) {
+		return 0
+	}
+
+	return Fixed(float64(v) * float64(FixedOne))
+}
+
+func (f Fixed) Float() float32 {
+	return float32(f) / float32(FixedOne)
+}
+
+func (f Fixed) Mul(g Fixed) Fixed {
+	return (f * g) >> fixedShift
+}
+
+func (f Fixed) Div(g Fixed) Fixed {
+	if g == 0 {
+		return 0
+	}
+
+	return (f << fixedShift) / g
+}
+
+// Sqrt - целочисленный квадратный корень методом Ньютона
+func (f Fixed) Sqrt() Fixed {
+	if f <= 0 {
+		return 0
+	}
+
+	n := uint64(f) << fixedShift
+	x := uint64(1) << ((bitLen(n) + 1) / 2)
+
+	for {
+		y := (x + n/x) / 2
+		if y >= x {
+			return Fixed(x)
+		}
+
+		x = y
+	}
+}
+
+func bitLen(n uint64) int {
+	l := 0
+	for ; n != 0; n >>= 1 {
+		l++
+	}
+
+	return l
+}
+
+// Hypot - длина вектора (x, y)
+func Hypot(x, y Fixed) Fixed {
+	return (x.Mul(x) + y.Mul(y)).Sqrt()
+}
+
+func clamp(v, lo, hi Fixed) Fixed {
+	return max(lo, min(v, hi))
+}
+
+func finite(v float32) bool {
+	return !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+}
+
+func abs(v float32) float32 {
+	return float32(math.Abs(float64(v)))
+}
@@ -0,0 +1,87 @@
+// Игровая логика (перенос правил из client_electron_js/client/game.js).
+// Пакет используется и бэкендом, и узлом для предсказания движения и lockstep,
+// поэтому симуляция детерминирована: вся арифметика в Fixed
+package game
+
+// Параметры карты
+const (
+	MapWidth  = 3000
+	MapHeight = 3000
+)
+
+const (
+	BaseSpeed         = 5
+	InitialPlayerSize = 10
+)
+
+// Input - намерение игрока на один тик: направление движения
+// (вектор от центра экрана к курсору) и порядковый номер для сверки с сервером
+type Input struct {
+	Seq  uint32
+	DirX float32
+	DirY float32
+}
+
+type Player struct {
+	ID   uint32
+	X    Fixed
+	Y    Fixed
+	Size Fixed
+
+	// LastInput - номер последнего обработанного ввода, бэкенд отдает его узлу
+	LastInput uint32
+}
+
+func NewPlayer(id uint32, x, y Fixed) *Player {
+	return &Player{ID: id, X: x, Y: y, Size: FixedFromInt(InitialPlayerSize)}
+}
+
+func (p *Player) Speed() Fixed {
+	return SpeedFor(p.Size)
+}
+
+// SpeedFor - перемещение за один ввод у игрока размера size
+func SpeedFor(size Fixed) Fixed {
+	return FixedFromInt(BaseSpeed).Div(size.Sqrt())
+}
+
+// ClampPosition ограничивает положение игрока размера size границами карты
+func ClampPosition(x, y, size Fixed) (Fixed, Fixed) {
+	return clamp(x, size, FixedFromInt(MapWidth)-size), clamp(y, size, FixedFromInt(MapHeight)-size)
+}
+
+// ClampDirection приводит направление ввода к компонентам в [-1, 1], не меняя
+// его направления: огромные значения переполнили бы Fixed в Hypot. NaN и
+// бесконечности дают нулевой ввод. Деление детерминировано в IEEE 754, поэтому
+// в lockstep все узлы получают одно и то же направление
+func ClampDirection(dirX, dirY float32) (float32, float32) {
+	if !finite(dirX) || !finite(dirY) {
+		return 0, 0
+	}
+
+	if m := max(abs(dirX), abs(dirY)); m > 1 {
+		return dirX / m, dirY / m
+	}
+
+	return dirX, dirY
+}
+
+// Apply сдвигает игрока на один шаг по направлению ввода
+// и ограничивает его границами карты
+func (p *Player) Apply(in Input) {
+	dirX, dirY := ClampDirection(in.DirX, in.DirY)
+	dx, dy := FixedFromFloat(dirX), FixedFromFloat(dirY)
+
+	if length := Hypot(dx, dy); length > 0 {
+		speed := p.Speed()
+
+		p.X += dx.Mul(speed).Div(length)
+		p.Y += dy.Mul(speed).Div(length)
+	}
+
+	p.X, p.Y = ClampPosition(p.X, p.Y, p.Size)
+
+	if int32(in.Seq-p.LastInput) > 0 {
+		p.LastInput = in.Seq
+	}
+}
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"hash/fnv"
+	"math/rand/v2"
+)
+
+// worldState - сериализуемое состояние мира, в том числе генератора еды,
+// чтобы восстановленный мир продолжил симуляцию так же, как исходный
+type worldState struct {
+	Tick    uint32
+	Players []Player
+	Foods   []Food
+	NextID  uint32
+	RNG     []byte
+}
+
+// MarshalBinary сохраняет мир целиком, например для передачи новому хосту
+func (w *World) MarshalBinary() ([]byte, error) {
+	rng, err := w.pcg.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	state := worldState{Tick: w.Tick, NextID: w.nextID, RNG: rng}
+
+	for _, id := range w.playerIDs() {
+		state.Players = append(state.Players, *w.Players[id])
+	}
+
+	for _, f := range w.Foods {
+		state.Foods = append(state.Foods, *f)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary восстанавливает мир, сохраненный MarshalBinary.
+// Очередь ввода не сохраняется: ввод, не дошедший до тика, теряется
+func (w *World) UnmarshalBinary(data []byte) error {
+	var state worldState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return err
+	}
+
+	pcg := &rand.PCG{}
+	if err := pcg.UnmarshalBinary(state.RNG); err != nil {
+		return err
+	}
+
+	w.Tick = state.Tick
+	w.nextID = state.NextID
+	w.pcg = pcg
+	w.rng = rand.New(pcg)
+	w.inputs = map[uint32][]Input{}
+	w.Players = make(map[uint32]*Player, len(state.Players))
+	w.Foods = make(map[uint32]*Food, len(state.Foods))
+
+	for i := range state.Players {
+		w.Players[state.Players[i].ID] = &state.Players[i]
+	}
+
+	for i := range state.Foods {
+		w.Foods[state.Foods[i].ID] = &state.Foods[i]
+	}
+
+	return nil
+}
+
+// Saved - снимок мира в памяти для отката (rollback). В отличие от
+// MarshalBinary не сериализуется и сохраняется быстро, на каждом кадре
+type Saved struct {
+	tick    uint32
+	players []Player
+	foods   []Food
+	nextID  uint32
+	pcg     rand.PCG
+}
+
+func (s *Saved) Tick() uint32 {
+	return s.tick
+}
+
+// Save сохраняет мир; очередь ввода не сохраняется
+func (w *World) Save() *Saved {
+	s := &Saved{
+		tick:    w.Tick,
+		players: make([]Player, 0, len(w.Players)),
+		foods:   make([]Food, 0, len(w.Foods)),
+		nextID:  w.nextID,
+		pcg:     *w.pcg,
+	}
+
+	for _, p := range w.Players {
+		s.players = append(s.players, *p)
+	}
+
+	for _, f := range w.Foods {
+		s.foods = append(s.foods, *f)
+	}
+
+	return s
+}
+
+// Restore возвращает мир в состояние, сохраненное Save. Снимок можно восстанавливать многократно
+func (w *World) Restore(s *Saved) {
+	w.Tick = s.tick
+	w.nextID = s.nextID
+	*w.pcg = s.pcg
+	w.inputs = map[uint32][]Input{}
+	w.Players = make(map[uint32]*Player, len(s.players))
+	w.Foods = make(map[uint32]*Food, len(s.foods))
+
+	// Мир получает копии: снимок восстанавливается многократно и не должен меняться вместе с миром
+	for i := range s.players {
+		p := s.players[i]
+		w.Players[p.ID] = &p
+	}
+
+	for i := range s.foods {
+		f := s.foods[i]
+		w.Foods[f.ID] = &f
+	}
+}
+
+// Hash - хеш состояния мира для обнаружения рассинхронизации в lockstep.
+// Обход в порядке ID, поэтому хеш не зависит от порядка обхода map
+func (w *World) Hash() uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, 64)
+
+	buf = binary.LittleEndian.AppendUint32(buf, w.Tick)
+	buf = binary.LittleEndian.AppendUint32(buf, w.nextID)
+	h.Write(buf)
+
+	for _, id := range w.playerIDs() {
+		p := w.Players[id]
+
+		buf = binary.LittleEndian.AppendUint32(buf[:0], p.ID)
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p.X))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p.Y))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p.Size))
+		h.Write(buf)
+	}
+
+	for _, id := range w.foodIDs() {
+		f := w.Foods[id]
+
+		buf = binary.LittleEndian.AppendUint32(buf[:0], f.ID)
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(f.X))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(f.Y))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(f.Size))
+		h.Write(buf)
+	}
+
+	if rng, err := w.pcg.MarshalBinary(); err == nil {
+		h.Write(rng)
+	}
+
+	return h.Sum64()
+}
@@ -0,0 +1,244 @@
+package game
+
+import (
+	"math/rand/v2"
+	"sort"
+
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+const (
+	TickRate  = 20
+	FoodCount = 100
+	FoodSize  = 5
+
+	// EatRatio - во сколько раз (в десятых) игрок должен быть больше другого, чтобы его съесть
+	EatRatio = 11
+)
+
+var eatRatio = FixedFromInt(EatRatio) / 10
+
+type Food struct {
+	ID   uint32
+	X    Fixed
+	Y    Fixed
+	Size Fixed
+}
+
+// Rewinder отдает положение цели target таким, каким его видел игрок actor
+// (см. пакет lagcomp). Если ok == false, используется текущее положение.
+// В lockstep компенсация не используется, так как все узлы видят один и тот же кадр
+type Rewinder interface {
+	Rewind(actor, target uint32) (x, y, size Fixed, ok bool)
+}
+
+// Observer узнает о поедании во время шага симуляции (статистика матча)
+type Observer interface {
+	FoodEaten(player uint32, count int)
+	PlayerEaten(hunter, prey uint32)
+}
+
+// World - авторитетная симуляция одной игры
+type World struct {
+	Tick    uint32
+	Players map[uint32]*Player
+	Foods   map[uint32]*Food
+
+	rewinder Rewinder
+	observer Observer
+	inputs   map[uint32][]Input
+	nextID   uint32
+	pcg      *rand.PCG
+	rng      *rand.Rand
+}
+
+func NewWorld(seed uint64) *World {
+	pcg := rand.NewPCG(seed, seed)
+	w := &World{
+		Players: map[uint32]*Player{},
+		Foods:   map[uint32]*Food{},
+		inputs:  map[uint32][]Input{},
+		pcg:     pcg,
+		rng:     rand.New(pcg),
+	}
+
+	for range FoodCount {
+		w.spawnFood()
+	}
+
+	return w
+}
+
+// SetRewinder включает компенсацию задержки при поедании игроков
+func (w *World) SetRewinder(r Rewinder) {
+	w.rewinder = r
+}
+
+func (w *World) SetObserver(o Observer) {
+	w.observer = o
+}
+
+func (w *World) AddPlayer() *Player {
+	w.nextID++
+	player := NewPlayer(w.nextID, FixedFromInt(MapWidth/2), FixedFromInt(MapHeight/2))
+	w.Players[player.ID] = player
+
+	return player
+}
+
+func (w *World) RemovePlayer(id uint32) {
+	delete(w.Players, id)
+	delete(w.inputs, id)
+}
+
+// QueueInput ставит ввод игрока в очередь на следующий тик
+func (w *World) QueueInput(id uint32, in Input) {
+	if _, ok := w.Players[id]; !ok {
+		return
+	}
+
+	w.inputs[id] = append(w.inputs[id], in)
+}
+
+// Step продвигает симуляцию на один тик и возвращает ID съеденных за тик игроков
+func (w *World) Step() []uint32 {
+	w.Tick++
+
+	for _, id := range w.playerIDs() {
+		player := w.Players[id]
+
+		for _, in := range w.inputs[id] {
+			player.Apply(in)
+		}
+
+		delete(w.inputs, id)
+		w.eatFood(player)
+	}
+
+	return w.eatPlayers()
+}
+
+func (w *World) eatFood(player *Player) {
+	eaten := 0
+
+	// Порядок обхода важен: размер растет по ходу, а от него зависит, что еще будет съедено
+	for _, id := range w.foodIDs() {
+		food := w.Foods[id]
+
+		if Hypot(player.X-food.X, player.Y-food.Y) < player.Size+food.Size {
+			player.Size += food.Size / 2
+			delete(w.Foods, id)
+			eaten++
+		}
+	}
+
+	if eaten > 0 && w.observer != nil {
+		w.observer.FoodEaten(player.ID, eaten)
+	}
+
+	// Взамен съеденной еды появляется новая
+	for range eaten {
+		w.spawnFood()
+	}
+}
+
+// eatPlayers проверяет поедание игроков: крупные ходят первыми,
+// а положение жертвы берется с учетом задержки охотника
+func (w *World) eatPlayers() []uint32 {
+	ids := w.playerIDs()
+	sort.SliceStable(ids, func(i, j int) bool { return w.Players[ids[i]].Size > w.Players[ids[j]].Size })
+
+	var eaten []uint32
+	dead := map[uint32]bool{}
+
+	for _, hunterID := range ids {
+		if dead[hunterID] {
+			continue
+		}
+
+		hunter := w.Players[hunterID]
+
+		for _, preyID := range ids {
+			if preyID == hunterID || dead[preyID] {
+				continue
+			}
+
+			x, y, size := w.view(hunterID, w.Players[preyID])
+
+			if hunter.Size < size.Mul(eatRatio) || Hypot(hunter.X-x, hunter.Y-y) >= hunter.Size {
+				continue
+			}
+
+			hunter.Size += w.Players[preyID].Size / 2
+			dead[preyID] = true
+			eaten = append(eaten, preyID)
+
+			if w.observer != nil {
+				w.observer.PlayerEaten(hunterID, preyID)
+			}
+		}
+	}
+
+	for _, id := range eaten {
+		w.RemovePlayer(id)
+	}
+
+	return eaten
+}
+
+func (w *World) view(actor uint32, target *Player) (Fixed, Fixed, Fixed) {
+	if w.rewinder != nil {
+		if x, y, size, ok := w.rewinder.Rewind(actor, target.ID); ok {
+			return x, y, size
+		}
+	}
+
+	return target.X, target.Y, target.Size
+}
+
+func (w *World) spawnFood() {
+	w.nextID++
+	w.Foods[w.nextID] = &Food{
+		ID:   w.nextID,
+		X:    Fixed(w.rng.Int64N(int64(FixedFromInt(MapWidth)))),
+		Y:    Fixed(w.rng.Int64N(int64(FixedFromInt(MapHeight)))),
+		Size: FixedFromInt(FoodSize),
+	}
+}
+
+func (w *World) playerIDs() []uint32 {
+	ids := make([]uint32, 0, len(w.Players))
+	for id := range w.Players {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+func (w *World) foodIDs() []uint32 {
+	ids := make([]uint32, 0, len(w.Foods))
+	for id := range w.Foods {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// Snapshot возвращает состояние мира для рассылки узлам
+func (w *World) Snapshot() snapshot.Snapshot {
+	s := snapshot.Snapshot{Tick: w.Tick, Entities: make([]snapshot.Entity, 0, len(w.Players)+len(w.Foods))}
+
+	for _, p := range w.Players {
+		s.Entities = append(s.Entities, snapshot.Entity{ID: p.ID, Kind: snapshot.KindPlayer, X: p.X.Float(), Y: p.Y.Float(), Size: p.Size.Float()})
+	}
+
+	for _, f := range w.Foods {
+		s.Entities = append(s.Entities, snapshot.Entity{ID: f.ID, Kind: snapshot.KindFood, X: f.X.Float(), Y: f.Y.Float(), Size: f.Size.Float()})
+	}
+
+	return s
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFixed(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Fixed
+		want Fixed
+	}{
+		{"mul", FixedFromInt(3).Mul(FixedFromInt(4)), FixedFromInt(12)},
+		{"mul fraction", FixedFromInt(3).Mul(FixedOne / 2), FixedFromInt(3) / 2},
+		{"div", FixedFromInt(12).Div(FixedFromInt(4)), FixedFromInt(3)},
+		{"div by zero", FixedFromInt(12).Div(0), 0},
+		{"sqrt", FixedFromInt(16).Sqrt(), FixedFromInt(4)},
+		{"sqrt of negative", FixedFromInt(-4).Sqrt(), 0},
+		{"hypot", Hypot(FixedFromInt(3), FixedFromInt(4)), FixedFromInt(5)},
+		{"from float", FixedFromFloat(1.5), FixedFromInt(3) / 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.want {
+				t.Fatalf("got %v, want %v", test.got.Float(), test.want.Float())
+			}
+		})
+	}
+}
+
+func TestPlayerApply(t *testing.T) {
+	center := FixedFromInt(MapWidth / 2)
+	speed := NewPlayer(1, 0, 0).Speed()
+	edge := FixedFromInt(MapWidth - InitialPlayerSize)
+
+	tests := []struct {
+		name     string
+		x, y     Fixed
+		last     uint32
+		in       Input
+		wantX    Fixed
+		wantY    Fixed
+		wantLast uint32
+	}{
+		{"right", center, center, 0, Input{Seq: 1, DirX: 1}, center + speed, center, 1},
+		{"length does not matter", center, center, 0, Input{Seq: 1, DirY: -1000}, center, center - speed, 1},
+		{"no direction", center, center, 0, Input{Seq: 1}, center, center, 1},
+		{"nan", center, center, 0, Input{Seq: 1, DirX: float32(math.NaN())}, center, center, 1},
+		{"clamped to map", edge, center, 0, Input{Seq: 1, DirX: 1}, edge, center, 1},
+		// Устаревший ввод двигает игрока, но не откатывает номер
+		{"stale seq", center, center, 5, Input{Seq: 3, DirX: 1}, center + speed, center, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewPlayer(1, test.x, test.y)
+			p.LastInput = test.last
+			p.Apply(test.in)
+
+			if p.X != test.wantX || p.Y != test.wantY || p.LastInput != test.wantLast {
+				t.Fatalf("player = (%v, %v, %d), want (%v, %v, %d)",
+					p.X.Float(), p.Y.Float(), p.LastInput, test.wantX.Float(), test.wantY.Float(), test.wantLast)
+			}
+		})
+	}
+}
+
+func TestWorldStepDeterministic(t *testing.T) {
+	inputs := []Input{{Seq: 1, DirX: 1}, {Seq: 2, DirX: 0.3, DirY: -0.7}, {Seq: 3, DirY: 1}}
+
+	run := func() *World {
+		w := NewWorld(42)
+		a, b := w.AddPlayer(), w.AddPlayer()
+
+		for tick := range 200 {
+			in := inputs[tick%len(inputs)]
+			w.QueueInput(a.ID, in)
+			w.QueueInput(b.ID, Input{Seq: in.Seq, DirX: -in.DirX, DirY: in.DirY})
+			w.Step()
+		}
+
+		return w
+	}
+
+	first, second := run(), run()
+
+	if !reflect.DeepEqual(first.Players, second.Players) || !reflect.DeepEqual(first.Foods, second.Foods) {
+		t.Fatal("worlds with the same seed and inputs diverged")
+	}
+}
+
+func TestWorldEatPlayers(t *testing.T) {
+	size := FixedFromInt(InitialPlayerSize)
+
+	tests := []struct {
+		name       string
+		hunterSize Fixed
+		preySize   Fixed
+		distance   Fixed
+		wantEaten  bool
+	}{
+		{"bigger and close", 2 * size, size, FixedFromInt(5), true},
+		{"bigger but far", 2 * size, size, 3 * size, false},
+		{"not big enough", size + size/20, size, FixedFromInt(1), false},
+		{"same size", size, size, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := NewWorld(1)
+			w.Foods = map[uint32]*Food{}
+
+			hunter, prey := w.AddPlayer(), w.AddPlayer()
+			hunter.Size, prey.Size = test.hunterSize, test.preySize
+			prey.X += test.distance
+
+			eaten := w.Step()
+
+			if got := slices.Contains(eaten, prey.ID); got != test.wantEaten {
+				t.Fatalf("eaten = %v, want prey eaten %v", eaten, test.wantEaten)
+			}
+
+			if _, alive := w.Players[prey.ID]; alive == test.wantEaten {
+				t.Fatalf("prey alive = %v after Step", alive)
+			}
+
+			if test.wantEaten && hunter.Size != test.hunterSize+test.preySize/2 {
+				t.Fatalf("hunter size = %v, want %v", hunter.Size.Float(), (test.hunterSize + test.preySize/2).Float())
+			}
+		})
+	}
+}
+
+func TestWorldEatFood(t *testing.T) {
+	w := NewWorld(1)
+	w.Foods = map[uint32]*Food{}
+
+	player := w.AddPlayer()
+	w.Foods[100] = &Food{ID: 100, X: player.X + FixedFromInt(3), Y: player.Y, Size: FixedFromInt(FoodSize)}
+	w.Foods[101] = &Food{ID: 101, X: 0, Y: 0, Size: FixedFromInt(FoodSize)}
+
+	w.Step()
+
+	if _, ok := w.Foods[100]; ok {
+		t.Fatal("food under the player was not eaten")
+	}
+
+	if _, ok := w.Foods[101]; !ok {
+		t.Fatal("distant food was eaten")
+	}
+
+	// Съеденная еда заменяется новой
+	if len(w.Foods) != 2 {
+		t.Fatalf("food count = %d, want 2", len(w.Foods))
+	}
+
+	if want := FixedFromInt(InitialPlayerSize) + FixedFromInt(FoodSize)/2; player.Size != want {
+		t.Fatalf("player size = %v, want %v", player.Size.Float(), want.Float())
+	}
+}
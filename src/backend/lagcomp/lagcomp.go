@@ -0,0 +1,153 @@
+// Компенсация задержки: бэкенд хранит короткую историю положений игроков
+// и проверяет поедание по тому состоянию мира, которое видел охотник
+// в момент действия (серверное время минус половина RTT и задержка интерполяции)
+package lagcomp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+type Config struct {
+	// MaxRewind - максимальная глубина отката, защищает от злоупотребления огромным пингом
+	MaxRewind time.Duration
+	// InterpolationDelay - задержка рендера чужих игроков на узле (state.DefaultInterpolationDelay)
+	InterpolationDelay time.Duration
+	// HistorySize - сколько тиков истории хранить
+	HistorySize int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxRewind:          300 * time.Millisecond,
+		InterpolationDelay: 100 * time.Millisecond,
+		HistorySize:        game.TickRate,
+	}
+}
+
+type position struct {
+	x    game.Fixed
+	y    game.Fixed
+	size game.Fixed
+}
+
+type frame struct {
+	tick    uint32
+	at      time.Time
+	players map[uint32]position
+}
+
+// Compensator реализует game.Rewinder
+type Compensator struct {
+	mu     sync.Mutex
+	config Config
+	frames []frame
+	next   int
+	rtt    map[uint32]time.Duration
+	now    time.Time
+}
+
+func New(config Config) *Compensator {
+	if config.HistorySize <= 0 {
+		config.HistorySize = DefaultConfig().HistorySize
+	}
+
+	return &Compensator{
+		config: config,
+		frames: make([]frame, 0, config.HistorySize),
+		rtt:    map[uint32]time.Duration{},
+	}
+}
+
+// Record сохраняет положения игроков после тика, вызывается в игровом цикле после World.Step
+func (c *Compensator) Record(w *game.World, at time.Time) {
+	f := frame{tick: w.Tick, at: at, players: make(map[uint32]position, len(w.Players))}
+
+	for id, p := range w.Players {
+		f.players[id] = position{x: p.X, y: p.Y, size: p.Size}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = at
+
+	if len(c.frames) < cap(c.frames) {
+		c.frames = append(c.frames, f)
+		return
+	}
+
+	c.frames[c.next] = f
+	c.next = (c.next + 1) % len(c.frames)
+}
+
+// SetRTT обновляет оценку RTT игрока
+func (c *Compensator) SetRTT(playerID uint32, rtt time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rtt[playerID] = rtt
+}
+
+func (c *Compensator) Forget(playerID uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.rtt, playerID)
+}
+
+// ViewTime - момент, состояние мира в который видел игрок, с учетом ограничения MaxRewind
+func (c *Compensator) ViewTime(playerID uint32) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.viewTime(playerID)
+}
+
+func (c *Compensator) viewTime(playerID uint32) time.Time {
+	rewind := c.rtt[playerID]/2 + c.config.InterpolationDelay
+
+	return c.now.Add(-min(rewind, c.config.MaxRewind))
+}
+
+// Rewind возвращает положение target в момент, который видел actor,
+// интерполируя между двумя ближайшими сохраненными тиками
+func (c *Compensator) Rewind(actor, target uint32) (game.Fixed, game.Fixed, game.Fixed, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	at := c.viewTime(actor)
+
+	var before, after *frame
+
+	for i := range c.frames {
+		f := &c.frames[i]
+
+		if _, ok := f.players[target]; !ok {
+			continue
+		}
+
+		if !f.at.After(at) && (before == nil || f.at.After(before.at)) {
+			before = f
+		}
+
+		if f.at.After(at) && (after == nil || f.at.Before(after.at)) {
+			after = f
+		}
+	}
+
+	switch {
+	case before == nil:
+		return 0, 0, 0, false
+	case after == nil:
+		p := before.players[target]
+		return p.x, p.y, p.size, true
+	}
+
+	a, b := before.players[target], after.players[target]
+	k := game.Fixed(at.Sub(before.at)).Div(game.Fixed(after.at.Sub(before.at)))
+
+	return a.x + (b.x - a.x).Mul(k), a.y + (b.y - a.y).Mul(k), a.size + (b.size - a.size).Mul(k), true
+}
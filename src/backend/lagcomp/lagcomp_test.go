@@ -0,0 +1,75 @@
+package lagcomp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+// history записывает тики каждые 50 мс, цель сдвигается на 10 по X за тик.
+// Возвращает ID охотника и цели
+func history(c *Compensator, start time.Time, ticks int) (uint32, uint32) {
+	w := game.NewWorld(1)
+	actor, target := w.AddPlayer(), w.AddPlayer()
+
+	for i := range ticks {
+		w.Tick = uint32(i + 1)
+		target.X = game.FixedFromInt(10 * i)
+		c.Record(w, start.Add(time.Duration(i)*50*time.Millisecond))
+	}
+
+	return actor.ID, target.ID
+}
+
+func TestRewind(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		rtt    time.Duration
+		// unknown - цель, которой нет в истории
+		unknown bool
+		wantX   game.Fixed
+		wantOK  bool
+	}{
+		// Последний тик в 450 мс, откат на 100 мс задержки интерполяции
+		{"interpolation delay", Config{MaxRewind: time.Second, InterpolationDelay: 100 * time.Millisecond}, 0, false, game.FixedFromInt(70), true},
+		{"half rtt", Config{MaxRewind: time.Second}, 100 * time.Millisecond, false, game.FixedFromInt(80), true},
+		{"between ticks", Config{MaxRewind: time.Second}, 50 * time.Millisecond, false, game.FixedFromInt(85), true},
+		{"max rewind", Config{MaxRewind: 50 * time.Millisecond}, time.Second, false, game.FixedFromInt(80), true},
+		{"older than history", Config{MaxRewind: time.Second, InterpolationDelay: 200 * time.Millisecond, HistorySize: 4}, 0, false, 0, false},
+		{"before first tick", Config{MaxRewind: time.Second, InterpolationDelay: time.Second}, 0, false, 0, false},
+		{"unknown target", Config{MaxRewind: time.Second}, 0, true, 0, false},
+	}
+
+	start := time.Unix(1000, 0)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New(test.config)
+			actor, target := history(c, start, 10)
+			c.SetRTT(actor, test.rtt)
+
+			if test.unknown {
+				target++
+			}
+
+			x, _, _, ok := c.Rewind(actor, target)
+			if ok != test.wantOK || x != test.wantX {
+				t.Fatalf("Rewind = %v, %v, want %v, %v", x.Float(), ok, test.wantX.Float(), test.wantOK)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	c := New(Config{MaxRewind: time.Second})
+	actor, target := history(c, time.Unix(1000, 0), 10)
+	c.SetRTT(actor, 200*time.Millisecond)
+	c.Forget(actor)
+
+	// Без RTT игрок видит последний записанный тик
+	if x, _, _, ok := c.Rewind(actor, target); !ok || x != game.FixedFromInt(90) {
+		t.Fatalf("Rewind after Forget = %v, %v, want 90, true", x.Float(), ok)
+	}
+}
@@ -0,0 +1,488 @@
+// Жизненный цикл матчей: создание по требованию, распределение узлов,
+// завершение (лимит времени, ушел последний игрок) и очистка комнат.
+// В одном процессе бэкенда может идти несколько матчей
+package match
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/anticheat"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/backend/lagcomp"
+	"github.com/matelq/p2pmp/src/backend/snapshot"
+	"github.com/matelq/p2pmp/src/backend/stats"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+var (
+	ErrNotInMatch     = errors.New("match: node is not in a match")
+	ErrMatchNotFound  = errors.New("match: match not found")
+	ErrMatchFull      = errors.New("match: match is full")
+	ErrSpectator      = errors.New("match: spectators cannot send input")
+	ErrNotSpectating  = errors.New("match: node is not spectating")
+	ErrPlayerNotFound = errors.New("match: player not found")
+)
+
+// Sender доставляет снимки узлам (через transmitter или P2P)
+type Sender interface {
+	// playerID - игрок получателя в снимке (0 у зрителя), lastInput - его последний обработанный ввод
+	SendSnapshot(nodeID string, data []byte, playerID, lastInput uint32) error
+}
+
+// Rooms - комнаты на стороне сети: по комнате transmitter рассылает сообщения
+// участникам матча, а при закрытии рвет связанные с ней P2P-соединения
+type Rooms interface {
+	CreateRoom(roomID string) error
+	JoinRoom(roomID, nodeID string) error
+	LeaveRoom(roomID, nodeID string) error
+	CloseRoom(roomID string) error
+	// SpectateRoom добавляет узел в комнату зрителем: кадры ему идут с задержкой
+	SpectateRoom(roomID, nodeID string) error
+	// Publish рассылает сообщение всем участникам комнаты, включая зрителей
+	Publish(roomID string, e envelope.Envelope) error
+}
+
+type Config struct {
+	MaxPlayers int
+	TickRate   int
+	TimeLimit  time.Duration
+	// JoinTimeout - сколько созданный матч ждет первого игрока
+	JoinTimeout time.Duration
+	AntiCheat   anticheat.Config
+	LagComp     lagcomp.Config
+	// SpectatorDelay - задержка трансляции зрителям в transmitter (защита от подсказок
+	// игрокам). Бэкенд хранит историю снимков на это время, чтобы слать зрителям дельты
+	SpectatorDelay time.Duration
+	// SpectatorViewRadius - радиус области интереса вокруг игрока, за которым следит зритель
+	SpectatorViewRadius float32
+	// LeaderboardSize - сколько игроков в таблице лидеров, которая раз в секунду рассылается в комнату
+	LeaderboardSize int
+	// ReplayDir - каталог для записей матчей (см. пакет replay), пустой отключает запись
+	ReplayDir string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxPlayers:  20,
+		TickRate:    game.TickRate,
+		TimeLimit:   10 * time.Minute,
+		JoinTimeout: 30 * time.Second,
+		AntiCheat:   anticheat.DefaultConfig(),
+		LagComp:     lagcomp.DefaultConfig(),
+
+		SpectatorDelay:      3 * time.Second,
+		SpectatorViewRadius: snapshot.DefaultViewRadius,
+		LeaderboardSize:     stats.DefaultLeaderboardSize,
+	}
+}
+
+// Seed - зерно генератора еды для матча, выводится из его ID
+func (c Config) Seed(matchID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(matchID))
+
+	return h.Sum64()
+}
+
+type Manager struct {
+	config   Config
+	sender   Sender
+	rooms    Rooms
+	enforcer anticheat.Enforcer
+	store    stats.Store
+
+	mu      sync.Mutex
+	next    int
+	matches map[string]*Match
+	byNode  map[string]*Match
+	// spectating - матч, который смотрит узел-зритель
+	spectating map[string]*Match
+}
+
+func NewManager(config Config, sender Sender, rooms Rooms, enforcer anticheat.Enforcer) *Manager {
+	if config.TickRate <= 0 {
+		config.TickRate = game.TickRate
+	}
+
+	return &Manager{
+		config:   config,
+		sender:   sender,
+		rooms:    rooms,
+		enforcer: enforcer,
+		matches:  map[string]*Match{},
+		byNode:   map[string]*Match{},
+
+		spectating: map[string]*Match{},
+	}
+}
+
+// SetStore включает сохранение итогов завершенных матчей
+func (m *Manager) SetStore(store stats.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store = store
+}
+
+// Create создает пустой матч и запускает его игровой цикл
+func (m *Manager) Create() (*Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.create()
+}
+
+func (m *Manager) create() (*Match, error) {
+	m.next++
+
+	return m.createWithID(fmt.Sprintf("match-%d", m.next))
+}
+
+func (m *Manager) createWithID(id string) (*Match, error) {
+	return m.start(newMatch(id, m.config, m.sender, m.rooms, m.enforcer, m.ended))
+}
+
+// Restore продолжает матч из состояния, сохраненного Match.Save на другом хосте.
+// Узлы из сохраненного состояния сразу считаются участниками
+func (m *Manager) Restore(matchID string, data []byte) (*Match, error) {
+	match := newMatch(matchID, m.config, m.sender, m.rooms, m.enforcer, m.ended)
+
+	if err := match.restore(data); err != nil {
+		return nil, fmt.Errorf("cannot restore %s: %w", matchID, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.matches[matchID]; ok {
+		return nil, fmt.Errorf("match %s is already running", matchID)
+	}
+
+	members := make([]string, 0, len(match.players))
+	for nodeID := range match.players {
+		members = append(members, nodeID)
+	}
+
+	if _, err := m.start(match); err != nil {
+		return nil, err
+	}
+
+	for _, nodeID := range members {
+		if err := m.rooms.JoinRoom(match.ID, nodeID); err != nil {
+			match.log.Warn("cannot join room", logging.Node(nodeID), logging.Err(err))
+		}
+
+		m.byNode[nodeID] = match
+	}
+
+	return match, nil
+}
+
+// Get возвращает запущенный матч по ID
+func (m *Manager) Get(matchID string) (*Match, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	match, ok := m.matches[matchID]
+
+	return match, ok
+}
+
+func (m *Manager) start(match *Match) (*Match, error) {
+	id := match.ID
+
+	if err := m.rooms.CreateRoom(id); err != nil {
+		return nil, fmt.Errorf("cannot create room for %s: %w", id, err)
+	}
+
+	m.matches[id] = match
+
+	if m.config.ReplayDir != "" {
+		if err := match.record(m.config.ReplayDir); err != nil {
+			match.log.Error("cannot record replay", logging.Err(err))
+		}
+	}
+
+	go match.run()
+
+	return match, nil
+}
+
+// Assign добавляет узел в первый матч со свободным местом или в новый
+func (m *Manager) Assign(nodeID string) (*Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match, ok := m.byNode[nodeID]; ok {
+		return match, nil
+	}
+
+	for _, match := range m.matches {
+		if match.Info().State != StateEnded && !match.full() {
+			return match, m.join(match, nodeID)
+		}
+	}
+
+	match, err := m.create()
+	if err != nil {
+		return nil, err
+	}
+
+	return match, m.join(match, nodeID)
+}
+
+// Join добавляет узел в конкретный матч (например, выбранный матчмейкингом),
+// матч создается при подключении первого участника
+func (m *Manager) Join(matchID, nodeID string) (*Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	match, ok := m.matches[matchID]
+	if !ok {
+		var err error
+		if match, err = m.createWithID(matchID); err != nil {
+			return nil, err
+		}
+	}
+
+	current, playing := m.byNode[nodeID]
+	if current == match {
+		return match, nil
+	}
+
+	if match.full() {
+		return nil, ErrMatchFull
+	}
+
+	if playing {
+		m.leave(current, nodeID)
+	}
+
+	if current, ok := m.spectating[nodeID]; ok {
+		m.stopSpectating(current, nodeID)
+	}
+
+	return match, m.join(match, nodeID)
+}
+
+func (m *Manager) join(match *Match, nodeID string) error {
+	if err := m.rooms.JoinRoom(match.ID, nodeID); err != nil {
+		return err
+	}
+
+	match.join(nodeID)
+	m.byNode[nodeID] = match
+
+	return nil
+}
+
+// Spectate подключает узел к идущему матчу зрителем. Зрители не занимают
+// места игроков; если узел сам играл, он выходит из своего матча
+func (m *Manager) Spectate(matchID, nodeID string) (*Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	match, ok := m.matches[matchID]
+	if !ok {
+		return nil, ErrMatchNotFound
+	}
+
+	if current, ok := m.byNode[nodeID]; ok {
+		m.leave(current, nodeID)
+	}
+
+	if current, ok := m.spectating[nodeID]; ok && current != match {
+		m.stopSpectating(current, nodeID)
+	}
+
+	if err := m.rooms.SpectateRoom(matchID, nodeID); err != nil {
+		return nil, err
+	}
+
+	match.spectate(nodeID)
+	m.spectating[nodeID] = match
+
+	return match, nil
+}
+
+// Follow переключает камеру зрителя на другого игрока
+func (m *Manager) Follow(nodeID string, playerID uint32) error {
+	m.mu.Lock()
+	match, ok := m.spectating[nodeID]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrNotSpectating
+	}
+
+	return match.follow(nodeID, playerID)
+}
+
+// Leave убирает узел из его матча; матч без игроков завершается
+func (m *Manager) Leave(nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match, ok := m.byNode[nodeID]; ok {
+		m.leave(match, nodeID)
+	}
+
+	if match, ok := m.spectating[nodeID]; ok {
+		m.stopSpectating(match, nodeID)
+	}
+}
+
+func (m *Manager) stopSpectating(match *Match, nodeID string) {
+	delete(m.spectating, nodeID)
+
+	if err := m.rooms.LeaveRoom(match.ID, nodeID); err != nil {
+		match.log.Warn("cannot leave room", logging.Node(nodeID), logging.Err(err))
+	}
+
+	match.unspectate(nodeID)
+}
+
+func (m *Manager) leave(match *Match, nodeID string) {
+	delete(m.byNode, nodeID)
+
+	if err := m.rooms.LeaveRoom(match.ID, nodeID); err != nil {
+		match.log.Warn("cannot leave room", logging.Node(nodeID), logging.Err(err))
+	}
+
+	if match.leave(nodeID) {
+		match.end(EndNoPlayers)
+	}
+}
+
+func (m *Manager) Input(nodeID string, in game.Input) error {
+	match, err := m.matchOf(nodeID)
+	if err != nil {
+		return err
+	}
+
+	match.input(nodeID, in)
+
+	return nil
+}
+
+// Ack принимает подтверждение снимка от игрока или зрителя
+func (m *Manager) Ack(nodeID string, tick uint32) error {
+	m.mu.Lock()
+	match, ok := m.byNode[nodeID]
+	if !ok {
+		match, ok = m.spectating[nodeID]
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrNotInMatch
+	}
+
+	match.ack(nodeID, tick)
+
+	return nil
+}
+
+func (m *Manager) SetRTT(nodeID string, rtt time.Duration) {
+	if match, err := m.matchOf(nodeID); err == nil {
+		match.setRTT(nodeID, rtt)
+	}
+}
+
+func (m *Manager) matchOf(nodeID string) (*Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	match, ok := m.byNode[nodeID]
+	if !ok {
+		if _, ok := m.spectating[nodeID]; ok {
+			return nil, ErrSpectator
+		}
+
+		return nil, ErrNotInMatch
+	}
+
+	return match, nil
+}
+
+func (m *Manager) Matches() []Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]Info, 0, len(m.matches))
+	for _, match := range m.matches {
+		infos = append(infos, match.Info())
+	}
+
+	return infos
+}
+
+// End досрочно завершает матч
+func (m *Manager) End(matchID string) error {
+	m.mu.Lock()
+	match, ok := m.matches[matchID]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrMatchNotFound
+	}
+
+	match.end(EndShutdown)
+	<-match.done
+
+	return nil
+}
+
+// Shutdown завершает все матчи и ждет остановки их игровых циклов
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	matches := make([]*Match, 0, len(m.matches))
+	for _, match := range m.matches {
+		matches = append(matches, match)
+	}
+	m.mu.Unlock()
+
+	for _, match := range matches {
+		match.end(EndShutdown)
+	}
+
+	for _, match := range matches {
+		<-match.done
+	}
+}
+
+// ended вызывается из игрового цикла матча после его завершения
+func (m *Manager) ended(match *Match, reason EndReason) {
+	m.mu.Lock()
+	delete(m.matches, match.ID)
+
+	for nodeID, current := range m.byNode {
+		if current == match {
+			delete(m.byNode, nodeID)
+		}
+	}
+
+	for nodeID, current := range m.spectating {
+		if current == match {
+			delete(m.spectating, nodeID)
+		}
+	}
+
+	store := m.store
+	m.mu.Unlock()
+
+	if result := match.result(reason, time.Now()); store != nil && len(result.Players) > 0 {
+		if err := store.SaveMatch(result); err != nil {
+			match.log.Error("cannot save results", logging.Err(err))
+		}
+	}
+
+	if err := m.rooms.CloseRoom(match.ID); err != nil {
+		match.log.Warn("cannot close room", logging.Err(err))
+	}
+}
@@ -0,0 +1,342 @@
+package match
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/backend/stats"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+)
+
+// network - подделка transmitter: снимки отбрасываются, комнаты запоминаются
+type network struct {
+	mu     sync.Mutex
+	rooms  map[string]map[string]bool
+	closed map[string]bool
+}
+
+func newNetwork() *network {
+	return &network{rooms: map[string]map[string]bool{}, closed: map[string]bool{}}
+}
+
+func (n *network) SendSnapshot(nodeID string, data []byte, playerID, lastInput uint32) error {
+	return nil
+}
+
+func (n *network) CreateRoom(roomID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.rooms[roomID] = map[string]bool{}
+
+	return nil
+}
+
+func (n *network) JoinRoom(roomID, nodeID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.rooms[roomID][nodeID] = true
+
+	return nil
+}
+
+func (n *network) SpectateRoom(roomID, nodeID string) error {
+	return n.JoinRoom(roomID, nodeID)
+}
+
+func (n *network) LeaveRoom(roomID, nodeID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.rooms[roomID], nodeID)
+
+	return nil
+}
+
+func (n *network) CloseRoom(roomID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.closed[roomID] = true
+
+	return nil
+}
+
+func (n *network) Publish(roomID string, e envelope.Envelope) error {
+	return nil
+}
+
+func (n *network) in(roomID, nodeID string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.rooms[roomID][nodeID]
+}
+
+func (n *network) members(roomID string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.rooms[roomID])
+}
+
+func (n *network) isClosed(roomID string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.closed[roomID]
+}
+
+// store запоминает сохраненные итоги
+type store struct {
+	mu      sync.Mutex
+	results []stats.MatchResult
+}
+
+func (s *store) SaveMatch(result stats.MatchResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results = append(s.results, result)
+
+	return nil
+}
+
+func (s *store) Recent(limit int) ([]stats.MatchResult, error) {
+	return nil, nil
+}
+
+func (s *store) Close() error {
+	return nil
+}
+
+func testConfig() Config {
+	config := DefaultConfig()
+	config.MaxPlayers = 2
+	config.TickRate = 100
+	config.JoinTimeout = 0
+	config.TimeLimit = 0
+	config.LeaderboardSize = 0
+
+	return config
+}
+
+func newTestManager(t *testing.T, config Config) (*Manager, *network) {
+	n := newNetwork()
+	m := NewManager(config, n, n, nil)
+	t.Cleanup(m.Shutdown)
+
+	return m, n
+}
+
+func TestManagerAssign(t *testing.T) {
+	m, n := newTestManager(t, testConfig())
+
+	assigned := map[string]string{}
+	for _, nodeID := range []string{"a", "b", "c", "a"} {
+		match, err := m.Assign(nodeID)
+		if err != nil {
+			t.Fatalf("Assign(%s): %v", nodeID, err)
+		}
+
+		if previous, ok := assigned[nodeID]; ok && previous != match.ID {
+			t.Fatalf("Assign(%s) moved the node from %s to %s", nodeID, previous, match.ID)
+		}
+
+		assigned[nodeID] = match.ID
+	}
+
+	// MaxPlayers = 2: третий узел попадает в новый матч
+	if assigned["a"] != assigned["b"] || assigned["c"] == assigned["a"] {
+		t.Fatalf("assigned = %v, want a and b together and c apart", assigned)
+	}
+
+	if got := n.members(assigned["a"]); got != 2 {
+		t.Fatalf("room %s has %d members, want 2", assigned["a"], got)
+	}
+}
+
+func TestManagerJoin(t *testing.T) {
+	tests := []struct {
+		name      string
+		before    map[string]string
+		nodeID    string
+		matchID   string
+		wantErr   error
+		wantMatch string
+	}{
+		{"creates the match", nil, "a", "m1", nil, "m1"},
+		{"already there", map[string]string{"a": "m1"}, "a", "m1", nil, "m1"},
+		{"full", map[string]string{"a": "m1", "b": "m1"}, "c", "m1", ErrMatchFull, ""},
+		{"moves between matches", map[string]string{"a": "m1", "b": "m2"}, "a", "m2", nil, "m2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, n := newTestManager(t, testConfig())
+
+			for nodeID, matchID := range test.before {
+				if _, err := m.Join(matchID, nodeID); err != nil {
+					t.Fatalf("Join(%s, %s): %v", matchID, nodeID, err)
+				}
+			}
+
+			match, err := m.Join(test.matchID, test.nodeID)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Join error = %v, want %v", err, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if current, _ := m.matchOf(test.nodeID); match.ID != test.wantMatch || current != match {
+				t.Fatalf("node is in %v, want %s", current, test.wantMatch)
+			}
+
+			for _, info := range m.Matches() {
+				if info.ID != test.wantMatch && n.in(info.ID, test.nodeID) {
+					t.Fatalf("node is still in room %s", info.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerInputErrors(t *testing.T) {
+	m, _ := newTestManager(t, testConfig())
+
+	match, err := m.Join("m1", "player")
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+
+	if _, err := m.Spectate(match.ID, "viewer"); err != nil {
+		t.Fatalf("Spectate: %v", err)
+	}
+
+	tests := []struct {
+		nodeID  string
+		wantErr error
+	}{
+		{"player", nil},
+		{"viewer", ErrSpectator},
+		{"stranger", ErrNotInMatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.nodeID, func(t *testing.T) {
+			if err := m.Input(test.nodeID, game.Input{Seq: 1, DirX: 1}); !errors.Is(err, test.wantErr) {
+				t.Fatalf("Input error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestManagerEnd(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     func(*Config)
+		leave      bool
+		wantReason EndReason
+	}{
+		{"last player left", func(*Config) {}, true, EndNoPlayers},
+		{"time limit", func(c *Config) { c.TimeLimit = 50 * time.Millisecond }, false, EndTimeLimit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig()
+			test.config(&config)
+
+			m, n := newTestManager(t, config)
+			s := &store{}
+			m.SetStore(s)
+
+			match, err := m.Join("m1", "a")
+			if err != nil {
+				t.Fatalf("Join: %v", err)
+			}
+
+			if test.leave {
+				m.Leave("a")
+			}
+
+			select {
+			case <-match.done:
+			case <-time.After(time.Second):
+				t.Fatal("match did not end")
+			}
+
+			if _, ok := m.Get(match.ID); ok || !n.isClosed(match.ID) {
+				t.Fatal("ended match was not torn down")
+			}
+
+			if _, err := m.matchOf("a"); !errors.Is(err, ErrNotInMatch) {
+				t.Fatalf("node still in a match after it ended: %v", err)
+			}
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if len(s.results) != 1 || s.results[0].Reason != string(test.wantReason) {
+				t.Fatalf("saved results = %+v, want one with reason %s", s.results, test.wantReason)
+			}
+		})
+	}
+}
+
+func TestManagerJoinTimeout(t *testing.T) {
+	config := testConfig()
+	config.JoinTimeout = 20 * time.Millisecond
+
+	m, n := newTestManager(t, config)
+
+	match, err := m.Create()
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	select {
+	case <-match.done:
+	case <-time.After(time.Second):
+		t.Fatal("empty match did not end")
+	}
+
+	if !n.isClosed(match.ID) {
+		t.Fatal("room of the empty match was not closed")
+	}
+}
+
+func TestRespawnKeepsLastInput(t *testing.T) {
+	n := newNetwork()
+	config := testConfig()
+	match := newMatch("m1", config, n, n, nil, func(*Match, EndReason) {})
+
+	hunterID, preyID := match.join("hunter"), match.join("prey")
+	match.input("prey", game.Input{Seq: 7})
+
+	// Добыча стоит рядом с вдвое большим охотником и будет съедена на этом тике
+	hunter, prey := match.world.Players[hunterID], match.world.Players[preyID]
+	hunter.Size = 2 * prey.Size
+
+	match.tick(time.Now())
+
+	respawned, ok := match.world.Players[match.players["prey"]]
+	if !ok || respawned.ID == preyID {
+		t.Fatal("prey was not respawned")
+	}
+
+	if respawned.LastInput != 7 {
+		t.Fatalf("LastInput after respawn = %d, want 7", respawned.LastInput)
+	}
+
+	// Повтор уже обработанного ввода после респауна отбрасывается
+	if _, ok := match.validator.Input(respawned.ID, game.Input{Seq: 7}); ok {
+		t.Fatal("input processed before respawn was accepted again")
+	}
+}
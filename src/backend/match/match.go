@@ -0,0 +1,560 @@
+package match
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/anticheat"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/backend/lagcomp"
+	"github.com/matelq/p2pmp/src/backend/replay"
+	"github.com/matelq/p2pmp/src/backend/snapshot"
+	"github.com/matelq/p2pmp/src/backend/stats"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+type State int
+
+const (
+	StateWaiting State = iota
+	StateRunning
+	StateEnded
+)
+
+func (s State) String() string {
+	switch s {
+	case StateWaiting:
+		return "waiting"
+	case StateRunning:
+		return "running"
+	default:
+		return "ended"
+	}
+}
+
+type EndReason string
+
+const (
+	EndTimeLimit    EndReason = "time_limit"
+	EndNoPlayers    EndReason = "no_players"
+	EndShutdown     EndReason = "shutdown"
+	EndNobodyJoined EndReason = "nobody_joined"
+)
+
+// Match - одна игра: своя симуляция, свой игровой цикл в отдельной горутине
+type Match struct {
+	ID      string
+	config  Config
+	sender  Sender
+	rooms   Rooms
+	onEnd   func(*Match, EndReason)
+	created time.Time
+	// log дополняет записи ID матча
+	log *slog.Logger
+
+	mu        sync.Mutex
+	state     State
+	started   time.Time
+	world     *game.World
+	encoder   *snapshot.Encoder
+	validator *anticheat.Validator
+	lagcomp   *lagcomp.Compensator
+	stats     *stats.Tracker
+	// recorder - запись повтора, nil если запись выключена
+	recorder *replay.Recorder
+	// players - игрок в симуляции для каждого узла
+	players map[string]uint32
+	// spectators - зрители: получают мир, но не управляют игроком и не занимают место в матче
+	spectators map[string]*spectator
+
+	// leaderboards - неотправленная таблица лидеров. Рассылка идет вызовом на
+	// каждого участника комнаты, поэтому ее делает publish, а не цикл тиков.
+	// Новая таблица вытесняет неотправленную
+	leaderboards chan []byte
+
+	stop chan EndReason
+	done chan struct{}
+}
+
+func newMatch(id string, config Config, sender Sender, rooms Rooms, enforcer anticheat.Enforcer, onEnd func(*Match, EndReason)) *Match {
+	world := game.NewWorld(config.Seed(id))
+	compensator := lagcomp.New(config.LagComp)
+	world.SetRewinder(compensator)
+	tracker := stats.NewTracker()
+	world.SetObserver(tracker)
+
+	return &Match{
+		ID:         id,
+		config:     config,
+		sender:     sender,
+		rooms:      rooms,
+		onEnd:      onEnd,
+		created:    time.Now(),
+		log:        slog.With(logging.Room(id)),
+		world:      world,
+		encoder:    snapshot.NewEncoder(snapshot.DefaultHistorySize),
+		validator:  anticheat.New(config.AntiCheat, enforcer),
+		lagcomp:    compensator,
+		stats:      tracker,
+		players:    map[string]uint32{},
+		spectators: map[string]*spectator{},
+
+		leaderboards: make(chan []byte, 1),
+		stop:         make(chan EndReason, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// spectator - зритель матча. Кадры ему идут с задержкой transmitter,
+// поэтому у него свой кодировщик с историей на всю задержку
+type spectator struct {
+	// follow - узел игрока, за которым следит камера; пустой - первый по порядку
+	follow  string
+	encoder *snapshot.Encoder
+}
+
+// savedMatch - состояние матча для передачи другому хосту
+type savedMatch struct {
+	World   []byte
+	Players map[string]uint32
+	Started time.Time
+}
+
+// Save сохраняет мир и привязку узлов к игрокам
+func (m *Match) Save() ([]byte, uint32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	world, err := m.world.MarshalBinary()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(savedMatch{World: world, Players: m.players, Started: m.started}); err != nil {
+		return nil, 0, err
+	}
+
+	return buf.Bytes(), m.world.Tick, nil
+}
+
+func (m *Match) restore(data []byte) error {
+	var saved savedMatch
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&saved); err != nil {
+		return err
+	}
+
+	if err := m.world.UnmarshalBinary(saved.World); err != nil {
+		return err
+	}
+
+	for nodeID, id := range saved.Players {
+		if player, ok := m.world.Players[id]; ok {
+			m.players[nodeID] = id
+			m.validator.Register(nodeID, player)
+			m.stats.Join(nodeID, player, time.Now())
+		}
+	}
+
+	if len(m.players) > 0 {
+		m.state = StateRunning
+		m.started = saved.Started
+	}
+
+	return nil
+}
+
+// record начинает запись повтора матча в каталог dir
+func (m *Match) record(dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.Create(filepath.Join(dir, m.ID+".replay"))
+	if err != nil {
+		return err
+	}
+
+	header := replay.Header{
+		MatchID:   m.ID,
+		Seed:      m.config.Seed(m.ID),
+		MapWidth:  game.MapWidth,
+		MapHeight: game.MapHeight,
+		TickRate:  uint32(m.config.TickRate),
+	}
+
+	recorder, err := replay.NewRecorder(f, header, m.world, replay.DefaultKeyframeInterval)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	m.recorder = recorder
+	m.world.SetRewinder(recorder.Rewinder(m.lagcomp))
+
+	return nil
+}
+
+// Info - краткое описание матча для логов и админки
+type Info struct {
+	ID      string
+	State   State
+	Players int
+	Tick    uint32
+	Started time.Time
+}
+
+func (m *Match) Info() Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return Info{ID: m.ID, State: m.state, Players: len(m.players), Tick: m.world.Tick, Started: m.started}
+}
+
+func (m *Match) full() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.config.MaxPlayers > 0 && len(m.players) >= m.config.MaxPlayers
+}
+
+func (m *Match) join(nodeID string) uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.players[nodeID]; ok {
+		return id
+	}
+
+	return m.spawn(nodeID, 0)
+}
+
+// spawn добавляет игрока узла в мир. lastInput - номер последнего принятого
+// ввода прошлого воплощения: без него узел после возрождения заново
+// проиграл бы свои уже обработанные вводы
+func (m *Match) spawn(nodeID string, lastInput uint32) uint32 {
+	player := m.world.AddPlayer()
+	player.LastInput = lastInput
+	m.players[nodeID] = player.ID
+
+	if m.recorder != nil {
+		m.recorder.Join(player.ID)
+	}
+
+	m.validator.Register(nodeID, player)
+	m.stats.Join(nodeID, player, time.Now())
+
+	if m.state == StateWaiting {
+		m.state = StateRunning
+		m.started = time.Now()
+	}
+
+	return player.ID
+}
+
+// leave возвращает true, если в матче больше никого не осталось
+func (m *Match) leave(nodeID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.players[nodeID]; ok {
+		m.world.RemovePlayer(id)
+
+		if m.recorder != nil {
+			m.recorder.Leave(id)
+		}
+
+		m.validator.Unregister(id)
+		m.lagcomp.Forget(id)
+		m.encoder.Forget(nodeID)
+		m.stats.Leave(nodeID, time.Now())
+		delete(m.players, nodeID)
+	}
+
+	return len(m.players) == 0
+}
+
+func (m *Match) input(nodeID string, in game.Input) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, ok := m.players[nodeID]
+	if !ok {
+		return
+	}
+
+	if in, ok = m.validator.Input(id, in); !ok {
+		return
+	}
+
+	m.world.QueueInput(id, in)
+
+	if m.recorder != nil {
+		m.recorder.Input(id, in)
+	}
+}
+
+func (m *Match) ack(nodeID string, tick uint32) {
+	m.mu.Lock()
+	s, ok := m.spectators[nodeID]
+	m.mu.Unlock()
+
+	if ok {
+		s.encoder.Ack(nodeID, tick)
+		return
+	}
+
+	m.encoder.Ack(nodeID, tick)
+}
+
+func (m *Match) spectate(nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.spectators[nodeID]; ok {
+		return
+	}
+
+	history := int(m.config.SpectatorDelay*time.Duration(m.config.TickRate)/time.Second) + snapshot.DefaultHistorySize
+	m.spectators[nodeID] = &spectator{encoder: snapshot.NewEncoder(history)}
+}
+
+func (m *Match) unspectate(nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.spectators, nodeID)
+}
+
+// follow переключает камеру зрителя на игрока playerID
+func (m *Match) follow(nodeID string, playerID uint32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.spectators[nodeID]
+	if !ok {
+		return ErrNotSpectating
+	}
+
+	for target, id := range m.players {
+		if id == playerID {
+			s.follow = target
+			return nil
+		}
+	}
+
+	return ErrPlayerNotFound
+}
+
+// followed - игрок, за которым следит зритель. Если он ушел, камера
+// переходит к игроку с наименьшим ID, чтобы у всех зрителей выбор был одинаковым
+func (m *Match) followed(s *spectator) (*game.Player, bool) {
+	if id, ok := m.players[s.follow]; ok {
+		return m.world.Players[id], true
+	}
+
+	var first *game.Player
+
+	for nodeID, id := range m.players {
+		if p := m.world.Players[id]; first == nil || p.ID < first.ID {
+			first, s.follow = p, nodeID
+		}
+	}
+
+	return first, first != nil
+}
+
+func (m *Match) setRTT(nodeID string, rtt time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.players[nodeID]; ok {
+		m.lagcomp.SetRTT(id, rtt)
+	}
+}
+
+// end просит игровой цикл завершиться, повторные вызовы игнорируются
+func (m *Match) end(reason EndReason) {
+	select {
+	case m.stop <- reason:
+	default:
+	}
+}
+
+func (m *Match) run() {
+	defer close(m.done)
+
+	go m.publish()
+
+	ticker := time.NewTicker(time.Second / time.Duration(m.config.TickRate))
+	defer ticker.Stop()
+
+	m.log.Info("match started")
+
+	for {
+		select {
+		case reason := <-m.stop:
+			m.finish(reason)
+			return
+		case now := <-ticker.C:
+			if reason, over := m.tick(now); over {
+				m.finish(reason)
+				return
+			}
+		}
+	}
+}
+
+func (m *Match) tick(now time.Time) (EndReason, bool) {
+	m.mu.Lock()
+
+	switch {
+	case m.state == StateWaiting && m.config.JoinTimeout > 0 && now.Sub(m.created) > m.config.JoinTimeout:
+		m.mu.Unlock()
+		return EndNobodyJoined, true
+	case m.state == StateRunning && m.config.TimeLimit > 0 && now.Sub(m.started) > m.config.TimeLimit:
+		m.mu.Unlock()
+		return EndTimeLimit, true
+	}
+
+	m.stats.Tick(now)
+	eaten := m.world.Step()
+	m.stats.Update(m.world)
+	corrected := m.validator.Check(m.world)
+	m.lagcomp.Record(m.world, now)
+
+	if m.recorder != nil {
+		m.recorder.Step()
+
+		for _, id := range corrected {
+			m.recorder.Correction(m.world.Players[id])
+		}
+	}
+
+	// Съеденные игроки появляются заново
+	for _, id := range eaten {
+		lastInput := m.validator.LastInput(id)
+		m.validator.Unregister(id)
+		m.lagcomp.Forget(id)
+
+		for nodeID, playerID := range m.players {
+			if playerID == id {
+				m.spawn(nodeID, lastInput)
+			}
+		}
+	}
+
+	if m.recorder != nil {
+		m.recorder.EndTick(m.world)
+	}
+
+	tick := m.world.Tick
+	world := m.world.Snapshot()
+	m.encoder.Push(world)
+
+	recipients := make(map[string]uint32, len(m.players))
+	lastInputs := make(map[string]uint32, len(m.players))
+	for nodeID, id := range m.players {
+		recipients[nodeID] = id
+		lastInputs[nodeID] = m.world.Players[id].LastInput
+	}
+
+	// Зрителям - только то, что видно вокруг игрока, за которым следит камера
+	spectators := make(map[string]*spectator, len(m.spectators))
+	for nodeID, s := range m.spectators {
+		view := world
+		if p, ok := m.followed(s); ok {
+			view = snapshot.AreaOfInterest(world, p.X.Float(), p.Y.Float(), m.config.SpectatorViewRadius)
+		}
+
+		s.encoder.Push(view)
+		spectators[nodeID] = s
+	}
+
+	m.mu.Unlock()
+
+	for nodeID, id := range recipients {
+		if err := m.sender.SendSnapshot(nodeID, m.encoder.Encode(nodeID), id, lastInputs[nodeID]); err != nil {
+			m.log.Warn("cannot send snapshot", logging.Node(nodeID), logging.Err(err))
+		}
+	}
+
+	for nodeID, s := range spectators {
+		if err := m.sender.SendSnapshot(nodeID, s.encoder.Encode(nodeID), 0, 0); err != nil {
+			m.log.Warn("cannot send snapshot to spectator", logging.Node(nodeID), logging.Err(err))
+		}
+	}
+
+	if m.config.LeaderboardSize > 0 && tick%uint32(m.config.TickRate) == 0 {
+		m.putLeaderboard(stats.MarshalLeaderboard(m.stats.Leaderboard(m.config.LeaderboardSize)))
+	}
+
+	return "", false
+}
+
+func (m *Match) putLeaderboard(board []byte) {
+	for {
+		select {
+		case m.leaderboards <- board:
+			return
+		default:
+		}
+
+		select {
+		case <-m.leaderboards:
+		default:
+		}
+	}
+}
+
+// publish рассылает таблицы лидеров в комнату, пока матч не закончился
+func (m *Match) publish() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case board := <-m.leaderboards:
+			if err := m.rooms.Publish(m.ID, envelope.Envelope{Type: envelope.TypeLeaderboard, Payload: board}); err != nil {
+				m.log.Warn("cannot publish leaderboard", logging.Err(err))
+			}
+		}
+	}
+}
+
+// result - итоги матча для stats.Store
+func (m *Match) result(reason EndReason, at time.Time) stats.MatchResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return stats.MatchResult{
+		MatchID: m.ID,
+		Started: m.started,
+		Ended:   at,
+		Reason:  string(reason),
+		Players: m.stats.Results(at),
+	}
+}
+
+func (m *Match) finish(reason EndReason) {
+	m.mu.Lock()
+	m.state = StateEnded
+
+	if m.recorder != nil {
+		if err := m.recorder.Close(); err != nil {
+			m.log.Error("cannot write replay", logging.Err(err))
+		}
+
+		m.recorder = nil
+	}
+
+	m.mu.Unlock()
+
+	m.log.Info("match ended", "reason", reason)
+
+	m.onEnd(m, reason)
+}
@@ -0,0 +1,347 @@
+// Запись матчей для воспроизведения багов. Файл повтора - заголовок
+// (версия, зерно, размер карты) и поток записей: все, что меняло мир
+// бэкенда (вход и выход игроков, принятый ввод, ответы компенсации задержки,
+// поправки античита), метки конца тика с хешем состояния и периодические
+// ключевые кадры с полным состоянием для перемотки
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+const Version = 1
+
+var magic = [4]byte{'P', '2', 'P', 'R'}
+
+// maxBytes ограничивает ключевой кадр и ID матча при чтении битого файла
+const maxBytes = 64 << 20
+
+var (
+	ErrMalformed = errors.New("replay: malformed replay")
+	ErrVersion   = errors.New("replay: unsupported version")
+)
+
+type Header struct {
+	Version   uint32
+	MatchID   string
+	Seed      uint64
+	MapWidth  uint32
+	MapHeight uint32
+	TickRate  uint32
+}
+
+type Kind uint8
+
+const (
+	KindJoin Kind = iota + 1
+	KindLeave
+	KindInput
+	// KindRewind - ответ компенсации задержки во время шага симуляции
+	KindRewind
+	KindStep
+	// KindCorrection - положение игрока после поправки античита
+	KindCorrection
+	// KindTick - конец тика и хеш состояния после него
+	KindTick
+	// KindKeyframe - полное состояние мира (game.World.MarshalBinary)
+	KindKeyframe
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindJoin:
+		return "join"
+	case KindLeave:
+		return "leave"
+	case KindInput:
+		return "input"
+	case KindRewind:
+		return "rewind"
+	case KindStep:
+		return "step"
+	case KindCorrection:
+		return "correction"
+	case KindTick:
+		return "tick"
+	case KindKeyframe:
+		return "keyframe"
+	default:
+		return "unknown"
+	}
+}
+
+// Record - одна запись; какие поля заполнены, зависит от Kind
+type Record struct {
+	Kind   Kind
+	Player uint32
+	// Target - цель для KindRewind (Player - тот, кто ест)
+	Target uint32
+	Input  game.Input
+	X      game.Fixed
+	Y      game.Fixed
+	Size   game.Fixed
+	OK     bool
+	Tick   uint32
+	Hash   uint64
+	State  []byte
+}
+
+type Writer struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+
+	buf := append([]byte(nil), magic[:]...)
+	buf = binary.AppendUvarint(buf, Version)
+	buf = binary.AppendUvarint(buf, uint64(len(h.MatchID)))
+	buf = append(buf, h.MatchID...)
+	buf = binary.LittleEndian.AppendUint64(buf, h.Seed)
+	buf = binary.AppendUvarint(buf, uint64(h.MapWidth))
+	buf = binary.AppendUvarint(buf, uint64(h.MapHeight))
+	buf = binary.AppendUvarint(buf, uint64(h.TickRate))
+
+	if _, err := bw.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: bw}, nil
+}
+
+func (w *Writer) Write(r Record) error {
+	buf := append(w.buf[:0], byte(r.Kind))
+
+	switch r.Kind {
+	case KindJoin, KindLeave:
+		buf = binary.AppendUvarint(buf, uint64(r.Player))
+	case KindInput:
+		buf = binary.AppendUvarint(buf, uint64(r.Player))
+		buf = binary.AppendUvarint(buf, uint64(r.Input.Seq))
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(r.Input.DirX))
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(r.Input.DirY))
+	case KindRewind:
+		buf = binary.AppendUvarint(buf, uint64(r.Player))
+		buf = binary.AppendUvarint(buf, uint64(r.Target))
+		buf = append(buf, boolByte(r.OK))
+		buf = binary.AppendVarint(buf, int64(r.X))
+		buf = binary.AppendVarint(buf, int64(r.Y))
+		buf = binary.AppendVarint(buf, int64(r.Size))
+	case KindStep:
+	case KindCorrection:
+		buf = binary.AppendUvarint(buf, uint64(r.Player))
+		buf = binary.AppendVarint(buf, int64(r.X))
+		buf = binary.AppendVarint(buf, int64(r.Y))
+	case KindTick:
+		buf = binary.AppendUvarint(buf, uint64(r.Tick))
+		buf = binary.LittleEndian.AppendUint64(buf, r.Hash)
+	case KindKeyframe:
+		buf = binary.AppendUvarint(buf, uint64(r.Tick))
+		buf = binary.AppendUvarint(buf, uint64(len(r.State)))
+		buf = append(buf, r.State...)
+	default:
+		return fmt.Errorf("replay: unknown record kind %d", r.Kind)
+	}
+
+	w.buf = buf
+	_, err := w.w.Write(buf)
+
+	return err
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) (*Reader, Header, error) {
+	br := bufio.NewReader(r)
+	rd := &Reader{r: br}
+
+	var m [4]byte
+	if _, err := io.ReadFull(br, m[:]); err != nil || m != magic {
+		return nil, Header{}, ErrMalformed
+	}
+
+	var h Header
+
+	version, err := rd.uvarint()
+	if err != nil {
+		return nil, Header{}, err
+	}
+
+	if version != Version {
+		return nil, Header{}, fmt.Errorf("%w: %d", ErrVersion, version)
+	}
+
+	h.Version = uint32(version)
+
+	id, err := rd.bytes()
+	if err != nil {
+		return nil, Header{}, err
+	}
+
+	h.MatchID = string(id)
+
+	if h.Seed, err = rd.uint64(); err != nil {
+		return nil, Header{}, err
+	}
+
+	for _, v := range []*uint32{&h.MapWidth, &h.MapHeight, &h.TickRate} {
+		n, err := rd.uvarint()
+		if err != nil {
+			return nil, Header{}, err
+		}
+
+		*v = uint32(n)
+	}
+
+	return rd, h, nil
+}
+
+// Next читает следующую запись, в конце файла возвращает io.EOF
+func (r *Reader) Next() (Record, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return Record{}, err
+	}
+
+	rec := Record{Kind: Kind(b)}
+
+	switch rec.Kind {
+	case KindJoin, KindLeave:
+		rec.Player, err = r.uint32()
+	case KindInput:
+		if rec.Player, err = r.uint32(); err != nil {
+			break
+		}
+
+		if rec.Input.Seq, err = r.uint32(); err != nil {
+			break
+		}
+
+		var bits [8]byte
+		if _, err = io.ReadFull(r.r, bits[:]); err != nil {
+			break
+		}
+
+		rec.Input.DirX = math.Float32frombits(binary.LittleEndian.Uint32(bits[:]))
+		rec.Input.DirY = math.Float32frombits(binary.LittleEndian.Uint32(bits[4:]))
+	case KindRewind:
+		if rec.Player, err = r.uint32(); err != nil {
+			break
+		}
+
+		if rec.Target, err = r.uint32(); err != nil {
+			break
+		}
+
+		var ok byte
+		if ok, err = r.r.ReadByte(); err != nil {
+			break
+		}
+
+		rec.OK = ok != 0
+		err = r.fixed(&rec.X, &rec.Y, &rec.Size)
+	case KindStep:
+	case KindCorrection:
+		if rec.Player, err = r.uint32(); err != nil {
+			break
+		}
+
+		err = r.fixed(&rec.X, &rec.Y)
+	case KindTick:
+		if rec.Tick, err = r.uint32(); err != nil {
+			break
+		}
+
+		rec.Hash, err = r.uint64()
+	case KindKeyframe:
+		if rec.Tick, err = r.uint32(); err != nil {
+			break
+		}
+
+		rec.State, err = r.bytes()
+	default:
+		return Record{}, fmt.Errorf("%w: unknown record kind %d", ErrMalformed, b)
+	}
+
+	if err != nil {
+		return Record{}, ErrMalformed
+	}
+
+	return rec, nil
+}
+
+func (r *Reader) uvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, ErrMalformed
+	}
+
+	return v, nil
+}
+
+func (r *Reader) uint32() (uint32, error) {
+	v, err := r.uvarint()
+	if err != nil || v > math.MaxUint32 {
+		return 0, ErrMalformed
+	}
+
+	return uint32(v), nil
+}
+
+func (r *Reader) uint64() (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r.r, b[:]); err != nil {
+		return 0, ErrMalformed
+	}
+
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+func (r *Reader) fixed(dst ...*game.Fixed) error {
+	for _, d := range dst {
+		v, err := binary.ReadVarint(r.r)
+		if err != nil {
+			return ErrMalformed
+		}
+
+		*d = game.Fixed(v)
+	}
+
+	return nil
+}
+
+func (r *Reader) bytes() ([]byte, error) {
+	n, err := r.uvarint()
+	if err != nil || n > maxBytes {
+		return nil, ErrMalformed
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return nil, ErrMalformed
+	}
+
+	return b, nil
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+
+	return 0
+}
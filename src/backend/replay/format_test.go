@@ -0,0 +1,145 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	header := Header{Version: Version, MatchID: "match-1", Seed: 42, MapWidth: game.MapWidth, MapHeight: game.MapHeight, TickRate: game.TickRate}
+
+	records := []Record{
+		{Kind: KindKeyframe, Tick: 0, State: []byte{1, 2, 3}},
+		{Kind: KindJoin, Player: 1},
+		{Kind: KindJoin, Player: math.MaxUint32},
+		{Kind: KindInput, Player: 1, Input: game.Input{Seq: 7, DirX: -0.5, DirY: 1}},
+		{Kind: KindInput, Player: 1, Input: game.Input{Seq: math.MaxUint32, DirX: float32(math.Inf(1)), DirY: -0}},
+		{Kind: KindRewind, Player: 1, Target: 2, X: game.FixedFromInt(-3), Y: game.FixedOne / 3, Size: game.FixedFromInt(10), OK: true},
+		{Kind: KindRewind, Player: 2, Target: 1},
+		{Kind: KindStep},
+		{Kind: KindCorrection, Player: 2, X: game.FixedFromInt(game.MapWidth), Y: -1},
+		{Kind: KindTick, Tick: 1, Hash: math.MaxUint64},
+		{Kind: KindLeave, Player: 1},
+		{Kind: KindKeyframe, Tick: 200, State: []byte{}},
+	}
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, header)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("Write %s: %v", rec.Kind, err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	r, got, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+
+	if got != header {
+		t.Fatalf("header = %+v, want %+v", got, header)
+	}
+
+	for _, want := range records {
+		rec, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next %s: %v", want.Kind, err)
+		}
+
+		// Float сравниваются побитово: ввод записывается как есть, включая бесконечности
+		if math.Float32bits(rec.Input.DirX) != math.Float32bits(want.Input.DirX) || math.Float32bits(rec.Input.DirY) != math.Float32bits(want.Input.DirY) {
+			t.Fatalf("%s input = %+v, want %+v", want.Kind, rec.Input, want.Input)
+		}
+
+		rec.Input, want.Input = game.Input{}, game.Input{}
+		if !reflect.DeepEqual(rec, want) {
+			t.Fatalf("record = %+v, want %+v", rec, want)
+		}
+	}
+
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next at end error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestWriteUnknownKind(t *testing.T) {
+	w, err := NewWriter(io.Discard, Header{})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	if err := w.Write(Record{Kind: 0}); err == nil {
+		t.Fatal("Write of unknown kind: no error")
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	var valid bytes.Buffer
+
+	w, err := NewWriter(&valid, Header{MatchID: "m", Seed: 1})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	if err := w.Write(Record{Kind: KindTick, Tick: 3, Hash: 9}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	data := valid.Bytes()
+	headerLen := len(data) - 10
+
+	tests := []struct {
+		name   string
+		data   []byte
+		header error
+		next   error
+	}{
+		{"empty", nil, ErrMalformed, nil},
+		{"bad magic", append([]byte("NOPE"), data[4:]...), ErrMalformed, nil},
+		{"unsupported version", append(append([]byte(nil), magic[:]...), 2), ErrVersion, nil},
+		{"truncated header", data[:headerLen-1], ErrMalformed, nil},
+		{"unknown kind", append(append([]byte(nil), data[:headerLen]...), 0xff), nil, ErrMalformed},
+		{"truncated record", data[:len(data)-1], nil, ErrMalformed},
+		{"huge keyframe", append(append([]byte(nil), data[:headerLen]...), byte(KindKeyframe), 0, 0xff, 0xff, 0xff, 0xff, 0x7f), nil, ErrMalformed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, _, err := NewReader(bytes.NewReader(test.data))
+			if test.header != nil {
+				if !errors.Is(err, test.header) {
+					t.Fatalf("NewReader error = %v, want %v", err, test.header)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+
+			if _, err := r.Next(); !errors.Is(err, test.next) {
+				t.Fatalf("Next error = %v, want %v", err, test.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+// record записывает матч из двух игроков на ticks тиков и возвращает файл
+// повтора и хеши состояния после каждого тика
+func record(t *testing.T, ticks int, keyframeInterval uint32) ([]byte, map[uint32]uint64) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := game.NewWorld(7)
+
+	r, err := NewRecorder(&buf, Header{Version: Version, MatchID: "m", Seed: 7, TickRate: game.TickRate}, w, keyframeInterval)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+
+	a, b := w.AddPlayer().ID, w.AddPlayer().ID
+	r.Join(a)
+	r.Join(b)
+
+	hashes := map[uint32]uint64{}
+
+	for i := range ticks {
+		for _, in := range []struct {
+			id   uint32
+			dirX float32
+		}{{a, 1}, {b, -1}} {
+			input := game.Input{Seq: uint32(i + 1), DirX: in.dirX, DirY: 0.25}
+			w.QueueInput(in.id, input)
+			r.Input(in.id, input)
+		}
+
+		w.Step()
+		r.Step()
+		r.EndTick(w)
+
+		hashes[w.Tick] = w.Hash()
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	return buf.Bytes(), hashes
+}
+
+func TestPlaybackVerify(t *testing.T) {
+	for _, keyframeInterval := range []uint32{0, 1, 5, 100} {
+		data, _ := record(t, 12, keyframeInterval)
+
+		p, err := Load(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+
+		if ticks, err := p.Verify(); err != nil || ticks != 12 {
+			t.Fatalf("keyframes every %d: Verify = %d, %v, want 12 ticks", keyframeInterval, ticks, err)
+		}
+	}
+}
+
+func TestPlaybackSeek(t *testing.T) {
+	data, hashes := record(t, 12, 5)
+
+	p, err := Load(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// Вперед, назад через ключевой кадр и к началу
+	for _, tick := range []uint32{7, 3, 12, 5, 1} {
+		if err := p.Seek(tick); err != nil {
+			t.Fatalf("Seek(%d): %v", tick, err)
+		}
+
+		if p.Tick() != tick || p.World().Hash() != hashes[tick] {
+			t.Fatalf("after Seek(%d) tick = %d, hash mismatch = %v", tick, p.Tick(), p.World().Hash() != hashes[tick])
+		}
+	}
+
+	if err := p.Seek(13); !errors.Is(err, ErrRange) {
+		t.Fatalf("Seek past the end error = %v, want %v", err, ErrRange)
+	}
+}
+
+func TestPlaybackDesync(t *testing.T) {
+	data, _ := record(t, 3, 0)
+
+	// Порча ввода первого игрока: симуляция расходится с записанным хешем
+	p, err := Load(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for i, rec := range p.records {
+		if rec.Kind == KindInput {
+			p.records[i].Input.DirX = -rec.Input.DirX
+			break
+		}
+	}
+
+	if _, err := p.Verify(); !errors.Is(err, ErrDesync) {
+		t.Fatalf("Verify of corrupted replay error = %v, want %v", err, ErrDesync)
+	}
+}
+
+func TestPlaybackResetClearsRewinds(t *testing.T) {
+	for _, keyframeInterval := range []uint32{0, 5} {
+		data, _ := record(t, 3, keyframeInterval)
+
+		p, err := Load(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+
+		// NewRecorder всегда пишет начальный ключевой кадр; без ключевых
+		// кадров Reset создает мир заново, а не через restore
+		if keyframeInterval == 0 {
+			p.keyframes = nil
+		}
+
+		p.rewinds.queue = append(p.rewinds.queue, Record{Kind: KindRewind, Player: 1, Target: 2, OK: true})
+
+		if err := p.Reset(); err != nil {
+			t.Fatalf("Reset: %v", err)
+		}
+
+		if len(p.rewinds.queue) != 0 {
+			t.Fatalf("keyframes every %d: %d rewinds left after Reset", keyframeInterval, len(p.rewinds.queue))
+		}
+	}
+}
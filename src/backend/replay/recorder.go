@@ -0,0 +1,127 @@
+package replay
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// DefaultKeyframeInterval - ключевой кадр раз в 10 секунд при 20 тиках в секунду
+const DefaultKeyframeInterval = 200
+
+// Recorder пишет повтор матча. Не потокобезопасен: матч вызывает его под своей блокировкой.
+// Ошибки записи не должны останавливать матч, поэтому методы их не возвращают:
+// запись прекращается после первой ошибки, а сама ошибка доступна через Close
+type Recorder struct {
+	w                *Writer
+	out              io.Writer
+	keyframeInterval uint32
+	err              error
+}
+
+// NewRecorder пишет заголовок и ключевой кадр с текущим состоянием мира
+func NewRecorder(out io.Writer, h Header, w *game.World, keyframeInterval uint32) (*Recorder, error) {
+	writer, err := NewWriter(out, h)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{w: writer, out: out, keyframeInterval: keyframeInterval}
+	r.keyframe(w)
+
+	return r, r.err
+}
+
+func (r *Recorder) Join(id uint32) {
+	r.write(Record{Kind: KindJoin, Player: id})
+}
+
+func (r *Recorder) Leave(id uint32) {
+	r.write(Record{Kind: KindLeave, Player: id})
+}
+
+// Input записывает ввод, прошедший проверку и поставленный в очередь симуляции
+func (r *Recorder) Input(id uint32, in game.Input) {
+	r.write(Record{Kind: KindInput, Player: id, Input: in})
+}
+
+// Step отмечает вызов World.Step
+func (r *Recorder) Step() {
+	r.write(Record{Kind: KindStep})
+}
+
+// Correction записывает положение игрока после поправки античита
+func (r *Recorder) Correction(p *game.Player) {
+	r.write(Record{Kind: KindCorrection, Player: p.ID, X: p.X, Y: p.Y})
+}
+
+// EndTick завершает тик: пишет хеш состояния и, если пора, ключевой кадр
+func (r *Recorder) EndTick(w *game.World) {
+	r.write(Record{Kind: KindTick, Tick: w.Tick, Hash: w.Hash()})
+
+	if r.keyframeInterval > 0 && w.Tick%r.keyframeInterval == 0 {
+		r.keyframe(w)
+	}
+}
+
+// Rewinder оборачивает компенсацию задержки, записывая каждый ее ответ:
+// при воспроизведении они подставляются вместо пересчета по времени и RTT
+func (r *Recorder) Rewinder(inner game.Rewinder) game.Rewinder {
+	return recordingRewinder{recorder: r, inner: inner}
+}
+
+// Close дописывает буфер и закрывает файл, если он закрываемый
+func (r *Recorder) Close() error {
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+
+	if c, ok := r.out.(io.Closer); ok {
+		if err := c.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+
+	return r.err
+}
+
+func (r *Recorder) keyframe(w *game.World) {
+	state, err := w.MarshalBinary()
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	r.write(Record{Kind: KindKeyframe, Tick: w.Tick, State: state})
+}
+
+func (r *Recorder) write(rec Record) {
+	if r.err != nil {
+		return
+	}
+
+	if err := r.w.Write(rec); err != nil {
+		r.fail(err)
+	}
+}
+
+func (r *Recorder) fail(err error) {
+	if r.err == nil {
+		slog.Error("replay recording stopped", logging.Err(err))
+		r.err = err
+	}
+}
+
+type recordingRewinder struct {
+	recorder *Recorder
+	inner    game.Rewinder
+}
+
+func (rr recordingRewinder) Rewind(actor, target uint32) (x, y, size game.Fixed, ok bool) {
+	x, y, size, ok = rr.inner.Rewind(actor, target)
+	rr.recorder.write(Record{Kind: KindRewind, Player: actor, Target: target, X: x, Y: y, Size: size, OK: ok})
+
+	return x, y, size, ok
+}
@@ -0,0 +1,21 @@
+package snapshot
+
+import "github.com/matelq/p2pmp/src/network/common/snapshot"
+
+// DefaultViewRadius - радиус области интереса, с запасом больше экрана рендера
+const DefaultViewRadius = 1500
+
+// AreaOfInterest оставляет в снимке только сущности, которые видны из точки (x, y):
+// их круг пересекается с кругом радиуса radius. Отсортированность сохраняется
+func AreaOfInterest(s snapshot.Snapshot, x, y, radius float32) snapshot.Snapshot {
+	filtered := snapshot.Snapshot{Tick: s.Tick, Entities: make([]snapshot.Entity, 0, len(s.Entities))}
+
+	for _, e := range s.Entities {
+		dx, dy, r := e.X-x, e.Y-y, radius+e.Size
+		if dx*dx+dy*dy <= r*r {
+			filtered.Entities = append(filtered.Entities, e)
+		}
+	}
+
+	return filtered
+}
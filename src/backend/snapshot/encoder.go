@@ -0,0 +1,101 @@
+// Кодировщик снимков мира на стороне бэкенда.
+// Для каждого узла помнит последний подтвержденный им тик и шлет дельту
+// относительно него, а если baseline неизвестен или устарел - полный снимок
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+// DefaultHistorySize - сколько последних тиков храним как возможные baseline.
+// При 20 тиках в секунду это ~1.5 секунды потерь, после которых узел получит полный снимок
+const DefaultHistorySize = 32
+
+type Encoder struct {
+	mu      sync.Mutex
+	history []snapshot.State
+	latest  int
+	acks    map[string]uint32
+}
+
+func NewEncoder(historySize int) *Encoder {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+
+	return &Encoder{
+		history: make([]snapshot.State, 0, historySize),
+		latest:  -1,
+		acks:    map[string]uint32{},
+	}
+}
+
+// Push добавляет в историю снимок очередного тика симуляции
+func (e *Encoder) Push(s snapshot.Snapshot) {
+	state := snapshot.Quantize(s)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.history) < cap(e.history) {
+		e.history = append(e.history, state)
+		e.latest = len(e.history) - 1
+		return
+	}
+
+	e.latest = (e.latest + 1) % len(e.history)
+	e.history[e.latest] = state
+}
+
+// Encode кодирует последний снимок для узла nodeID
+func (e *Encoder) Encode(nodeID string) []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.latest < 0 {
+		return nil
+	}
+
+	cur := e.history[e.latest]
+
+	if ack, ok := e.acks[nodeID]; ok {
+		if base := e.find(ack); base != nil {
+			return snapshot.AppendDelta(nil, *base, cur)
+		}
+	}
+
+	return snapshot.AppendFull(nil, cur)
+}
+
+// Ack фиксирует, что узел получил и декодировал снимок с тиком tick.
+// Подтверждения, пришедшие не по порядку, не откатывают baseline назад
+func (e *Encoder) Ack(nodeID string, tick uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if prev, ok := e.acks[nodeID]; ok && int32(tick-prev) <= 0 {
+		return
+	}
+
+	e.acks[nodeID] = tick
+}
+
+// Forget удаляет baseline узла, следующий снимок для него будет полным
+func (e *Encoder) Forget(nodeID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.acks, nodeID)
+}
+
+func (e *Encoder) find(tick uint32) *snapshot.State {
+	for i := range e.history {
+		if e.history[i].Tick == tick {
+			return &e.history[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var ErrMalformed = errors.New("stats: malformed leaderboard")
+
+// MarshalLeaderboard кодирует таблицу лидеров для envelope.TypeLeaderboard
+func MarshalLeaderboard(entries []Entry) []byte {
+	data := binary.AppendUvarint(nil, uint64(len(entries)))
+
+	for _, e := range entries {
+		data = binary.AppendUvarint(data, uint64(len(e.NodeID)))
+		data = append(data, e.NodeID...)
+		data = binary.AppendUvarint(data, uint64(e.PlayerID))
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(e.Size))
+		data = binary.AppendUvarint(data, uint64(e.FoodEaten))
+		data = binary.AppendUvarint(data, uint64(e.PlayersEaten))
+	}
+
+	return data
+}
+
+func UnmarshalLeaderboard(data []byte) ([]Entry, error) {
+	r := reader{data: data}
+
+	n := r.uvarint()
+	if r.err != nil || n > uint64(len(data)) {
+		return nil, ErrMalformed
+	}
+
+	entries := make([]Entry, 0, n)
+	for range n {
+		var e Entry
+
+		e.NodeID = string(r.bytes(int(r.uvarint())))
+		e.PlayerID = uint32(r.uvarint())
+		e.Size = math.Float32frombits(binary.LittleEndian.Uint32(r.bytes(4)))
+		e.FoodEaten = uint32(r.uvarint())
+		e.PlayersEaten = uint32(r.uvarint())
+
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = ErrMalformed
+		return 0
+	}
+
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		r.err = ErrMalformed
+		// Чтобы разбор float32 не паниковал, результат все равно будет отброшен
+		return make([]byte, 4)
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
@@ -0,0 +1,188 @@
+// Статистика игроков матча: съеденная еда и игроки, максимальный размер,
+// время жизни. Живой топ игроков рассылается в комнату матча, итоги
+// по окончании матча сохраняются в Store
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+// DefaultLeaderboardSize - сколько строк в живой таблице лидеров
+const DefaultLeaderboardSize = 10
+
+// PlayerStats - итоги игрока за матч. Игрок определяется узлом:
+// после респауна ID в симуляции новый, а статистика продолжается
+type PlayerStats struct {
+	NodeID       string  `json:"nodeId"`
+	FoodEaten    uint32  `json:"foodEaten"`
+	PlayersEaten uint32  `json:"playersEaten"`
+	Deaths       uint32  `json:"deaths"`
+	MaxSize      float32 `json:"maxSize"`
+	// Survival - самая долгая жизнь без смерти
+	Survival time.Duration `json:"survival"`
+}
+
+// Entry - строка живой таблицы лидеров, место определяется текущим размером
+type Entry struct {
+	NodeID       string
+	PlayerID     uint32
+	Size         float32
+	FoodEaten    uint32
+	PlayersEaten uint32
+}
+
+type player struct {
+	stats PlayerStats
+	id    uint32
+	alive bool
+	born  time.Time
+	size  float32
+}
+
+// Tracker собирает статистику одного матча, реализует game.Observer
+type Tracker struct {
+	mu      sync.Mutex
+	now     time.Time
+	players map[string]*player
+	byID    map[uint32]*player
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{players: map[string]*player{}, byID: map[uint32]*player{}}
+}
+
+// Tick задает время текущего тика, вызывается перед World.Step
+func (t *Tracker) Tick(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.now = now
+}
+
+// Join начинает новую жизнь игрока узла nodeID (вход в матч или респаун)
+func (t *Tracker) Join(nodeID string, p *game.Player, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pl, ok := t.players[nodeID]
+	if !ok {
+		pl = &player{stats: PlayerStats{NodeID: nodeID}}
+		t.players[nodeID] = pl
+	}
+
+	if pl.alive {
+		t.endLife(pl, at)
+	}
+
+	pl.id, pl.alive, pl.born = p.ID, true, at
+	pl.size = p.Size.Float()
+	pl.stats.MaxSize = max(pl.stats.MaxSize, pl.size)
+	t.byID[p.ID] = pl
+}
+
+// Leave завершает жизнь игрока при выходе из матча, статистика остается в итогах
+func (t *Tracker) Leave(nodeID string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if pl, ok := t.players[nodeID]; ok && pl.alive {
+		t.endLife(pl, at)
+	}
+}
+
+func (t *Tracker) FoodEaten(id uint32, count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if pl, ok := t.byID[id]; ok {
+		pl.stats.FoodEaten += uint32(count)
+	}
+}
+
+func (t *Tracker) PlayerEaten(hunter, prey uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if pl, ok := t.byID[hunter]; ok {
+		pl.stats.PlayersEaten++
+	}
+
+	if pl, ok := t.byID[prey]; ok && pl.alive {
+		pl.stats.Deaths++
+		t.endLife(pl, t.now)
+	}
+}
+
+// Update обновляет размеры после шага симуляции
+func (t *Tracker) Update(w *game.World) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, p := range w.Players {
+		if pl, ok := t.byID[id]; ok {
+			pl.size = p.Size.Float()
+			pl.stats.MaxSize = max(pl.stats.MaxSize, pl.size)
+		}
+	}
+}
+
+// Leaderboard возвращает n живых игроков с наибольшим размером
+func (t *Tracker) Leaderboard(n int) []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]Entry, 0, len(t.players))
+	for _, pl := range t.players {
+		if !pl.alive {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			NodeID:       pl.stats.NodeID,
+			PlayerID:     pl.id,
+			Size:         pl.size,
+			FoodEaten:    pl.stats.FoodEaten,
+			PlayersEaten: pl.stats.PlayersEaten,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+
+		return entries[i].NodeID < entries[j].NodeID
+	})
+
+	return entries[:min(n, len(entries))]
+}
+
+// Results возвращает итоги всех, кто был в матче, с учетом текущих жизней
+func (t *Tracker) Results(at time.Time) []PlayerStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	results := make([]PlayerStats, 0, len(t.players))
+	for _, pl := range t.players {
+		stats := pl.stats
+		if pl.alive {
+			stats.Survival = max(stats.Survival, at.Sub(pl.born))
+		}
+
+		results = append(results, stats)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].NodeID < results[j].NodeID })
+
+	return results
+}
+
+func (t *Tracker) endLife(pl *player, at time.Time) {
+	pl.alive = false
+	pl.stats.Survival = max(pl.stats.Survival, at.Sub(pl.born))
+	delete(t.byID, pl.id)
+}
@@ -0,0 +1,198 @@
+package stats
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+func newPlayer(id uint32, size int) *game.Player {
+	p := game.NewPlayer(id, 0, 0)
+	p.Size = game.FixedFromInt(size)
+
+	return p
+}
+
+func TestTracker(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	t.Run("eating and respawn", func(t *testing.T) {
+		tr := NewTracker()
+		tr.Join("a", newPlayer(1, 20), start)
+		tr.Join("b", newPlayer(2, 10), start)
+
+		tr.FoodEaten(1, 3)
+		tr.Tick(start.Add(10 * time.Second))
+		tr.PlayerEaten(1, 2)
+
+		// Респаун: новый ID в симуляции, статистика узла продолжается
+		tr.Join("b", newPlayer(3, 10), start.Add(10*time.Second))
+		tr.FoodEaten(3, 1)
+		// Старый ID больше не принадлежит узлу
+		tr.FoodEaten(2, 5)
+
+		want := []PlayerStats{
+			{NodeID: "a", FoodEaten: 3, PlayersEaten: 1, MaxSize: 20, Survival: 30 * time.Second},
+			{NodeID: "b", FoodEaten: 1, Deaths: 1, MaxSize: 10, Survival: 20 * time.Second},
+		}
+
+		if got := tr.Results(start.Add(30 * time.Second)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Results = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("leave keeps results", func(t *testing.T) {
+		tr := NewTracker()
+		tr.Join("a", newPlayer(1, 10), start)
+		tr.Leave("a", start.Add(5*time.Second))
+
+		got := tr.Results(start.Add(time.Minute))
+		if len(got) != 1 || got[0].Survival != 5*time.Second {
+			t.Fatalf("Results = %+v, want a single life of 5s", got)
+		}
+
+		if board := tr.Leaderboard(10); len(board) != 0 {
+			t.Fatalf("Leaderboard = %+v, want players who left excluded", board)
+		}
+	})
+}
+
+func TestLeaderboard(t *testing.T) {
+	sizes := map[string]int{"a": 10, "b": 30, "c": 20, "d": 20}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		// При равном размере порядок по ID узла
+		{"all", 10, []string{"b", "c", "d", "a"}},
+		{"top two", 2, []string{"b", "c"}},
+		{"none", 0, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := NewTracker()
+			w := game.NewWorld(1)
+
+			for nodeID, size := range sizes {
+				p := w.AddPlayer()
+				tr.Join(nodeID, p, time.Now())
+				p.Size = game.FixedFromInt(size)
+			}
+
+			tr.Update(w)
+
+			got := []string{}
+			for _, e := range tr.Leaderboard(test.n) {
+				got = append(got, e.NodeID)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Leaderboard = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLeaderboardRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+	}{
+		{"empty", []Entry{}},
+		{"entries", []Entry{
+			{NodeID: "node-1", PlayerID: 7, Size: 42.5, FoodEaten: 300, PlayersEaten: 2},
+			{NodeID: "", PlayerID: 1 << 31, Size: 10},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := UnmarshalLeaderboard(MarshalLeaderboard(test.entries))
+			if err != nil {
+				t.Fatalf("UnmarshalLeaderboard: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.entries) {
+				t.Fatalf("UnmarshalLeaderboard = %+v, want %+v", got, test.entries)
+			}
+		})
+	}
+}
+
+func TestUnmarshalLeaderboardMalformed(t *testing.T) {
+	valid := MarshalLeaderboard([]Entry{{NodeID: "a", PlayerID: 1, Size: 10}})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated", valid[:len(valid)-1]},
+		{"count too large", []byte{0xff, 0x01}},
+		{"name too long", []byte{1, 0x7f, 'a'}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := UnmarshalLeaderboard(test.data); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("UnmarshalLeaderboard error = %v, want %v", err, ErrMalformed)
+			}
+		})
+	}
+}
+
+func TestBoltStoreRecent(t *testing.T) {
+	s, err := OpenBolt(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("OpenBolt: %v", err)
+	}
+	defer s.Close()
+
+	start := time.Unix(1000, 0).UTC()
+
+	// ID повторяются между запусками бэкенда и не затирают старые итоги
+	saved := []MatchResult{
+		{MatchID: "match-1", Ended: start, Reason: "time_limit"},
+		{MatchID: "match-1", Ended: start.Add(time.Hour), Reason: "no_players"},
+		{MatchID: "match-2", Ended: start.Add(time.Minute), Reason: "shutdown"},
+	}
+
+	for _, result := range saved {
+		if err := s.SaveMatch(result); err != nil {
+			t.Fatalf("SaveMatch: %v", err)
+		}
+	}
+
+	tests := []struct {
+		limit int
+		want  []MatchResult
+	}{
+		{10, []MatchResult{saved[1], saved[2], saved[0]}},
+		{2, []MatchResult{saved[1], saved[2]}},
+		{0, nil},
+	}
+
+	for _, test := range tests {
+		got, err := s.Recent(test.limit)
+		if err != nil {
+			t.Fatalf("Recent(%d): %v", test.limit, err)
+		}
+
+		if len(got) != len(test.want) {
+			t.Fatalf("Recent(%d) = %+v, want %+v", test.limit, got, test.want)
+		}
+
+		for i := range got {
+			if got[i].MatchID != test.want[i].MatchID || !got[i].Ended.Equal(test.want[i].Ended) || got[i].Reason != test.want[i].Reason {
+				t.Fatalf("Recent(%d)[%d] = %+v, want %+v", test.limit, i, got[i], test.want[i])
+			}
+		}
+	}
+}
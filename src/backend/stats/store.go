@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// MatchResult - итоги завершенного матча
+type MatchResult struct {
+	MatchID string        `json:"matchId"`
+	Started time.Time     `json:"started"`
+	Ended   time.Time     `json:"ended"`
+	Reason  string        `json:"reason"`
+	Players []PlayerStats `json:"players"`
+}
+
+// Store хранит итоги матчей. По умолчанию используется BoltStore,
+// другие хранилища (SQLite и т.п.) подключаются реализацией этого интерфейса
+type Store interface {
+	SaveMatch(result MatchResult) error
+	// Recent возвращает до limit последних матчей, новые первыми
+	Recent(limit int) ([]MatchResult, error)
+	Close() error
+}
+
+var matchesBucket = []byte("matches")
+
+// BoltStore - Store во встроенной базе bbolt (один файл, без отдельного сервера).
+// Ключ - время окончания и ID матча: ID повторяются между запусками бэкенда
+// и не должны затирать старые итоги, а порядок ключей совпадает с хронологией
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(matchesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) SaveMatch(result MatchResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	key := binary.BigEndian.AppendUint64(nil, uint64(result.Ended.UnixNano()))
+	key = append(key, result.MatchID...)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(matchesBucket).Put(key, data)
+	})
+}
+
+func (s *BoltStore) Recent(limit int) ([]MatchResult, error) {
+	var results []MatchResult
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(matchesBucket).Cursor()
+
+		for k, v := c.Last(); k != nil && len(results) < limit; k, v = c.Prev() {
+			var result MatchResult
+			if err := json.Unmarshal(v, &result); err != nil {
+				return err
+			}
+
+			results = append(results, result)
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
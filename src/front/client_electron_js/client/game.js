@@ -22,6 +22,9 @@ class Player {
         this.y = y;
         this.size = size;
         this.baseSpeed = 5;
+        // Профиль аккаунта приходит от узла сообщением 'profile'
+        this.name = '';
+        this.color = 'blue';
     }
 
     get speed() {
@@ -53,23 +56,55 @@ class Food {
 }
 
 
+// Генератор случайных чисел с зерном (mulberry32): при одинаковом зерне
+// еда появляется в одних и тех же местах, в отличие от Math.random()
+function seededRandom(seed) {
+    let state = seed >>> 0;
+    return function () {
+        state = (state + 0x6D2B79F5) >>> 0;
+        let t = state;
+        t = Math.imul(t ^ (t >>> 15), t | 1);
+        t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+        return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+    };
+}
+
+// Зерно еды для игры без узла. С узлом еду присылает мир сервера или
+// lockstep (сообщение players), и своя раскладка заменяется ею
+const foodSeed = 1;
+const random = seededRandom(foodSeed);
+
+// worldDriven - мир ведет узел: еда приходит от него, локально не съедается
+let worldDriven = false;
+
 let foods = [];
 for (let i = 0; i < 100; i++) {
-    foods.push(new Food(Math.random() * mapWidth, Math.random() * mapHeight, 5));
+    foods.push(new Food(random() * mapWidth, random() * mapHeight, 5));
 }
 
-let currentPlayer = new Player('myId', mapWidth / 2, mapHeight / 2, 10);
+// ID своего игрока - ID аккаунта, под которым узел подключен к серверу
+let currentPlayer = new Player(null, mapWidth / 2, mapHeight / 2, 10);
 let allPlayers = []; // Здесь будут храниться другие игроки
 
+// Режим зрителя: своего игрока нет, камера следует за выбранным игроком
+let spectating = false;
+let followedId = null;
+
 
 
 // Отрисовка игрока с учётом смещения камеры
 function drawPlayer() {
     context.beginPath();
     context.arc(currentPlayer.x - cameraX, currentPlayer.y - cameraY, currentPlayer.size, 0, Math.PI * 2);
-    context.fillStyle = 'blue';
+    context.fillStyle = currentPlayer.color;
     context.fill();
     context.closePath();
+
+    if (currentPlayer.name) {
+        context.fillStyle = 'black';
+        context.textAlign = 'center';
+        context.fillText(currentPlayer.name, currentPlayer.x - cameraX, currentPlayer.y - cameraY - currentPlayer.size - 4);
+    }
 }
 
 // Отрисовка еды с учётом смещения камеры
@@ -103,7 +138,7 @@ function eatFood() {
             foods.splice(index, 1); // Удаление съеденной еды
 
             // Спавн новой еды
-            foods.push(new Food(Math.random() * mapWidth, Math.random() * mapHeight, 5));
+            foods.push(new Food(random() * mapWidth, random() * mapHeight, 5));
             foodEaten = true; // Обновляем переменную, если еда была съедена
         }
     });
@@ -120,6 +155,21 @@ function updateFoodCounter(){
     counterElement.innerText = `Съедено: ${foodCounter}`;
 }
 
+// Таблица лидеров: место, узел и размер
+function updateLeaderboard(entries) {
+    const board = document.getElementById('leaderboard');
+    board.innerHTML = '';
+
+    entries.forEach((entry, i) => {
+        const row = document.createElement('div');
+        row.innerText = `${i + 1}. ${entry.nodeId} — ${Math.round(entry.size)}`;
+        if (entry.nodeId === currentPlayer.id) {
+            row.style.fontWeight = 'bold';
+        }
+        board.appendChild(row);
+    });
+}
+
 // Отрисовка других игроков
 function drawPlayers() {
     allPlayers.forEach(player => {
@@ -141,27 +191,75 @@ window.addEventListener('mousemove', (event) => {
     mouseY = event.clientY;
 });
 
+// Зритель переключает камеру на следующего игрока клавишей Tab
+window.addEventListener('keydown', (event) => {
+    if (!spectating || event.key !== 'Tab') {
+        return;
+    }
+
+    event.preventDefault();
+    followNext();
+});
+
+function followNext() {
+    if (allPlayers.length === 0) {
+        return;
+    }
+
+    const ids = allPlayers.map(player => player.id).sort((a, b) => a - b);
+    followedId = ids.find(id => followedId === null || id > followedId) ?? ids[0];
+
+    socket.send(JSON.stringify({
+        type: 'follow',
+        playerId: followedId
+    }));
+}
+
+// За кем следит камера: свой игрок или выбранный зрителем
+function cameraTarget() {
+    if (spectating) {
+        const followed = allPlayers.find(player => player.id === followedId) || allPlayers[0];
+        if (followed) {
+            return followed;
+        }
+    }
+
+    return currentPlayer;
+}
+
 // Основной игровой цикл
 function gameLoop() {
     context.clearRect(0, 0, canvas.width, canvas.height); // Очистка холста
 
     // Устанавливаем новое положение камеры
-    const targetCameraX = currentPlayer.x - canvas.width / 2;
-    const targetCameraY = currentPlayer.y - canvas.height / 2;
+    const target = cameraTarget();
+    const targetCameraX = target.x - canvas.width / 2;
+    const targetCameraY = target.y - canvas.height / 2;
 
     // Увеличиваем скорость камеры
     cameraX += (targetCameraX - cameraX) * cameraSpeedFactor;
     cameraY += (targetCameraY - cameraY) * cameraSpeedFactor;
 
-    // Двигаем игрока к текущим координатам мыши
-    currentPlayer.moveTo(mouseX, mouseY);
+    if (!spectating) {
+        // Двигаем игрока к текущим координатам мыши:
+        // при подключенном узле позицию предсказывает и сверяет с сервером шлюз
+        if (socket.readyState === WebSocket.OPEN) {
+            sendInput(mouseX, mouseY);
+        } else {
+            currentPlayer.moveTo(mouseX, mouseY);
+        }
 
-    // Обработка еды и игрока
-    eatFood();
+        // Без узла еду раскладывает и ест сам рендер
+        if (!worldDriven) {
+            eatFood();
+        }
+    }
     
     // Отрисовка объектов
     drawFoods();
-    drawPlayer();
+    if (!spectating) {
+        drawPlayer();
+    }
     drawPlayers();
 
     requestAnimationFrame(gameLoop);
@@ -173,13 +271,135 @@ const socket = new WebSocket('ws://localhost:8080');
 
 socket.onopen = function() {
     console.log('Подключено к серверу');
-    socket.send('Привет от клиента!');
 };
 
 socket.onmessage = function(event) {
-    console.log('Сообщение от сервера:', event.data);
-    // Здесь обрабатывай сообщения от сервера (например, обновления других игроков)
+    const message = JSON.parse(event.data);
+
+    switch (message.type) {
+        case 'self':
+            // Предсказанная и сверенная с сервером позиция своего игрока
+            currentPlayer.x = message.x;
+            currentPlayer.y = message.y;
+            currentPlayer.size = message.size;
+            break;
+        case 'players':
+            // Другие игроки и еда уже сглажены буфером интерполяции на узле
+            allPlayers = message.players;
+            if (message.foods) {
+                worldDriven = true;
+                foods = message.foods.map(food => new Food(food.x, food.y, food.size));
+            }
+            break;
+        case 'mode':
+            spectating = message.spectator;
+            followedId = null;
+            break;
+        case 'profile':
+            currentPlayer.id = message.nodeId;
+            currentPlayer.name = message.name;
+            currentPlayer.color = message.color;
+            break;
+        case 'leaderboard':
+            updateLeaderboard(message.entries);
+            break;
+        case 'chat':
+            showChatMessage(message);
+            break;
+        default:
+            console.log('Сообщение от сервера:', message);
+    }
 };
 
+// Отправка ввода: только направление от центра экрана к курсору,
+// номер ввода проставляет шлюз узла
+function sendInput(x, y) {
+    socket.send(JSON.stringify({
+        type: 'input',
+        dirX: x - canvas.width / 2,
+        dirY: y - canvas.height / 2
+    }));
+}
+
+// Чат
+const chatLog = document.getElementById('chat-log');
+const chatInput = document.getElementById('chat-input');
+
+// Команды списков: /mute id, /unmute id, /block id, /unblock id
+const chatListCommands = {
+    '/mute': { type: 'mute', enabled: true },
+    '/unmute': { type: 'mute', enabled: false },
+    '/block': { type: 'block', enabled: true },
+    '/unblock': { type: 'block', enabled: false }
+};
+
+chatInput.addEventListener('keydown', (event) => {
+    if (event.key !== 'Enter') {
+        return;
+    }
+
+    // Иначе обработчик окна сразу вернет фокус в поле ввода
+    event.stopPropagation();
+
+    const text = chatInput.value.trim();
+    chatInput.value = '';
+    chatInput.blur();
+
+    if (text !== '') {
+        sendChat(text);
+    }
+});
+
+window.addEventListener('keydown', (event) => {
+    if (event.key === 'Enter' && document.activeElement !== chatInput) {
+        chatInput.focus();
+    }
+});
+
+function sendChat(text) {
+    const [command, target, ...rest] = text.split(' ');
+
+    if (chatListCommands[command] && target) {
+        const list = chatListCommands[command];
+        socket.send(JSON.stringify({ type: list.type, target: target, enabled: list.enabled }));
+        addChatLine(`${command.slice(1)}: ${target}`);
+        return;
+    }
+
+    if (command === '/w' && target) {
+        socket.send(JSON.stringify({ type: 'chat', to: target, text: rest.join(' ') }));
+        return;
+    }
+
+    socket.send(JSON.stringify({ type: 'chat', text: text }));
+}
+
+function addChatLine(text, style) {
+    const line = document.createElement('div');
+    line.innerText = text;
+    Object.assign(line.style, style);
+    chatLog.appendChild(line);
+
+    // Храним последние 100 строк
+    while (chatLog.childElementCount > 100) {
+        chatLog.removeChild(chatLog.firstChild);
+    }
+
+    chatLog.scrollTop = chatLog.scrollHeight;
+}
+
+function showChatMessage(message) {
+    switch (message.kind) {
+        case 'system':
+            addChatLine(message.text, { color: 'gray', fontStyle: 'italic' });
+            break;
+        case 'direct':
+            addChatLine(`[${message.from} → ${message.to}] ${message.text}`, { color: 'purple' });
+            break;
+        default:
+            addChatLine(`${message.from}: ${message.text}`);
+    }
+}
+
 // Начало игрового цикла
 gameLoop();
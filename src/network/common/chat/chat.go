@@ -0,0 +1,119 @@
+// Текстовый чат матча: сообщения в комнату, личные и системные.
+// Сообщения маршрутизирует Relay - на transmitter в режиме relay и на узле-хосте
+// в режиме P2P, поэтому ограничение частоты и фильтры работают одинаково.
+// Списки mute/block хранит сам получатель (Lists) и применяет к входящим
+package chat
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxLength - максимальная длина сообщения в символах
+const MaxLength = 256
+
+var (
+	ErrMalformed   = errors.New("chat: malformed message")
+	ErrEmpty       = errors.New("chat: empty message")
+	ErrTooLong     = errors.New("chat: message is too long")
+	ErrRateLimited = errors.New("chat: too many messages, slow down")
+	// ErrRejected возвращают фильтры, чтобы не пропустить сообщение
+	ErrRejected   = errors.New("chat: message rejected by filter")
+	ErrNoSuchPeer = errors.New("chat: recipient is not in the match")
+	// ErrSystem - отправлять системные сообщения может только сервер
+	ErrSystem = errors.New("chat: players cannot send system messages")
+)
+
+type Kind uint8
+
+const (
+	KindRoom Kind = iota
+	KindDirect
+	KindSystem
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindRoom:
+		return "room"
+	case KindDirect:
+		return "direct"
+	case KindSystem:
+		return "system"
+	default:
+		return "unknown"
+	}
+}
+
+type Message struct {
+	Kind Kind
+	From string
+	// To - получатель личного сообщения
+	To   string
+	Text string
+	Sent time.Time
+}
+
+// validate нормализует текст и проверяет длину
+func (m Message) validate() (Message, error) {
+	m.Text = strings.TrimSpace(m.Text)
+
+	if m.Text == "" {
+		return m, ErrEmpty
+	}
+
+	if !utf8.ValidString(m.Text) {
+		return m, ErrMalformed
+	}
+
+	if utf8.RuneCountInString(m.Text) > MaxLength {
+		return m, ErrTooLong
+	}
+
+	return m, nil
+}
+
+// Marshal кодирует сообщение для envelope.TypeChat:
+// вид, затем From, To и Text как uvarint длина + байты, затем время в миллисекундах
+func Marshal(m Message) []byte {
+	data := make([]byte, 0, 1+len(m.From)+len(m.To)+len(m.Text)+binary.MaxVarintLen64*4)
+	data = append(data, byte(m.Kind))
+
+	for _, s := range []string{m.From, m.To, m.Text} {
+		data = binary.AppendUvarint(data, uint64(len(s)))
+		data = append(data, s...)
+	}
+
+	return binary.AppendVarint(data, m.Sent.UnixMilli())
+}
+
+func Unmarshal(data []byte) (Message, error) {
+	if len(data) == 0 || Kind(data[0]) > KindSystem {
+		return Message{}, ErrMalformed
+	}
+
+	m := Message{Kind: Kind(data[0])}
+	data = data[1:]
+
+	for _, s := range []*string{&m.From, &m.To, &m.Text} {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return Message{}, ErrMalformed
+		}
+
+		*s = string(data[n : n+int(length)])
+		data = data[n+int(length):]
+	}
+
+	millis, n := binary.Varint(data)
+	if n <= 0 || n != len(data) {
+		return Message{}, ErrMalformed
+	}
+
+	m.Sent = time.UnixMilli(millis)
+
+	return m, nil
+}
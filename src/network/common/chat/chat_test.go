@@ -0,0 +1,237 @@
+package chat
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inbox собирает доставленные сообщения по получателям
+type inbox map[string][]Message
+
+func (in inbox) deliver(to string, m Message) error {
+	in[to] = append(in[to], m)
+	return nil
+}
+
+func TestRoute(t *testing.T) {
+	members := []string{"a", "b", "c"}
+
+	tests := []struct {
+		name    string
+		m       Message
+		wantErr error
+		wantTo  []string
+		// wantText - текст после нормализации и фильтров
+		wantText string
+	}{
+		{"room", Message{Kind: KindRoom, From: "a", Text: "hello"}, nil, []string{"a", "b", "c"}, "hello"},
+		{"direct", Message{Kind: KindDirect, From: "a", To: "c", Text: "hi"}, nil, []string{"a", "c"}, "hi"},
+		{"trimmed", Message{Kind: KindRoom, From: "a", Text: "  hello \n"}, nil, []string{"a", "b", "c"}, "hello"},
+		{"filtered", Message{Kind: KindRoom, From: "a", Text: "you Noob!"}, nil, []string{"a", "b", "c"}, "you ****!"},
+		{"direct to stranger", Message{Kind: KindDirect, From: "a", To: "x", Text: "hi"}, ErrNoSuchPeer, nil, ""},
+		{"system from player", Message{Kind: KindSystem, From: "a", Text: "hi"}, ErrSystem, nil, ""},
+		{"empty", Message{Kind: KindRoom, From: "a", Text: " \t"}, ErrEmpty, nil, ""},
+		{"too long", Message{Kind: KindRoom, From: "a", Text: strings.Repeat("я", MaxLength+1)}, ErrTooLong, nil, ""},
+		{"invalid utf-8", Message{Kind: KindRoom, From: "a", Text: "\xff"}, ErrMalformed, nil, ""},
+		{"rejected", Message{Kind: KindRoom, From: "a", Text: "buy gold"}, ErrRejected, nil, ""},
+	}
+
+	spam := FilterFunc(func(m Message) (Message, error) {
+		if strings.Contains(m.Text, "gold") {
+			return m, ErrRejected
+		}
+
+		return m, nil
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := inbox{}
+			r := NewRelay(DefaultConfig(), Chain{spam, NewWordFilter([]string{"noob"})}, in.deliver)
+
+			err := r.Route(test.m, members)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Route error = %v, want %v", err, test.wantErr)
+			}
+
+			if err != nil && !Rejected(err) {
+				t.Fatalf("Rejected(%v) = false", err)
+			}
+
+			var to []string
+			for _, member := range members {
+				if len(in[member]) > 0 {
+					to = append(to, member)
+
+					if got := in[member][0].Text; got != test.wantText {
+						t.Fatalf("%s got %q, want %q", member, got, test.wantText)
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(to, test.wantTo) {
+				t.Fatalf("delivered to %v, want %v", to, test.wantTo)
+			}
+		})
+	}
+}
+
+func TestRouteRateLimit(t *testing.T) {
+	r := NewRelay(Config{Interval: time.Hour, Burst: 2}, nil, inbox{}.deliver)
+
+	for i, want := range []error{nil, nil, ErrRateLimited} {
+		if err := r.Route(Message{Kind: KindRoom, From: "a", Text: "hi"}, []string{"a"}); !errors.Is(err, want) {
+			t.Fatalf("message %d error = %v, want %v", i+1, err, want)
+		}
+	}
+
+	// Лимит у каждого отправителя свой
+	if err := r.Route(Message{Kind: KindRoom, From: "b", Text: "hi"}, []string{"b"}); err != nil {
+		t.Fatalf("other sender error = %v", err)
+	}
+
+	r.Forget("a")
+
+	if err := r.Route(Message{Kind: KindRoom, From: "a", Text: "hi"}, []string{"a"}); err != nil {
+		t.Fatalf("error after Forget = %v", err)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	start := time.Unix(1000, 0)
+
+	tests := []struct {
+		name    string
+		elapsed []time.Duration
+		want    []bool
+	}{
+		{"burst", []time.Duration{0, 0, 0}, []bool{true, true, false}},
+		{"refill", []time.Duration{0, 0, time.Second}, []bool{true, true, true}},
+		{"partial refill", []time.Duration{0, 0, 500 * time.Millisecond}, []bool{true, true, false}},
+		{"capped at burst", []time.Duration{0, 10 * time.Second, 10 * time.Second, 10 * time.Second}, []bool{true, true, true, false}},
+		{"clock going back", []time.Duration{0, 0, -time.Minute}, []bool{true, true, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := NewLimiter(time.Second, 2)
+
+			for i, elapsed := range test.elapsed {
+				if got := l.Allow("a", start.Add(elapsed)); got != test.want[i] {
+					t.Fatalf("Allow #%d = %v, want %v", i+1, got, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWordFilter(t *testing.T) {
+	f := NewWordFilter([]string{"noob", "Дурак"})
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hello", "hello"},
+		{"NOOB", "****"},
+		{"noobs", "noobs"},
+		{"noob,noob", "****,****"},
+		{"ты дурак!", "ты *****!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			m, err := f.Filter(Message{Text: test.text})
+			if err != nil || m.Text != test.want {
+				t.Fatalf("Filter = %q, %v, want %q", m.Text, err, test.want)
+			}
+		})
+	}
+}
+
+func TestLists(t *testing.T) {
+	l := NewLists()
+	l.SetMuted("muted", true)
+	l.SetBlocked("blocked", true)
+	l.SetMuted("unmuted", true)
+	l.SetMuted("unmuted", false)
+
+	tests := []struct {
+		m    Message
+		want bool
+	}{
+		{Message{Kind: KindRoom, From: "friend"}, true},
+		{Message{Kind: KindRoom, From: "muted"}, false},
+		{Message{Kind: KindDirect, From: "muted"}, true},
+		{Message{Kind: KindRoom, From: "blocked"}, false},
+		{Message{Kind: KindDirect, From: "blocked"}, false},
+		{Message{Kind: KindRoom, From: "unmuted"}, true},
+		{Message{Kind: KindSystem, From: "blocked"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.m.Kind.String()+" from "+test.m.From, func(t *testing.T) {
+			if got := l.Accepts(test.m); got != test.want {
+				t.Fatalf("Accepts = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if got := l.Muted(); !reflect.DeepEqual(got, []string{"muted"}) {
+		t.Fatalf("Muted = %v, want [muted]", got)
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Message
+	}{
+		{"room", Message{Kind: KindRoom, From: "a", Text: "привет", Sent: time.UnixMilli(1700000000123)}},
+		{"direct", Message{Kind: KindDirect, From: "a", To: "b", Text: "hi", Sent: time.UnixMilli(1)}},
+		{"system", Message{Kind: KindSystem, Text: "match ended", Sent: time.UnixMilli(-5)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Unmarshal(Marshal(test.m))
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.m) {
+				t.Fatalf("Unmarshal = %+v, want %+v", got, test.m)
+			}
+
+			if got, err := FromContract(test.m.Contract()); err != nil || !reflect.DeepEqual(got, test.m) {
+				t.Fatalf("FromContract = %+v, %v, want %+v", got, err, test.m)
+			}
+		})
+	}
+}
+
+func TestUnmarshalMalformed(t *testing.T) {
+	valid := Marshal(Message{Kind: KindRoom, From: "a", Text: "hi", Sent: time.UnixMilli(1)})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"unknown kind", append([]byte{9}, valid[1:]...)},
+		{"truncated", valid[:len(valid)-1]},
+		{"trailing bytes", append(append([]byte{}, valid...), 0)},
+		{"length overflow", []byte{byte(KindRoom), 0x7f, 'a'}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Unmarshal(test.data); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("Unmarshal error = %v, want %v", err, ErrMalformed)
+			}
+		})
+	}
+}
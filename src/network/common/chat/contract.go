@@ -0,0 +1,39 @@
+package chat
+
+import (
+	"errors"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+)
+
+// Contract - сообщение для вызовов SendChat и PushChat
+func (m Message) Contract() *contracts.ChatMessage {
+	return &contracts.ChatMessage{
+		Kind:           contracts.ChatKind(m.Kind),
+		From:           m.From,
+		To:             m.To,
+		Text:           m.Text,
+		SentUnixMillis: m.Sent.UnixMilli(),
+	}
+}
+
+func FromContract(c *contracts.ChatMessage) (Message, error) {
+	if c.Kind < contracts.ChatKind_CHAT_ROOM || c.Kind > contracts.ChatKind_CHAT_SYSTEM {
+		return Message{}, ErrMalformed
+	}
+
+	return Message{Kind: Kind(c.Kind), From: c.From, To: c.To, Text: c.Text, Sent: time.UnixMilli(c.SentUnixMillis)}, nil
+}
+
+// Rejected сообщает, что Relay не принял сообщение (проверки, частота, фильтры),
+// в отличие от ошибок доставки отдельным получателям
+func Rejected(err error) bool {
+	for _, target := range []error{ErrMalformed, ErrEmpty, ErrTooLong, ErrRateLimited, ErrRejected, ErrNoSuchPeer, ErrSystem} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
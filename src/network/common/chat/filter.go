@@ -0,0 +1,81 @@
+package chat
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Filter проверяет сообщение перед доставкой: может изменить текст
+// (например, скрыть ругательства) или отклонить сообщение ошибкой (спам)
+type Filter interface {
+	Filter(m Message) (Message, error)
+}
+
+type FilterFunc func(m Message) (Message, error)
+
+func (f FilterFunc) Filter(m Message) (Message, error) {
+	return f(m)
+}
+
+// Chain применяет фильтры по порядку, останавливаясь на первой ошибке
+type Chain []Filter
+
+func (c Chain) Filter(m Message) (Message, error) {
+	for _, f := range c {
+		var err error
+		if m, err = f.Filter(m); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
+// WordFilter заменяет звездочками слова из списка без учета регистра
+type WordFilter struct {
+	words map[string]bool
+}
+
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{words: make(map[string]bool, len(words))}
+	for _, w := range words {
+		f.words[strings.ToLower(w)] = true
+	}
+
+	return f
+}
+
+func (f *WordFilter) Filter(m Message) (Message, error) {
+	if len(f.words) == 0 {
+		return m, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(m.Text))
+
+	word := []rune{}
+	flush := func() {
+		if f.words[strings.ToLower(string(word))] {
+			b.WriteString(strings.Repeat("*", len(word)))
+		} else {
+			b.WriteString(string(word))
+		}
+
+		word = word[:0]
+	}
+
+	for _, r := range m.Text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+
+		flush()
+		b.WriteRune(r)
+	}
+
+	flush()
+	m.Text = b.String()
+
+	return m, nil
+}
@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter - ограничение частоты сообщений по отправителю (token bucket):
+// отправитель может сразу послать Burst сообщений, дальше по одному раз в Interval
+type Limiter struct {
+	interval time.Duration
+	burst    int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+const (
+	DefaultInterval = time.Second
+	DefaultBurst    = 5
+)
+
+func NewLimiter(interval time.Duration, burst int) *Limiter {
+	return &Limiter{interval: interval, burst: burst, buckets: map[string]*bucket{}}
+}
+
+// Allow забирает токен отправителя, если он есть
+func (l *Limiter) Allow(sender string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[sender]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[sender] = b
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(float64(l.burst), b.tokens+float64(elapsed)/float64(l.interval))
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// Forget удаляет состояние отправителя, когда он уходит из матча
+func (l *Limiter) Forget(sender string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.buckets, sender)
+}
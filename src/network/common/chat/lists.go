@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"slices"
+	"sync"
+)
+
+// Lists - списки mute и block одного игрока. Хранятся на его узле и применяются
+// к входящим сообщениям, поэтому не зависят от того, кто их маршрутизировал.
+// Muted скрывает сообщения в комнату, Blocked - вообще все, кроме системных
+type Lists struct {
+	mu      sync.Mutex
+	muted   map[string]bool
+	blocked map[string]bool
+}
+
+func NewLists() *Lists {
+	return &Lists{muted: map[string]bool{}, blocked: map[string]bool{}}
+}
+
+func (l *Lists) SetMuted(nodeID string, muted bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	set(l.muted, nodeID, muted)
+}
+
+func (l *Lists) SetBlocked(nodeID string, blocked bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	set(l.blocked, nodeID, blocked)
+}
+
+// Accepts решает, показывать ли входящее сообщение
+func (l *Lists) Accepts(m Message) bool {
+	if m.Kind == KindSystem {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.blocked[m.From] {
+		return false
+	}
+
+	return m.Kind != KindRoom || !l.muted[m.From]
+}
+
+func (l *Lists) Muted() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return keys(l.muted)
+}
+
+func (l *Lists) Blocked() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return keys(l.blocked)
+}
+
+func set(m map[string]bool, key string, on bool) {
+	if on {
+		m[key] = true
+	} else {
+		delete(m, key)
+	}
+}
+
+func keys(m map[string]bool) []string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+
+	slices.Sort(list)
+
+	return list
+}
@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Deliver доставляет проверенное сообщение узлу to
+type Deliver func(to string, m Message) error
+
+type Config struct {
+	// Interval и Burst - параметры Limiter
+	Interval time.Duration
+	Burst    int
+}
+
+func DefaultConfig() Config {
+	return Config{Interval: DefaultInterval, Burst: DefaultBurst}
+}
+
+// Relay проверяет сообщения игроков (длина, частота, фильтры) и раздает их
+// участникам матча. Один и тот же Relay работает на transmitter и на узле-хосте
+type Relay struct {
+	limiter *Limiter
+	filter  Filter
+	deliver Deliver
+}
+
+// NewRelay создает маршрутизатор; filter может быть nil
+func NewRelay(config Config, filter Filter, deliver Deliver) *Relay {
+	if filter == nil {
+		filter = Chain{}
+	}
+
+	return &Relay{limiter: NewLimiter(config.Interval, config.Burst), filter: filter, deliver: deliver}
+}
+
+// Route проверяет сообщение игрока m.From и доставляет его участникам members:
+// сообщение в комнату - всем, личное - получателю. Отправитель тоже получает
+// сообщение, чтобы увидеть его таким, каким оно прошло фильтры
+func (r *Relay) Route(m Message, members []string) error {
+	if m.Kind == KindSystem {
+		return ErrSystem
+	}
+
+	if m.Kind == KindDirect && !slices.Contains(members, m.To) {
+		return ErrNoSuchPeer
+	}
+
+	m, err := m.validate()
+	if err != nil {
+		return err
+	}
+
+	if !r.limiter.Allow(m.From, time.Now()) {
+		return ErrRateLimited
+	}
+
+	if m, err = r.filter.Filter(m); err != nil {
+		return err
+	}
+
+	m.Sent = time.Now()
+
+	if m.Kind == KindDirect {
+		members = []string{m.From, m.To}
+	}
+
+	return r.send(m, members)
+}
+
+// System рассылает системное сообщение участникам members без проверок
+func (r *Relay) System(text string, members []string) error {
+	return r.send(Message{Kind: KindSystem, Text: text, Sent: time.Now()}, members)
+}
+
+// Forget удаляет состояние ушедшего игрока
+func (r *Relay) Forget(nodeID string) {
+	r.limiter.Forget(nodeID)
+}
+
+func (r *Relay) send(m Message, members []string) error {
+	var errs []error
+	for _, to := range slices.Compact(slices.Sorted(slices.Values(members))) {
+		if err := r.deliver(to, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
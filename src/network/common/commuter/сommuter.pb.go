@@ -0,0 +1,68 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: сommuter.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package commuter
+
+import (
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_сommuter_proto protoreflect.FileDescriptor
+
+const file_сommuter_proto_rawDesc = "" +
+	"\n" +
+	"\x0fсommuter.proto\x12\x0fcommon.commuter\x1a\x0fcontracts.proto2R\n" +
+	"\bCommuter\x12F\n" +
+	"\x12CallFuncOnCommuter\x12\x16.common.contracts.Text\x1a\x16.common.contracts.Text\"\x00B5Z3github.com/matelq/p2pmp/src/network/common/commuterb\x06proto3"
+
+var file_сommuter_proto_goTypes = []any{
+	(*contracts.Text)(nil), // 0: common.contracts.Text
+}
+var file_сommuter_proto_depIdxs = []int32{
+	0, // 0: common.commuter.Commuter.CallFuncOnCommuter:input_type -> common.contracts.Text
+	0, // 1: common.commuter.Commuter.CallFuncOnCommuter:output_type -> common.contracts.Text
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_сommuter_proto_init() }
+func file_сommuter_proto_init() {
+	if File_сommuter_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_сommuter_proto_rawDesc), len(file_сommuter_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_сommuter_proto_goTypes,
+		DependencyIndexes: file_сommuter_proto_depIdxs,
+	}.Build()
+	File_сommuter_proto = out.File
+	file_сommuter_proto_goTypes = nil
+	file_сommuter_proto_depIdxs = nil
+}
@@ -0,0 +1,124 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: сommuter.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package commuter
+
+import (
+	context "context"
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Commuter_CallFuncOnCommuter_FullMethodName = "/common.commuter.Commuter/CallFuncOnCommuter"
+)
+
+// CommuterClient is the client API for Commuter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CommuterClient interface {
+	CallFuncOnCommuter(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error)
+}
+
+type commuterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCommuterClient(cc grpc.ClientConnInterface) CommuterClient {
+	return &commuterClient{cc}
+}
+
+func (c *commuterClient) CallFuncOnCommuter(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Commuter_CallFuncOnCommuter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CommuterServer is the server API for Commuter service.
+// All implementations must embed UnimplementedCommuterServer
+// for forward compatibility.
+type CommuterServer interface {
+	CallFuncOnCommuter(context.Context, *contracts.Text) (*contracts.Text, error)
+	mustEmbedUnimplementedCommuterServer()
+}
+
+// UnimplementedCommuterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCommuterServer struct{}
+
+func (UnimplementedCommuterServer) CallFuncOnCommuter(context.Context, *contracts.Text) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CallFuncOnCommuter not implemented")
+}
+func (UnimplementedCommuterServer) mustEmbedUnimplementedCommuterServer() {}
+func (UnimplementedCommuterServer) testEmbeddedByValue()                  {}
+
+// UnsafeCommuterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CommuterServer will
+// result in compilation errors.
+type UnsafeCommuterServer interface {
+	mustEmbedUnimplementedCommuterServer()
+}
+
+func RegisterCommuterServer(s grpc.ServiceRegistrar, srv CommuterServer) {
+	// If the following call pancis, it indicates UnimplementedCommuterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Commuter_ServiceDesc, srv)
+}
+
+func _Commuter_CallFuncOnCommuter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommuterServer).CallFuncOnCommuter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Commuter_CallFuncOnCommuter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommuterServer).CallFuncOnCommuter(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Commuter_ServiceDesc is the grpc.ServiceDesc for Commuter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Commuter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.commuter.Commuter",
+	HandlerType: (*CommuterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CallFuncOnCommuter",
+			Handler:    _Commuter_CallFuncOnCommuter_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "сommuter.proto",
+}
@@ -0,0 +1,1292 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: contracts.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package contracts
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ChatKind int32
+
+const (
+	ChatKind_CHAT_ROOM   ChatKind = 0
+	ChatKind_CHAT_DIRECT ChatKind = 1
+	// Системные сообщения отправляет только сервер (transmitter или хост)
+	ChatKind_CHAT_SYSTEM ChatKind = 2
+)
+
+// Enum value maps for ChatKind.
+var (
+	ChatKind_name = map[int32]string{
+		0: "CHAT_ROOM",
+		1: "CHAT_DIRECT",
+		2: "CHAT_SYSTEM",
+	}
+	ChatKind_value = map[string]int32{
+		"CHAT_ROOM":   0,
+		"CHAT_DIRECT": 1,
+		"CHAT_SYSTEM": 2,
+	}
+)
+
+func (x ChatKind) Enum() *ChatKind {
+	p := new(ChatKind)
+	*p = x
+	return p
+}
+
+func (x ChatKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChatKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_contracts_proto_enumTypes[0].Descriptor()
+}
+
+func (ChatKind) Type() protoreflect.EnumType {
+	return &file_contracts_proto_enumTypes[0]
+}
+
+func (x ChatKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChatKind.Descriptor instead.
+func (ChatKind) EnumDescriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{0}
+}
+
+type MatchAssignment_Transport int32
+
+const (
+	MatchAssignment_RELAY MatchAssignment_Transport = 0
+	MatchAssignment_P2P   MatchAssignment_Transport = 1
+)
+
+// Enum value maps for MatchAssignment_Transport.
+var (
+	MatchAssignment_Transport_name = map[int32]string{
+		0: "RELAY",
+		1: "P2P",
+	}
+	MatchAssignment_Transport_value = map[string]int32{
+		"RELAY": 0,
+		"P2P":   1,
+	}
+)
+
+func (x MatchAssignment_Transport) Enum() *MatchAssignment_Transport {
+	p := new(MatchAssignment_Transport)
+	*p = x
+	return p
+}
+
+func (x MatchAssignment_Transport) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MatchAssignment_Transport) Descriptor() protoreflect.EnumDescriptor {
+	return file_contracts_proto_enumTypes[1].Descriptor()
+}
+
+func (MatchAssignment_Transport) Type() protoreflect.EnumType {
+	return &file_contracts_proto_enumTypes[1]
+}
+
+func (x MatchAssignment_Transport) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MatchAssignment_Transport.Descriptor instead.
+func (MatchAssignment_Transport) EnumDescriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{6, 0}
+}
+
+type Text struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          string                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Text) Reset() {
+	*x = Text{}
+	mi := &file_contracts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Text) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Text) ProtoMessage() {}
+
+func (x *Text) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Text.ProtoReflect.Descriptor instead.
+func (*Text) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Text) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+// Снимок состояния мира: полный или дельта относительно подтвержденного узлом тика.
+// Бинарный формат описан в пакете snapshot
+type WorldSnapshot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// номер последнего ввода получателя, обработанного бэкендом (для сверки предсказания)
+	LastProcessedInput uint32 `protobuf:"varint,2,opt,name=last_processed_input,json=lastProcessedInput,proto3" json:"last_processed_input,omitempty"`
+	// ID игрока получателя в мире снимка, 0 у зрителя
+	PlayerId      uint32 `protobuf:"varint,3,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorldSnapshot) Reset() {
+	*x = WorldSnapshot{}
+	mi := &file_contracts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorldSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorldSnapshot) ProtoMessage() {}
+
+func (x *WorldSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorldSnapshot.ProtoReflect.Descriptor instead.
+func (*WorldSnapshot) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WorldSnapshot) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *WorldSnapshot) GetLastProcessedInput() uint32 {
+	if x != nil {
+		return x.LastProcessedInput
+	}
+	return 0
+}
+
+func (x *WorldSnapshot) GetPlayerId() uint32 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+type SnapshotAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tick          uint32                 `protobuf:"varint,1,opt,name=tick,proto3" json:"tick,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotAck) Reset() {
+	*x = SnapshotAck{}
+	mi := &file_contracts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotAck) ProtoMessage() {}
+
+func (x *SnapshotAck) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotAck.ProtoReflect.Descriptor instead.
+func (*SnapshotAck) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SnapshotAck) GetTick() uint32 {
+	if x != nil {
+		return x.Tick
+	}
+	return 0
+}
+
+// Сообщение матча от бэкенда узлу (таблица лидеров и т.п.), type - envelope.Type.
+// Тот же конверт, что идет по data channel между узлами (пакет envelope)
+type Envelope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          uint32                 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_contracts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Envelope) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Envelope) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Envelope) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+// Ввод игрока на один тик. Клиент шлет только направление,
+// seq растет монотонно и эхом возвращается в WorldSnapshot.last_processed_input
+type PlayerInput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint32                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	DirX          float32                `protobuf:"fixed32,2,opt,name=dir_x,json=dirX,proto3" json:"dir_x,omitempty"`
+	DirY          float32                `protobuf:"fixed32,3,opt,name=dir_y,json=dirY,proto3" json:"dir_y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayerInput) Reset() {
+	*x = PlayerInput{}
+	mi := &file_contracts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerInput) ProtoMessage() {}
+
+func (x *PlayerInput) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerInput.ProtoReflect.Descriptor instead.
+func (*PlayerInput) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PlayerInput) GetSeq() uint32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PlayerInput) GetDirX() float32 {
+	if x != nil {
+		return x.DirX
+	}
+	return 0
+}
+
+func (x *PlayerInput) GetDirY() float32 {
+	if x != nil {
+		return x.DirY
+	}
+	return 0
+}
+
+// Заявка узла на подбор матча: рейтинг и RTT до доступных relay (адрес -> миллисекунды)
+type MatchmakingTicket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Skill         float64                `protobuf:"fixed64,2,opt,name=skill,proto3" json:"skill,omitempty"`
+	RttMillis     map[string]uint32      `protobuf:"bytes,3,rep,name=rtt_millis,json=rttMillis,proto3" json:"rtt_millis,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	P2PCapable    bool                   `protobuf:"varint,4,opt,name=p2p_capable,json=p2pCapable,proto3" json:"p2p_capable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchmakingTicket) Reset() {
+	*x = MatchmakingTicket{}
+	mi := &file_contracts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchmakingTicket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchmakingTicket) ProtoMessage() {}
+
+func (x *MatchmakingTicket) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchmakingTicket.ProtoReflect.Descriptor instead.
+func (*MatchmakingTicket) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MatchmakingTicket) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *MatchmakingTicket) GetSkill() float64 {
+	if x != nil {
+		return x.Skill
+	}
+	return 0
+}
+
+func (x *MatchmakingTicket) GetRttMillis() map[string]uint32 {
+	if x != nil {
+		return x.RttMillis
+	}
+	return nil
+}
+
+func (x *MatchmakingTicket) GetP2PCapable() bool {
+	if x != nil {
+		return x.P2PCapable
+	}
+	return false
+}
+
+// Подобранный матч и план связи для участника
+type MatchAssignment struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	MatchId       string                    `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	Members       []string                  `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	Relay         string                    `protobuf:"bytes,3,opt,name=relay,proto3" json:"relay,omitempty"`
+	Transport     MatchAssignment_Transport `protobuf:"varint,4,opt,name=transport,proto3,enum=common.contracts.MatchAssignment_Transport" json:"transport,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchAssignment) Reset() {
+	*x = MatchAssignment{}
+	mi := &file_contracts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchAssignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchAssignment) ProtoMessage() {}
+
+func (x *MatchAssignment) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchAssignment.ProtoReflect.Descriptor instead.
+func (*MatchAssignment) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MatchAssignment) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *MatchAssignment) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *MatchAssignment) GetRelay() string {
+	if x != nil {
+		return x.Relay
+	}
+	return ""
+}
+
+func (x *MatchAssignment) GetTransport() MatchAssignment_Transport {
+	if x != nil {
+		return x.Transport
+	}
+	return MatchAssignment_RELAY
+}
+
+// Сериализованное состояние матча от текущего хоста (epoch - номер назначения хоста)
+type HostState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	NodeId        string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Tick          uint32                 `protobuf:"varint,4,opt,name=tick,proto3" json:"tick,omitempty"`
+	State         []byte                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostState) Reset() {
+	*x = HostState{}
+	mi := &file_contracts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostState) ProtoMessage() {}
+
+func (x *HostState) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostState.ProtoReflect.Descriptor instead.
+func (*HostState) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HostState) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *HostState) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *HostState) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *HostState) GetTick() uint32 {
+	if x != nil {
+		return x.Tick
+	}
+	return 0
+}
+
+func (x *HostState) GetState() []byte {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type HostReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	NodeId        string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostReport) Reset() {
+	*x = HostReport{}
+	mi := &file_contracts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostReport) ProtoMessage() {}
+
+func (x *HostReport) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostReport.ProtoReflect.Descriptor instead.
+func (*HostReport) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HostReport) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *HostReport) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+// Назначение хоста; state передается только новому хосту при миграции
+type HostAssignment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	Host          string                 `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Members       []string               `protobuf:"bytes,4,rep,name=members,proto3" json:"members,omitempty"`
+	State         []byte                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostAssignment) Reset() {
+	*x = HostAssignment{}
+	mi := &file_contracts_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostAssignment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostAssignment) ProtoMessage() {}
+
+func (x *HostAssignment) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostAssignment.ProtoReflect.Descriptor instead.
+func (*HostAssignment) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *HostAssignment) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *HostAssignment) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *HostAssignment) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *HostAssignment) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *HostAssignment) GetState() []byte {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+// Подключение к идущему матчу зрителем: без ввода и без места игрока
+type SpectateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	NodeId        string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpectateRequest) Reset() {
+	*x = SpectateRequest{}
+	mi := &file_contracts_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpectateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpectateRequest) ProtoMessage() {}
+
+func (x *SpectateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpectateRequest.ProtoReflect.Descriptor instead.
+func (*SpectateRequest) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SpectateRequest) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *SpectateRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+// Вход игроком в матч, который подобрал матчмейкинг регулятора
+type JoinMatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MatchId       string                 `protobuf:"bytes,1,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	NodeId        string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinMatchRequest) Reset() {
+	*x = JoinMatchRequest{}
+	mi := &file_contracts_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinMatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinMatchRequest) ProtoMessage() {}
+
+func (x *JoinMatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinMatchRequest.ProtoReflect.Descriptor instead.
+func (*JoinMatchRequest) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *JoinMatchRequest) GetMatchId() string {
+	if x != nil {
+		return x.MatchId
+	}
+	return ""
+}
+
+func (x *JoinMatchRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+// Переключение камеры зрителя на игрока
+type FollowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	PlayerId      uint32                 `protobuf:"varint,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FollowRequest) Reset() {
+	*x = FollowRequest{}
+	mi := &file_contracts_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FollowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FollowRequest) ProtoMessage() {}
+
+func (x *FollowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FollowRequest.ProtoReflect.Descriptor instead.
+func (*FollowRequest) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FollowRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *FollowRequest) GetPlayerId() uint32 {
+	if x != nil {
+		return x.PlayerId
+	}
+	return 0
+}
+
+// Сообщение чата. from проставляет тот, кто маршрутизирует сообщение, а не отправитель
+type ChatMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  ChatKind               `protobuf:"varint,1,opt,name=kind,proto3,enum=common.contracts.ChatKind" json:"kind,omitempty"`
+	From  string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	// to - получатель личного сообщения
+	To             string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Text           string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	SentUnixMillis int64  `protobuf:"varint,5,opt,name=sent_unix_millis,json=sentUnixMillis,proto3" json:"sent_unix_millis,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_contracts_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ChatMessage) GetKind() ChatKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ChatKind_CHAT_ROOM
+}
+
+func (x *ChatMessage) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetSentUnixMillis() int64 {
+	if x != nil {
+		return x.SentUnixMillis
+	}
+	return 0
+}
+
+// Просьба transmitter перейти на другой transmitter до deadline, после чего туннель будет закрыт.
+// Пустой address - узел выбирает transmitter сам
+type MigrateRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Address            string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	DeadlineUnixMillis int64                  `protobuf:"varint,2,opt,name=deadline_unix_millis,json=deadlineUnixMillis,proto3" json:"deadline_unix_millis,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *MigrateRequest) Reset() {
+	*x = MigrateRequest{}
+	mi := &file_contracts_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MigrateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MigrateRequest) ProtoMessage() {}
+
+func (x *MigrateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MigrateRequest.ProtoReflect.Descriptor instead.
+func (*MigrateRequest) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MigrateRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *MigrateRequest) GetDeadlineUnixMillis() int64 {
+	if x != nil {
+		return x.DeadlineUnixMillis
+	}
+	return 0
+}
+
+// Первый запрос узла: какой экземпляр выбрать по объявленной нагрузке. kind - transmitter или commuter
+type BootstrapRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Region        string                 `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BootstrapRequest) Reset() {
+	*x = BootstrapRequest{}
+	mi := &file_contracts_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BootstrapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BootstrapRequest) ProtoMessage() {}
+
+func (x *BootstrapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BootstrapRequest.ProtoReflect.Descriptor instead.
+func (*BootstrapRequest) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BootstrapRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *BootstrapRequest) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+type BootstrapResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Region        string                 `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BootstrapResponse) Reset() {
+	*x = BootstrapResponse{}
+	mi := &file_contracts_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BootstrapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BootstrapResponse) ProtoMessage() {}
+
+func (x *BootstrapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_contracts_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BootstrapResponse.ProtoReflect.Descriptor instead.
+func (*BootstrapResponse) Descriptor() ([]byte, []int) {
+	return file_contracts_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BootstrapResponse) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *BootstrapResponse) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+var File_contracts_proto protoreflect.FileDescriptor
+
+const file_contracts_proto_rawDesc = "" +
+	"\n" +
+	"\x0fcontracts.proto\x12\x10common.contracts\"\x1a\n" +
+	"\x04Text\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\tR\x04data\"r\n" +
+	"\rWorldSnapshot\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x120\n" +
+	"\x14last_processed_input\x18\x02 \x01(\rR\x12lastProcessedInput\x12\x1b\n" +
+	"\tplayer_id\x18\x03 \x01(\rR\bplayerId\"!\n" +
+	"\vSnapshotAck\x12\x12\n" +
+	"\x04tick\x18\x01 \x01(\rR\x04tick\"L\n" +
+	"\bEnvelope\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\rR\x04type\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\"I\n" +
+	"\vPlayerInput\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\rR\x03seq\x12\x13\n" +
+	"\x05dir_x\x18\x02 \x01(\x02R\x04dirX\x12\x13\n" +
+	"\x05dir_y\x18\x03 \x01(\x02R\x04dirY\"\xf4\x01\n" +
+	"\x11MatchmakingTicket\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05skill\x18\x02 \x01(\x01R\x05skill\x12Q\n" +
+	"\n" +
+	"rtt_millis\x18\x03 \x03(\v22.common.contracts.MatchmakingTicket.RttMillisEntryR\trttMillis\x12\x1f\n" +
+	"\vp2p_capable\x18\x04 \x01(\bR\n" +
+	"p2pCapable\x1a<\n" +
+	"\x0eRttMillisEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\xc8\x01\n" +
+	"\x0fMatchAssignment\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\tR\amembers\x12\x14\n" +
+	"\x05relay\x18\x03 \x01(\tR\x05relay\x12I\n" +
+	"\ttransport\x18\x04 \x01(\x0e2+.common.contracts.MatchAssignment.TransportR\ttransport\"\x1f\n" +
+	"\tTransport\x12\t\n" +
+	"\x05RELAY\x10\x00\x12\a\n" +
+	"\x03P2P\x10\x01\"\x7f\n" +
+	"\tHostState\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\x12\x12\n" +
+	"\x04tick\x18\x04 \x01(\rR\x04tick\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\fR\x05state\"@\n" +
+	"\n" +
+	"HostReport\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\"\x85\x01\n" +
+	"\x0eHostAssignment\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\x12\x18\n" +
+	"\amembers\x18\x04 \x03(\tR\amembers\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\fR\x05state\"E\n" +
+	"\x0fSpectateRequest\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\"F\n" +
+	"\x10JoinMatchRequest\x12\x19\n" +
+	"\bmatch_id\x18\x01 \x01(\tR\amatchId\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\"E\n" +
+	"\rFollowRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1b\n" +
+	"\tplayer_id\x18\x02 \x01(\rR\bplayerId\"\x9f\x01\n" +
+	"\vChatMessage\x12.\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x1a.common.contracts.ChatKindR\x04kind\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12(\n" +
+	"\x10sent_unix_millis\x18\x05 \x01(\x03R\x0esentUnixMillis\"\\\n" +
+	"\x0eMigrateRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x120\n" +
+	"\x14deadline_unix_millis\x18\x02 \x01(\x03R\x12deadlineUnixMillis\">\n" +
+	"\x10BootstrapRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\"E\n" +
+	"\x11BootstrapResponse\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region*;\n" +
+	"\bChatKind\x12\r\n" +
+	"\tCHAT_ROOM\x10\x00\x12\x0f\n" +
+	"\vCHAT_DIRECT\x10\x01\x12\x0f\n" +
+	"\vCHAT_SYSTEM\x10\x02B6Z4github.com/matelq/p2pmp/src/network/common/contractsb\x06proto3"
+
+var (
+	file_contracts_proto_rawDescOnce sync.Once
+	file_contracts_proto_rawDescData []byte
+)
+
+func file_contracts_proto_rawDescGZIP() []byte {
+	file_contracts_proto_rawDescOnce.Do(func() {
+		file_contracts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_contracts_proto_rawDesc), len(file_contracts_proto_rawDesc)))
+	})
+	return file_contracts_proto_rawDescData
+}
+
+var file_contracts_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_contracts_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_contracts_proto_goTypes = []any{
+	(ChatKind)(0),                  // 0: common.contracts.ChatKind
+	(MatchAssignment_Transport)(0), // 1: common.contracts.MatchAssignment.Transport
+	(*Text)(nil),                   // 2: common.contracts.Text
+	(*WorldSnapshot)(nil),          // 3: common.contracts.WorldSnapshot
+	(*SnapshotAck)(nil),            // 4: common.contracts.SnapshotAck
+	(*Envelope)(nil),               // 5: common.contracts.Envelope
+	(*PlayerInput)(nil),            // 6: common.contracts.PlayerInput
+	(*MatchmakingTicket)(nil),      // 7: common.contracts.MatchmakingTicket
+	(*MatchAssignment)(nil),        // 8: common.contracts.MatchAssignment
+	(*HostState)(nil),              // 9: common.contracts.HostState
+	(*HostReport)(nil),             // 10: common.contracts.HostReport
+	(*HostAssignment)(nil),         // 11: common.contracts.HostAssignment
+	(*SpectateRequest)(nil),        // 12: common.contracts.SpectateRequest
+	(*JoinMatchRequest)(nil),       // 13: common.contracts.JoinMatchRequest
+	(*FollowRequest)(nil),          // 14: common.contracts.FollowRequest
+	(*ChatMessage)(nil),            // 15: common.contracts.ChatMessage
+	(*MigrateRequest)(nil),         // 16: common.contracts.MigrateRequest
+	(*BootstrapRequest)(nil),       // 17: common.contracts.BootstrapRequest
+	(*BootstrapResponse)(nil),      // 18: common.contracts.BootstrapResponse
+	nil,                            // 19: common.contracts.MatchmakingTicket.RttMillisEntry
+}
+var file_contracts_proto_depIdxs = []int32{
+	19, // 0: common.contracts.MatchmakingTicket.rtt_millis:type_name -> common.contracts.MatchmakingTicket.RttMillisEntry
+	1,  // 1: common.contracts.MatchAssignment.transport:type_name -> common.contracts.MatchAssignment.Transport
+	0,  // 2: common.contracts.ChatMessage.kind:type_name -> common.contracts.ChatKind
+	3,  // [3:3] is the sub-list for method output_type
+	3,  // [3:3] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_contracts_proto_init() }
+func file_contracts_proto_init() {
+	if File_contracts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_contracts_proto_rawDesc), len(file_contracts_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_contracts_proto_goTypes,
+		DependencyIndexes: file_contracts_proto_depIdxs,
+		EnumInfos:         file_contracts_proto_enumTypes,
+		MessageInfos:      file_contracts_proto_msgTypes,
+	}.Build()
+	File_contracts_proto = out.File
+	file_contracts_proto_goTypes = nil
+	file_contracts_proto_depIdxs = nil
+}
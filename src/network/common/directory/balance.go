@@ -0,0 +1,62 @@
+package directory
+
+import "math/rand/v2"
+
+// DefaultCapacity - сколько узлов считается допустимым для экземпляра без объявленного Capacity
+const DefaultCapacity = 1000
+
+// Pick выбирает экземпляр для нового узла: из региона region, если там есть
+// свободное место, иначе из любого региона. Выбор случайный с весом по числу
+// свободных мест, чтобы узлы, пришедшие одновременно, не попадали все на
+// один наименее загруженный экземпляр. Выводимые из работы и заполненные
+// экземпляры не выбираются
+func Pick(instances []Instance, region string) (Instance, bool) {
+	if region != "" {
+		local := make([]Instance, 0, len(instances))
+		for _, instance := range instances {
+			if instance.Region == region {
+				local = append(local, instance)
+			}
+		}
+
+		if instance, ok := pick(local); ok {
+			return instance, true
+		}
+	}
+
+	return pick(instances)
+}
+
+func pick(instances []Instance) (Instance, bool) {
+	total := 0
+	for _, instance := range instances {
+		total += free(instance)
+	}
+
+	if total == 0 {
+		return Instance{}, false
+	}
+
+	n := rand.IntN(total)
+	for _, instance := range instances {
+		if n -= free(instance); n < 0 {
+			return instance, true
+		}
+	}
+
+	return Instance{}, false
+}
+
+// free - число свободных мест экземпляра
+func free(instance Instance) int {
+	if instance.Draining {
+		return 0
+	}
+
+	capacity := instance.Capacity
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+
+	return max(capacity-instance.Load, 0)
+}
@@ -0,0 +1,73 @@
+package directory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPick(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []Instance
+		region    string
+		// want - экземпляры, которые может выбрать Pick; пустой - выбрать нечего
+		want []string
+	}{
+		{"none", nil, "", nil},
+		{"one", []Instance{{ID: "a", Capacity: 10}}, "", []string{"a"}},
+		{"default capacity", []Instance{{ID: "a", Load: DefaultCapacity - 1}}, "", []string{"a"}},
+		{"default capacity full", []Instance{{ID: "a", Load: DefaultCapacity}}, "", nil},
+		{"skips draining", []Instance{{ID: "a", Capacity: 10, Draining: true}, {ID: "b", Capacity: 10}}, "", []string{"b"}},
+		{"skips full", []Instance{{ID: "a", Capacity: 10, Load: 10}, {ID: "b", Capacity: 10, Load: 3}}, "", []string{"b"}},
+		{"overloaded", []Instance{{ID: "a", Capacity: 10, Load: 12}}, "", nil},
+		{"all draining", []Instance{{ID: "a", Capacity: 10, Draining: true}}, "", nil},
+		{"any region", []Instance{{ID: "a", Capacity: 10, Region: "eu"}, {ID: "b", Capacity: 10, Region: "us"}}, "", []string{"a", "b"}},
+		{"prefers region", []Instance{{ID: "a", Capacity: 10, Region: "eu"}, {ID: "b", Capacity: 10, Region: "us"}}, "us", []string{"b"}},
+		{"full region", []Instance{{ID: "a", Capacity: 10, Region: "eu"}, {ID: "b", Capacity: 10, Load: 10, Region: "us"}}, "us", []string{"a"}},
+		{"unknown region", []Instance{{ID: "a", Capacity: 10, Region: "eu"}}, "asia", []string{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Выбор случайный: повторяем, чтобы попасть во все варианты
+			picked := map[string]bool{}
+
+			for range 200 {
+				instance, ok := Pick(test.instances, test.region)
+				if ok != (len(test.want) > 0) {
+					t.Fatalf("Pick ok = %v, want %v", ok, len(test.want) > 0)
+				}
+
+				if !ok {
+					return
+				}
+
+				if !slices.Contains(test.want, instance.ID) {
+					t.Fatalf("Pick = %s, want one of %v", instance.ID, test.want)
+				}
+
+				picked[instance.ID] = true
+			}
+
+			if len(picked) != len(test.want) {
+				t.Fatalf("Pick chose %v, want all of %v", picked, test.want)
+			}
+		})
+	}
+}
+
+func TestPickWeighted(t *testing.T) {
+	instances := []Instance{{ID: "busy", Capacity: 100, Load: 99}, {ID: "idle", Capacity: 100}}
+
+	idle := 0
+	for range 1000 {
+		if instance, _ := Pick(instances, ""); instance.ID == "idle" {
+			idle++
+		}
+	}
+
+	// Свободных мест 1 и 100: пустой выбирается примерно в 99% случаев
+	if idle < 950 {
+		t.Fatalf("idle instance picked %d times of 1000, want about 990", idle)
+	}
+}
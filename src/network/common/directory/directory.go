@@ -0,0 +1,268 @@
+// Общий каталог расположения узлов для нескольких экземпляров transmitter.
+// Каждый экземпляр записывает в каталог свои подключенные узлы, а по
+// каталогу находит экземпляр, к которому подключен чужой узел, и пересылает
+// ему сообщения. Там же хранится состав комнат, чтобы матч мог включать
+// узлы разных экземпляров, и нагрузка экземпляров, по которой новые узлы
+// распределяются между ними (см. Pick). Memory подходит для одного
+// процесса, Redis - для кластера
+package directory
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultTTL - сколько запись об узле живет без обновления: записи упавшего
+// экземпляра пропадают сами
+const DefaultTTL = 30 * time.Second
+
+var ErrNotFound = errors.New("directory: not found")
+
+// Directory - каталог узлов, комнат и экземпляров. Методы потокобезопасны
+type Directory interface {
+	// Register записывает, что узел подключен к экземпляру instance, на ttl
+	Register(nodeID, instance string, ttl time.Duration) error
+	// Unregister удаляет запись, если узел все еще записан за instance:
+	// узел мог уже переподключиться к другому экземпляру
+	Unregister(nodeID, instance string) error
+	// Lookup возвращает экземпляр узла или ErrNotFound
+	Lookup(nodeID string) (string, error)
+
+	JoinRoom(roomID, nodeID string) error
+	LeaveRoom(roomID, nodeID string) error
+	CloseRoom(roomID string) error
+	// RoomOf возвращает комнату узла или ErrNotFound
+	RoomOf(nodeID string) (string, error)
+	RoomMembers(roomID string) ([]string, error)
+
+	// Announce сообщает нагрузку экземпляра; запись живет ttl без повторного объявления
+	Announce(instance Instance, ttl time.Duration) error
+	// Instances возвращает живые экземпляры вида kind
+	Instances(kind string) ([]Instance, error)
+}
+
+// Виды экземпляров, к которым подключаются узлы
+const (
+	KindTransmitter = "transmitter"
+	KindCommuter    = "commuter"
+)
+
+// Instance - экземпляр сервера и его нагрузка
+type Instance struct {
+	// ID - адрес экземпляра для других экземпляров
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Address - адрес, к которому подключаются узлы
+	Address string `json:"address"`
+	Region  string `json:"region,omitempty"`
+	// Load - подключенные узлы, Capacity - сколько их экземпляр выдерживает
+	Load     int  `json:"load"`
+	Capacity int  `json:"capacity"`
+	Draining bool `json:"draining,omitempty"`
+}
+
+type location struct {
+	instance string
+	expires  time.Time
+}
+
+type announcement struct {
+	instance Instance
+	expires  time.Time
+}
+
+// Memory - каталог в памяти процесса
+type Memory struct {
+	mu       sync.Mutex
+	nodes    map[string]location
+	rooms    map[string]map[string]struct{}
+	nodeRoom map[string]string
+	// instances - объявления по виду и ID экземпляра
+	instances map[string]map[string]announcement
+}
+
+var _ Directory = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		nodes:     map[string]location{},
+		rooms:     map[string]map[string]struct{}{},
+		nodeRoom:  map[string]string{},
+		instances: map[string]map[string]announcement{},
+	}
+}
+
+func (m *Memory) Register(nodeID, instance string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nodes[nodeID] = location{instance: instance, expires: time.Now().Add(ttl)}
+
+	return nil
+}
+
+func (m *Memory) Unregister(nodeID, instance string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.nodes[nodeID].instance == instance {
+		delete(m.nodes, nodeID)
+	}
+
+	return nil
+}
+
+func (m *Memory) Lookup(nodeID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, ok := m.nodes[nodeID]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	if time.Now().After(node.expires) {
+		delete(m.nodes, nodeID)
+		return "", ErrNotFound
+	}
+
+	return node.instance, nil
+}
+
+func (m *Memory) JoinRoom(roomID, nodeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[roomID]
+	if !ok {
+		room = map[string]struct{}{}
+		m.rooms[roomID] = room
+	}
+
+	room[nodeID] = struct{}{}
+	m.nodeRoom[nodeID] = roomID
+
+	return nil
+}
+
+func (m *Memory) LeaveRoom(roomID, nodeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.rooms[roomID], nodeID)
+
+	if m.nodeRoom[nodeID] == roomID {
+		delete(m.nodeRoom, nodeID)
+	}
+
+	return nil
+}
+
+func (m *Memory) CloseRoom(roomID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for nodeID := range m.rooms[roomID] {
+		if m.nodeRoom[nodeID] == roomID {
+			delete(m.nodeRoom, nodeID)
+		}
+	}
+
+	delete(m.rooms, roomID)
+
+	return nil
+}
+
+func (m *Memory) RoomOf(nodeID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	roomID, ok := m.nodeRoom[nodeID]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return roomID, nil
+}
+
+func (m *Memory) RoomMembers(roomID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	members := make([]string, 0, len(m.rooms[roomID]))
+	for nodeID := range m.rooms[roomID] {
+		members = append(members, nodeID)
+	}
+
+	return members, nil
+}
+
+func (m *Memory) Announce(instance Instance, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instances, ok := m.instances[instance.Kind]
+	if !ok {
+		instances = map[string]announcement{}
+		m.instances[instance.Kind] = instances
+	}
+
+	instances[instance.ID] = announcement{instance: instance, expires: time.Now().Add(ttl)}
+
+	return nil
+}
+
+func (m *Memory) Instances(kind string) ([]Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	instances := make([]Instance, 0, len(m.instances[kind]))
+
+	for id, a := range m.instances[kind] {
+		if now.After(a.expires) {
+			delete(m.instances[kind], id)
+			continue
+		}
+
+		instances = append(instances, a.instance)
+	}
+
+	return instances, nil
+}
+
+// Open открывает каталог по адресу spec: пустой - каталог в памяти для
+// одного процесса, redis://[:пароль@]хост:порт - общий. timeout ограничивает
+// каждую команду Redis
+func Open(spec string, timeout time.Duration) (Directory, error) {
+	if spec == "" {
+		return NewMemory(), nil
+	}
+
+	conn, err := OpenRedis(spec, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedis(conn), nil
+}
+
+// OpenRedis подключается к Redis по адресу redis://[:пароль@]хост:порт, чтобы
+// хранить в нем рядом с каталогом и другие общие данные экземпляров
+func OpenRedis(spec string, timeout time.Duration) (*RedisConn, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("directory: unsupported address %q, want redis://host:port", spec)
+	}
+
+	password, _ := u.User.Password()
+
+	return DialRedis(u.Host, password, timeout)
+}
@@ -0,0 +1,237 @@
+package directory
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryConn - подделка Redis с командами, которые использует каталог
+type memoryConn struct {
+	strings map[string]string
+	expires map[string]time.Time
+	sets    map[string]map[string]struct{}
+}
+
+func newMemoryConn() *memoryConn {
+	return &memoryConn{strings: map[string]string{}, expires: map[string]time.Time{}, sets: map[string]map[string]struct{}{}}
+}
+
+func (c *memoryConn) Do(args ...string) (any, error) {
+	switch args[0] {
+	case "SET":
+		c.strings[args[1]] = args[2]
+		delete(c.expires, args[1])
+
+		if len(args) == 5 && args[3] == "PX" {
+			ms, err := strconv.ParseInt(args[4], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+
+			c.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+
+		return "OK", nil
+	case "GET":
+		if expires, ok := c.expires[args[1]]; ok && time.Now().After(expires) {
+			delete(c.strings, args[1])
+		}
+
+		value, ok := c.strings[args[1]]
+		if !ok {
+			return nil, nil
+		}
+
+		return value, nil
+	case "DEL":
+		delete(c.strings, args[1])
+		delete(c.sets, args[1])
+
+		return int64(1), nil
+	case "SADD":
+		set, ok := c.sets[args[1]]
+		if !ok {
+			set = map[string]struct{}{}
+			c.sets[args[1]] = set
+		}
+
+		set[args[2]] = struct{}{}
+
+		return int64(1), nil
+	case "SREM":
+		delete(c.sets[args[1]], args[2])
+		return int64(1), nil
+	case "SMEMBERS":
+		members := make([]any, 0, len(c.sets[args[1]]))
+		for member := range c.sets[args[1]] {
+			members = append(members, member)
+		}
+
+		return members, nil
+	default:
+		return nil, fmt.Errorf("unsupported command %s", args[0])
+	}
+}
+
+// directories - одинаковые проверки выполняются для каталога в памяти и в Redis
+func directories() map[string]func() Directory {
+	return map[string]func() Directory{
+		"memory": func() Directory { return NewMemory() },
+		"redis":  func() Directory { return NewRedis(newMemoryConn()) },
+	}
+}
+
+func TestDirectoryNodes(t *testing.T) {
+	for name, open := range directories() {
+		t.Run(name, func(t *testing.T) {
+			d := open()
+
+			if _, err := d.Lookup("a"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Lookup of unknown node error = %v, want %v", err, ErrNotFound)
+			}
+
+			if err := d.Register("a", "one", time.Minute); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			if instance, err := d.Lookup("a"); err != nil || instance != "one" {
+				t.Fatalf("Lookup = %q, %v, want one", instance, err)
+			}
+
+			// Узел переподключился к другому экземпляру: старый его не удаляет
+			if err := d.Register("a", "two", time.Minute); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			if err := d.Unregister("a", "one"); err != nil {
+				t.Fatalf("Unregister: %v", err)
+			}
+
+			if instance, err := d.Lookup("a"); err != nil || instance != "two" {
+				t.Fatalf("Lookup after stale Unregister = %q, %v, want two", instance, err)
+			}
+
+			if err := d.Unregister("a", "two"); err != nil {
+				t.Fatalf("Unregister: %v", err)
+			}
+
+			if _, err := d.Lookup("a"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Lookup after Unregister error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestDirectoryNodeExpires(t *testing.T) {
+	for name, open := range directories() {
+		t.Run(name, func(t *testing.T) {
+			d := open()
+
+			if err := d.Register("a", "one", time.Millisecond); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+
+			if _, err := d.Lookup("a"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Lookup of expired node error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestDirectoryRooms(t *testing.T) {
+	for name, open := range directories() {
+		t.Run(name, func(t *testing.T) {
+			d := open()
+
+			for _, nodeID := range []string{"a", "b", "c"} {
+				if err := d.JoinRoom("r", nodeID); err != nil {
+					t.Fatalf("JoinRoom: %v", err)
+				}
+			}
+
+			members, err := d.RoomMembers("r")
+			slices.Sort(members)
+
+			if err != nil || !slices.Equal(members, []string{"a", "b", "c"}) {
+				t.Fatalf("RoomMembers = %v, %v, want [a b c]", members, err)
+			}
+
+			if roomID, err := d.RoomOf("b"); err != nil || roomID != "r" {
+				t.Fatalf("RoomOf = %q, %v, want r", roomID, err)
+			}
+
+			if err := d.LeaveRoom("r", "b"); err != nil {
+				t.Fatalf("LeaveRoom: %v", err)
+			}
+
+			if _, err := d.RoomOf("b"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("RoomOf after LeaveRoom error = %v, want %v", err, ErrNotFound)
+			}
+
+			// c перешел в другую комнату: закрытие старой его не трогает
+			if err := d.JoinRoom("s", "c"); err != nil {
+				t.Fatalf("JoinRoom: %v", err)
+			}
+
+			if err := d.CloseRoom("r"); err != nil {
+				t.Fatalf("CloseRoom: %v", err)
+			}
+
+			if members, err := d.RoomMembers("r"); err != nil || len(members) != 0 {
+				t.Fatalf("RoomMembers of closed room = %v, %v, want none", members, err)
+			}
+
+			if _, err := d.RoomOf("a"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("RoomOf after CloseRoom error = %v, want %v", err, ErrNotFound)
+			}
+
+			if roomID, err := d.RoomOf("c"); err != nil || roomID != "s" {
+				t.Fatalf("RoomOf of moved node = %q, %v, want s", roomID, err)
+			}
+		})
+	}
+}
+
+func TestDirectoryInstances(t *testing.T) {
+	for name, open := range directories() {
+		t.Run(name, func(t *testing.T) {
+			d := open()
+
+			announced := []Instance{
+				{ID: "one", Kind: KindTransmitter, Address: "one:3001", Load: 1, Capacity: 10},
+				{ID: "two", Kind: KindTransmitter, Address: "two:3001", Draining: true},
+				{ID: "three", Kind: KindCommuter, Address: "three:3005"},
+			}
+
+			for _, instance := range announced {
+				if err := d.Announce(instance, time.Minute); err != nil {
+					t.Fatalf("Announce: %v", err)
+				}
+			}
+
+			if err := d.Announce(Instance{ID: "gone", Kind: KindTransmitter}, time.Millisecond); err != nil {
+				t.Fatalf("Announce: %v", err)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+
+			instances, err := d.Instances(KindTransmitter)
+			if err != nil {
+				t.Fatalf("Instances: %v", err)
+			}
+
+			slices.SortFunc(instances, func(a, b Instance) int { return strings.Compare(a.ID, b.ID) })
+
+			if !slices.Equal(instances, []Instance{announced[0], announced[1]}) {
+				t.Fatalf("Instances = %+v, want %+v", instances, announced[:2])
+			}
+		})
+	}
+}
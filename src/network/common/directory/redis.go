@@ -0,0 +1,207 @@
+package directory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Conn выполняет одну команду Redis и возвращает ответ: string, int64,
+// []any или nil для пустого ответа. Реализуется RedisConn, в тестах
+// его можно заменить локальной подделкой
+type Conn interface {
+	Do(args ...string) (any, error)
+}
+
+// Ключи каталога в Redis
+const (
+	keyPrefix = "p2pmp:"
+	// nodeKey - строка с экземпляром узла и TTL
+	nodeKey = keyPrefix + "node:"
+	// roomKey - множество узлов комнаты
+	roomKey = keyPrefix + "room:"
+	// nodeRoomKey - строка с комнатой узла
+	nodeRoomKey = keyPrefix + "node-room:"
+	// instanceKey - JSON объявления экземпляра с TTL, ключ вида instance:вид:ID
+	instanceKey = keyPrefix + "instance:"
+	// instancesKey - множество ID экземпляров вида, ключ вида instances:вид
+	instancesKey = keyPrefix + "instances:"
+)
+
+// Redis - каталог в Redis или совместимом хранилище, общий для всех экземпляров
+type Redis struct {
+	conn Conn
+}
+
+var _ Directory = (*Redis)(nil)
+
+func NewRedis(conn Conn) *Redis {
+	return &Redis{conn: conn}
+}
+
+func (r *Redis) Register(nodeID, instance string, ttl time.Duration) error {
+	_, err := r.conn.Do("SET", nodeKey+nodeID, instance, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Unregister сравнивает и удаляет двумя командами. Гонка с регистрацией на
+// другом экземпляре между ними возможна, но ее исправит следующее обновление
+// записи тем экземпляром
+func (r *Redis) Unregister(nodeID, instance string) error {
+	return r.deleteIf(nodeKey+nodeID, instance)
+}
+
+func (r *Redis) Lookup(nodeID string) (string, error) {
+	return r.get(nodeKey + nodeID)
+}
+
+func (r *Redis) JoinRoom(roomID, nodeID string) error {
+	if _, err := r.conn.Do("SADD", roomKey+roomID, nodeID); err != nil {
+		return err
+	}
+
+	_, err := r.conn.Do("SET", nodeRoomKey+nodeID, roomID)
+
+	return err
+}
+
+func (r *Redis) LeaveRoom(roomID, nodeID string) error {
+	if _, err := r.conn.Do("SREM", roomKey+roomID, nodeID); err != nil {
+		return err
+	}
+
+	return r.deleteIf(nodeRoomKey+nodeID, roomID)
+}
+
+func (r *Redis) CloseRoom(roomID string) error {
+	members, err := r.RoomMembers(roomID)
+	if err != nil {
+		return err
+	}
+
+	for _, nodeID := range members {
+		if err := r.deleteIf(nodeRoomKey+nodeID, roomID); err != nil {
+			return err
+		}
+	}
+
+	_, err = r.conn.Do("DEL", roomKey+roomID)
+
+	return err
+}
+
+func (r *Redis) RoomOf(nodeID string) (string, error) {
+	return r.get(nodeRoomKey + nodeID)
+}
+
+func (r *Redis) RoomMembers(roomID string) ([]string, error) {
+	return r.members(roomKey + roomID)
+}
+
+func (r *Redis) members(key string) ([]string, error) {
+	reply, err := r.conn.Do("SMEMBERS", key)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("directory: unexpected SMEMBERS reply %T", reply)
+	}
+
+	members := make([]string, 0, len(items))
+	for _, item := range items {
+		member, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("directory: unexpected SMEMBERS item %T", item)
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (r *Redis) Announce(instance Instance, ttl time.Duration) error {
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+
+	key := instanceKey + instance.Kind + ":" + instance.ID
+	if _, err := r.conn.Do("SET", key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10)); err != nil {
+		return err
+	}
+
+	_, err = r.conn.Do("SADD", instancesKey+instance.Kind, instance.ID)
+
+	return err
+}
+
+// Instances заодно убирает из множества экземпляры, чьи объявления истекли
+func (r *Redis) Instances(kind string) ([]Instance, error) {
+	ids, err := r.members(instancesKey + kind)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0, len(ids))
+
+	for _, id := range ids {
+		data, err := r.get(instanceKey + kind + ":" + id)
+		if errors.Is(err, ErrNotFound) {
+			if _, err := r.conn.Do("SREM", instancesKey+kind, id); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var instance Instance
+		if err := json.Unmarshal([]byte(data), &instance); err != nil {
+			return nil, fmt.Errorf("directory: instance %s: %w", id, err)
+		}
+
+		instances = append(instances, instance)
+	}
+
+	return instances, nil
+}
+
+func (r *Redis) get(key string) (string, error) {
+	reply, err := r.conn.Do("GET", key)
+	if err != nil {
+		return "", err
+	}
+
+	switch value := reply.(type) {
+	case nil:
+		return "", ErrNotFound
+	case string:
+		return value, nil
+	default:
+		return "", fmt.Errorf("directory: unexpected GET reply %T", reply)
+	}
+}
+
+// deleteIf удаляет ключ, если в нем все еще value
+func (r *Redis) deleteIf(key, value string) error {
+	current, err := r.get(key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	if err != nil || current != value {
+		return err
+	}
+
+	_, err = r.conn.Do("DEL", key)
+
+	return err
+}
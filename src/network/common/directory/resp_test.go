@@ -0,0 +1,226 @@
+package directory
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedis отвечает на каждую команду очередным ответом из replies и
+// записывает полученные команды в commands
+type fakeRedis struct {
+	listener net.Listener
+	replies  chan string
+	commands chan []string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	f := &fakeRedis{listener: listener, replies: make(chan string, 16), commands: make(chan []string, 16)}
+	t.Cleanup(func() { listener.Close() })
+
+	go f.serve()
+
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		f.commands <- args
+
+		reply, ok := <-f.replies
+		if !ok {
+			return
+		}
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand читает команду клиента: массив bulk-строк
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+
+	return args, nil
+}
+
+func TestRedisConnReplies(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  any
+	}{
+		{"simple string", "+OK\r\n", "OK"},
+		{"integer", ":42\r\n", int64(42)},
+		{"negative integer", ":-7\r\n", int64(-7)},
+		{"bulk string", "$5\r\nhello\r\n", "hello"},
+		{"empty bulk string", "$0\r\n\r\n", ""},
+		{"bulk string with CRLF", "$4\r\na\r\nb\r\n", "a\r\nb"},
+		{"nil bulk string", "$-1\r\n", nil},
+		{"array", "*3\r\n$1\r\na\r\n:2\r\n+c\r\n", []any{"a", int64(2), "c"}},
+		{"empty array", "*0\r\n", []any{}},
+		{"nested array", "*2\r\n*1\r\n+x\r\n$1\r\ny\r\n", []any{[]any{"x"}, "y"}},
+	}
+
+	server := newFakeRedis(t)
+
+	conn, err := DialRedis(server.listener.Addr().String(), "", time.Second)
+	if err != nil {
+		t.Fatalf("DialRedis: %v", err)
+	}
+
+	defer conn.Close()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server.replies <- test.reply
+
+			got, err := conn.Do("GET", "key")
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Do = %#v, want %#v", got, test.want)
+			}
+
+			if args := <-server.commands; !reflect.DeepEqual(args, []string{"GET", "key"}) {
+				t.Fatalf("server got %q, want GET key", args)
+			}
+		})
+	}
+}
+
+func TestRedisConnServerError(t *testing.T) {
+	server := newFakeRedis(t)
+
+	conn, err := DialRedis(server.listener.Addr().String(), "", time.Second)
+	if err != nil {
+		t.Fatalf("DialRedis: %v", err)
+	}
+
+	defer conn.Close()
+
+	server.replies <- "-ERR wrong type\r\n"
+
+	_, err = conn.Do("SADD", "key", "member")
+
+	var serverErr redisError
+	if !errors.As(err, &serverErr) || string(serverErr) != "ERR wrong type" {
+		t.Fatalf("Do error = %v, want server error", err)
+	}
+
+	<-server.commands
+
+	// После ошибки сервера соединение остается рабочим
+	server.replies <- "+PONG\r\n"
+
+	if got, err := conn.Do("PING"); err != nil || got != "PONG" {
+		t.Fatalf("Do after server error = %v, %v, want PONG", got, err)
+	}
+}
+
+func TestRedisConnMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+	}{
+		{"no CRLF", "+OK\n"},
+		{"too short", "\r\n"},
+		{"unknown type", "!1\r\n"},
+		{"bad integer", ":x\r\n"},
+		{"bad bulk length", "$x\r\n"},
+		{"bad array length", "*x\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newFakeRedis(t)
+
+			conn, err := DialRedis(server.listener.Addr().String(), "", time.Second)
+			if err != nil {
+				t.Fatalf("DialRedis: %v", err)
+			}
+
+			defer conn.Close()
+
+			server.replies <- test.reply
+
+			if _, err := conn.Do("GET", "key"); err == nil {
+				t.Fatalf("Do of %q: no error", test.reply)
+			}
+		})
+	}
+}
+
+func TestRedisConnAuth(t *testing.T) {
+	server := newFakeRedis(t)
+	server.replies <- "+OK\r\n"
+
+	conn, err := DialRedis(server.listener.Addr().String(), "secret", time.Second)
+	if err != nil {
+		t.Fatalf("DialRedis: %v", err)
+	}
+
+	defer conn.Close()
+
+	if args := <-server.commands; !reflect.DeepEqual(args, []string{"AUTH", "secret"}) {
+		t.Fatalf("first command = %q, want AUTH secret", args)
+	}
+}
@@ -0,0 +1,127 @@
+// Конверт для сообщений вне gRPC (data channel между узлами).
+// Формат совпадает по смыслу с message Envelope из contracts.proto
+package envelope
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformed = errors.New("envelope: malformed envelope")
+
+type Type uint8
+
+const (
+	TypeSnapshot Type = iota + 1
+	TypeSnapshotAck
+	TypeInput
+	// TypeHostState - сериализованный матч, которым хост делится при миграции
+	TypeHostState
+	// TypeLockstepInput - ввод узла на кадр lockstep (InputPayload, Seq - номер кадра)
+	TypeLockstepInput
+	// TypeStateHash - хеш состояния после кадра lockstep для обнаружения рассинхронизации
+	TypeStateHash
+	// TypeLeaderboard - живая таблица лидеров матча (stats.MarshalLeaderboard)
+	TypeLeaderboard
+	// TypeChat - сообщение чата (chat.Marshal)
+	TypeChat
+	// TypePing и TypePong - пульс P2P-соединения (HeartbeatPayload), на Ping отвечают Pong с той же нагрузкой
+	TypePing
+	TypePong
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeSnapshot:
+		return "snapshot"
+	case TypeSnapshotAck:
+		return "snapshot_ack"
+	case TypeInput:
+		return "input"
+	case TypeHostState:
+		return "host_state"
+	case TypeLockstepInput:
+		return "lockstep_input"
+	case TypeStateHash:
+		return "state_hash"
+	case TypeLeaderboard:
+		return "leaderboard"
+	case TypeChat:
+		return "chat"
+	case TypePing:
+		return "ping"
+	case TypePong:
+		return "pong"
+	default:
+		return "unknown"
+	}
+}
+
+type Envelope struct {
+	Type    Type
+	From    string
+	Payload []byte
+	// Trace - контекст трассы (traceparent, см. tracing.Inject), пустой - без трассировки.
+	// Кодируется после Payload и только если не пустой, поэтому старый формат читается как есть
+	Trace string
+}
+
+func (e Envelope) Marshal() []byte {
+	data := make([]byte, 0, 1+binary.MaxVarintLen64*3+len(e.From)+len(e.Payload)+len(e.Trace))
+	data = append(data, byte(e.Type))
+	data = binary.AppendUvarint(data, uint64(len(e.From)))
+	data = append(data, e.From...)
+	data = binary.AppendUvarint(data, uint64(len(e.Payload)))
+	data = append(data, e.Payload...)
+
+	if e.Trace != "" {
+		data = binary.AppendUvarint(data, uint64(len(e.Trace)))
+		data = append(data, e.Trace...)
+	}
+
+	return data
+}
+
+func Unmarshal(data []byte) (Envelope, error) {
+	if len(data) == 0 {
+		return Envelope{}, ErrMalformed
+	}
+
+	e := Envelope{Type: Type(data[0])}
+	data = data[1:]
+
+	from, data, err := readBytes(data)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	payload, data, err := readBytes(data)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	e.From = string(from)
+	e.Payload = payload
+
+	if len(data) > 0 {
+		trace, _, err := readBytes(data)
+		if err != nil {
+			return Envelope{}, err
+		}
+
+		e.Trace = string(trace)
+	}
+
+	return e, nil
+}
+
+func readBytes(data []byte) ([]byte, []byte, error) {
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n > uint64(len(data)-k) {
+		return nil, nil, ErrMalformed
+	}
+
+	data = data[k:]
+
+	return data[:n], data[n:], nil
+}
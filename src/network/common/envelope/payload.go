@@ -0,0 +1,93 @@
+package envelope
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// Кодирование полезной нагрузки игровых сообщений
+
+func InputPayload(seq uint32, dirX, dirY float32) []byte {
+	data := binary.AppendUvarint(nil, uint64(seq))
+	data = binary.LittleEndian.AppendUint32(data, math.Float32bits(dirX))
+
+	return binary.LittleEndian.AppendUint32(data, math.Float32bits(dirY))
+}
+
+func ReadInput(payload []byte) (seq uint32, dirX, dirY float32, err error) {
+	v, n := binary.Uvarint(payload)
+	if n <= 0 || len(payload)-n != 8 {
+		return 0, 0, 0, ErrMalformed
+	}
+
+	payload = payload[n:]
+
+	return uint32(v),
+		math.Float32frombits(binary.LittleEndian.Uint32(payload)),
+		math.Float32frombits(binary.LittleEndian.Uint32(payload[4:])),
+		nil
+}
+
+// SnapshotPayload - кадр снимка, ID игрока получателя и номер его последнего обработанного ввода
+func SnapshotPayload(playerID, lastInput uint32, frame []byte) []byte {
+	data := binary.AppendUvarint(nil, uint64(playerID))
+	data = binary.AppendUvarint(data, uint64(lastInput))
+
+	return append(data, frame...)
+}
+
+func ReadSnapshot(payload []byte) (playerID, lastInput uint32, frame []byte, err error) {
+	id, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return 0, 0, nil, ErrMalformed
+	}
+
+	v, k := binary.Uvarint(payload[n:])
+	if k <= 0 {
+		return 0, 0, nil, ErrMalformed
+	}
+
+	return uint32(id), uint32(v), payload[n+k:], nil
+}
+
+func TickPayload(tick uint32) []byte {
+	return binary.AppendUvarint(nil, uint64(tick))
+}
+
+func ReadTick(payload []byte) (uint32, error) {
+	v, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return 0, ErrMalformed
+	}
+
+	return uint32(v), nil
+}
+
+// HashPayload - номер кадра и хеш состояния после него
+func HashPayload(frame uint32, hash uint64) []byte {
+	return binary.LittleEndian.AppendUint64(binary.AppendUvarint(nil, uint64(frame)), hash)
+}
+
+func ReadHash(payload []byte) (frame uint32, hash uint64, err error) {
+	v, n := binary.Uvarint(payload)
+	if n <= 0 || len(payload)-n != 8 {
+		return 0, 0, ErrMalformed
+	}
+
+	return uint32(v), binary.LittleEndian.Uint64(payload[n:]), nil
+}
+
+// HeartbeatPayload - номер пульса и время отправки; получатель возвращает их без изменений
+func HeartbeatPayload(seq uint32, sent time.Time) []byte {
+	return binary.LittleEndian.AppendUint64(binary.AppendUvarint(nil, uint64(seq)), uint64(sent.UnixNano()))
+}
+
+func ReadHeartbeat(payload []byte) (seq uint32, sent time.Time, err error) {
+	v, n := binary.Uvarint(payload)
+	if n <= 0 || len(payload)-n != 8 {
+		return 0, time.Time{}, ErrMalformed
+	}
+
+	return uint32(v), time.Unix(0, int64(binary.LittleEndian.Uint64(payload[n:]))), nil
+}
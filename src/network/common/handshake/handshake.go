@@ -0,0 +1,182 @@
+// Рукопожатие узла с transmitter: первым сообщением по TCP, до yamux,
+// узел отправляет токен сессии аккаунта, transmitter отвечает ID узла
+// (это ID аккаунта) и ID сессии подключения или причиной отказа.
+// Перегруженный transmitter вместо ID может ответить адресом другого
+// transmitter, к которому узлу нужно переподключиться.
+// Кадр: uvarint длина + байты; ответ начинается с байта статуса.
+// ID сессии обе стороны пишут в логи, чтобы связать записи одного подключения
+package handshake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultTimeout - сколько transmitter ждет рукопожатие от нового соединения
+const DefaultTimeout = 5 * time.Second
+
+// maxFrame - токены и ID короткие, длинный кадр - не рукопожатие
+const maxFrame = 256
+
+const (
+	statusOK       byte = 0
+	statusRejected byte = 1
+	statusRedirect byte = 2
+)
+
+var ErrMalformed = errors.New("handshake: malformed frame")
+
+// RejectedError - transmitter отказал в подключении
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "handshake: rejected: " + e.Reason
+}
+
+// RedirectError - transmitter отправляет узел на другой transmitter по адресу Address.
+// Accept отвечает перенаправлением, если authenticate вернул эту ошибку
+type RedirectError struct {
+	Address string
+}
+
+func (e *RedirectError) Error() string {
+	return "handshake: redirected to " + e.Address
+}
+
+// Dial выполняет рукопожатие со стороны узла и возвращает выданные ID узла и ID сессии.
+// При перенаправлении возвращает *RedirectError: соединение нужно закрыть и подключиться заново
+func Dial(conn net.Conn, token string, timeout time.Duration) (nodeID, session string, err error) {
+	conn.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	defer conn.SetDeadline(time.Time{})       //nolint:errcheck
+
+	if _, err := conn.Write(frame(nil, token)); err != nil {
+		return "", "", err
+	}
+
+	// Без буфера: после ответа по соединению идет yamux, лишние байты читать нельзя
+	r := byteReader{conn}
+
+	status, err := r.ReadByte()
+	if err != nil {
+		return "", "", err
+	}
+
+	text, err := read(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch status {
+	case statusOK:
+	case statusRedirect:
+		return "", "", &RedirectError{Address: text}
+	default:
+		return "", "", &RejectedError{Reason: text}
+	}
+
+	if session, err = read(r); err != nil {
+		return "", "", err
+	}
+
+	return text, session, nil
+}
+
+// Accept читает токен нового соединения, проверяет его через authenticate
+// и отвечает узлу. Возвращает ID узла и новый ID сессии или ошибку, если узел не допущен
+func Accept(conn net.Conn, timeout time.Duration, authenticate func(token string) (string, error)) (nodeID, session string, err error) {
+	conn.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	defer conn.SetDeadline(time.Time{})       //nolint:errcheck
+
+	token, err := read(byteReader{conn})
+	if err != nil {
+		return "", "", err
+	}
+
+	nodeID, authErr := authenticate(token)
+
+	var redirect *RedirectError
+	if errors.As(authErr, &redirect) {
+		if _, err := conn.Write(frame([]byte{statusRedirect}, redirect.Address)); err != nil {
+			return "", "", err
+		}
+
+		return "", "", authErr
+	}
+
+	if authErr != nil {
+		if _, err := conn.Write(frame([]byte{statusRejected}, authErr.Error())); err != nil {
+			return "", "", err
+		}
+
+		return "", "", fmt.Errorf("handshake: %w", authErr)
+	}
+
+	if session, err = newSession(); err != nil {
+		return "", "", err
+	}
+
+	if _, err := conn.Write(frame(frame([]byte{statusOK}, nodeID), session)); err != nil {
+		return "", "", err
+	}
+
+	return nodeID, session, nil
+}
+
+func newSession() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+func frame(dst []byte, text string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(text)))
+
+	return append(dst, text...)
+}
+
+func read(r io.ByteReader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+
+	if n > maxFrame {
+		return "", ErrMalformed
+	}
+
+	buf := make([]byte, n)
+	for i := range buf {
+		if buf[i], err = r.ReadByte(); err != nil {
+			return "", err
+		}
+	}
+
+	return string(buf), nil
+}
+
+// byteReader читает по одному байту, в отличие от bufio.Reader не забирая лишнего
+type byteReader struct {
+	r io.Reader
+}
+
+var _ io.ByteReader = byteReader{}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	if _, err := io.ReadFull(b.r, buf[:]); err != nil {
+		return 0, err
+	}
+
+	return buf[0], nil
+}
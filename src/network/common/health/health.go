@@ -0,0 +1,155 @@
+// Проверки состояния по стандартному протоколу grpc.health.v1.
+// Сервер (Readiness) отвечает SERVING, только когда выполнены все условия
+// готовности процесса (слушатели подняты, бэкенд подключен) и процесс
+// не выводится из работы. Клиент (Watch) периодически опрашивает сервер
+// и сообщает, если тот перестал отвечать или перестал быть готов
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// DefaultInterval - как часто узел проверяет transmitter через туннель
+	DefaultInterval = 2 * time.Second
+	// DefaultTimeout - ответ дольше считается неудачей: сервер жив по TCP, но не отвечает
+	DefaultTimeout = time.Second
+	// DefaultFailures - сколько неудач подряд означают, что сервер мертв
+	DefaultFailures = 3
+)
+
+// Readiness - сервис grpc.health.v1 процесса. Статус общий для всего
+// процесса (пустое имя сервиса)
+type Readiness struct {
+	server *grpchealth.Server
+
+	mu         sync.Mutex
+	conditions map[string]bool
+	draining   bool
+}
+
+// NewReadiness создает сервис в состоянии NOT_SERVING: каждое из conditions
+// должно быть выполнено через Set, чтобы процесс стал готов
+func NewReadiness(conditions ...string) *Readiness {
+	r := &Readiness{server: grpchealth.NewServer(), conditions: make(map[string]bool, len(conditions))}
+
+	for _, condition := range conditions {
+		r.conditions[condition] = false
+	}
+
+	r.update()
+
+	return r
+}
+
+// Register добавляет сервис проверок на gRPC-сервер; один Readiness можно
+// зарегистрировать на нескольких серверах
+func (r *Readiness) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, r.server)
+}
+
+// Set отмечает, выполнено ли условие готовности
+func (r *Readiness) Set(condition string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.conditions[condition] = ok
+	r.update()
+}
+
+// Drain переводит процесс в NOT_SERVING насовсем: клиенты и балансировщики
+// должны уходить на другие экземпляры
+func (r *Readiness) Drain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.draining = true
+	r.update()
+}
+
+// Ready сообщает, отвечает ли процесс SERVING
+func (r *Readiness) Ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ready()
+}
+
+// ready вызывается под r.mu
+func (r *Readiness) ready() bool {
+	if r.draining {
+		return false
+	}
+
+	for _, ok := range r.conditions {
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// update вызывается под r.mu
+func (r *Readiness) update() {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if r.ready() {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+
+	r.server.SetServingStatus("", status)
+}
+
+// Watch проверяет сервер за conn каждые interval, пока не отменен ctx.
+// После failures неудач подряд (ошибка, таймаут или не SERVING) вызывает
+// onUnhealthy с последней ошибкой и возвращается
+func Watch(ctx context.Context, conn grpc.ClientConnInterface, interval, timeout time.Duration, failures int, onUnhealthy func(error)) {
+	client := healthpb.NewHealthClient(conn)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failed := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := check(ctx, client, timeout)
+		if err == nil {
+			failed = 0
+			continue
+		}
+
+		if failed++; failed >= failures {
+			onUnhealthy(err)
+			return
+		}
+	}
+}
+
+func check(ctx context.Context, client healthpb.HealthClient, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health: server is %s", res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// Пульс соединений на уровне приложения: туннеля узел - transmitter и
+// P2P-соединений между узлами. Monitor раз в Interval отправляет ping и ждет
+// pong с тем же временем отправки, по ним считает RTT и джиттер. Если Misses
+// пульсов подряд остались без ответа, соединение считается мертвым - это
+// быстрее, чем ждать таймаута TCP или ICE
+package heartbeat
+
+import (
+	"sync"
+	"time"
+)
+
+type Config struct {
+	Interval time.Duration
+	// Misses - сколько пульсов подряд без ответа означают потерю соединения
+	Misses int
+}
+
+func DefaultConfig() Config {
+	return Config{Interval: time.Second, Misses: 5}
+}
+
+// Stats - качество соединения по ответам на пульс
+type Stats struct {
+	// RTT - сглаженное время кругового обхода (RFC 6298)
+	RTT time.Duration
+	// Jitter - среднее отклонение соседних RTT (RFC 3550)
+	Jitter time.Duration
+	// Missed - пульсы подряд без ответа на текущий момент
+	Missed int
+}
+
+// Ping отправляет пульс с номером seq и временем отправки sent
+type Ping func(seq uint32, sent time.Time) error
+
+// Monitor следит за одним соединением. Методы потокобезопасны
+type Monitor struct {
+	config Config
+	ping   Ping
+	onDead func()
+
+	mu       sync.Mutex
+	seq      uint32
+	awaiting bool
+	stats    Stats
+	measured bool
+	// last - RTT предыдущего ответа для джиттера
+	last time.Duration
+}
+
+// New создает монитор; onDead вызывается один раз, когда соединение признано мертвым
+func New(config Config, ping Ping, onDead func()) *Monitor {
+	return &Monitor{config: config, ping: ping, onDead: onDead}
+}
+
+// Run отправляет пульсы до закрытия stop или потери соединения
+func (m *Monitor) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if m.tick() {
+			m.onDead()
+			return
+		}
+	}
+}
+
+// tick отправляет очередной пульс и сообщает, признано ли соединение мертвым
+func (m *Monitor) tick() bool {
+	m.mu.Lock()
+	if m.awaiting {
+		m.stats.Missed++
+	}
+
+	if m.stats.Missed >= m.config.Misses {
+		m.mu.Unlock()
+		return true
+	}
+
+	m.seq++
+	m.awaiting = true
+	seq := m.seq
+	m.mu.Unlock()
+
+	// Ошибку отправки не считаем отдельно: неотвеченный пульс и так будет пропуском
+	m.ping(seq, time.Now()) //nolint:errcheck
+
+	return false
+}
+
+// Pong учитывает ответ на пульс, отправленный в sent. Любой ответ,
+// даже на старый пульс, доказывает, что соединение живо
+func (m *Monitor) Pong(seq uint32, sent time.Time) {
+	rtt := time.Since(sent)
+	if rtt < 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if seq == m.seq {
+		m.awaiting = false
+	}
+
+	m.stats.Missed = 0
+
+	if !m.measured {
+		m.stats.RTT, m.last, m.measured = rtt, rtt, true
+		return
+	}
+
+	m.stats.Jitter += (abs(rtt-m.last) - m.stats.Jitter) / 16
+	m.stats.RTT += (rtt - m.stats.RTT) / 8
+	m.last = rtt
+}
+
+func (m *Monitor) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stats
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
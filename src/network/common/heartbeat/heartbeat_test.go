@@ -0,0 +1,132 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+// step - один шаг сценария: пульс или ответ на него
+type step int
+
+const (
+	tick step = iota
+	// pong - ответ на последний пульс
+	pong
+	// late - ответ на предыдущий пульс
+	late
+)
+
+func TestMonitorMisses(t *testing.T) {
+	tests := []struct {
+		name       string
+		steps      []step
+		wantDead   bool
+		wantMissed int
+	}{
+		{"answered", []step{tick, pong, tick, pong, tick, pong, tick, pong}, false, 0},
+		// Первый пульс еще ждет ответа, пропуски считаются со второго
+		{"silent", []step{tick, tick, tick}, false, 2},
+		{"dead", []step{tick, tick, tick, tick}, true, 3},
+		{"recovered", []step{tick, tick, tick, pong, tick, tick}, false, 1},
+		// Ответ на старый пульс тоже доказывает, что соединение живо
+		{"late answer", []step{tick, tick, tick, late, tick, tick}, false, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sent []time.Time
+			m := New(Config{Interval: time.Second, Misses: 3}, func(seq uint32, at time.Time) error {
+				sent = append(sent, at)
+				return nil
+			}, nil)
+
+			dead := false
+			for _, s := range test.steps {
+				switch s {
+				case tick:
+					dead = m.tick()
+				case pong:
+					m.Pong(uint32(len(sent)), sent[len(sent)-1])
+				case late:
+					m.Pong(uint32(len(sent)-1), sent[len(sent)-2])
+				}
+			}
+
+			if dead != test.wantDead {
+				t.Fatalf("dead = %v, want %v", dead, test.wantDead)
+			}
+
+			if got := m.Stats().Missed; got != test.wantMissed {
+				t.Fatalf("Missed = %d, want %d", got, test.wantMissed)
+			}
+		})
+	}
+}
+
+func TestMonitorRTT(t *testing.T) {
+	tests := []struct {
+		name       string
+		rtts       []time.Duration
+		wantRTT    time.Duration
+		wantJitter time.Duration
+	}{
+		{"first sample", []time.Duration{80 * time.Millisecond}, 80 * time.Millisecond, 0},
+		{"smoothed", []time.Duration{80 * time.Millisecond, 160 * time.Millisecond}, 90 * time.Millisecond, 5 * time.Millisecond},
+		{"steady", []time.Duration{50 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}, 50 * time.Millisecond, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New(DefaultConfig(), func(uint32, time.Time) error { return nil }, nil)
+
+			for _, rtt := range test.rtts {
+				m.tick()
+				m.Pong(m.seq, time.Now().Add(-rtt))
+			}
+
+			stats := m.Stats()
+
+			if d := stats.RTT - test.wantRTT; d < 0 || d > time.Millisecond {
+				t.Fatalf("RTT = %v, want %v", stats.RTT, test.wantRTT)
+			}
+
+			if d := abs(stats.Jitter - test.wantJitter); d > time.Millisecond {
+				t.Fatalf("Jitter = %v, want %v", stats.Jitter, test.wantJitter)
+			}
+		})
+	}
+}
+
+func TestMonitorIgnoresPongFromFuture(t *testing.T) {
+	m := New(DefaultConfig(), func(uint32, time.Time) error { return nil }, nil)
+	m.tick()
+	m.tick()
+	m.Pong(2, time.Now().Add(time.Hour))
+
+	if stats := m.Stats(); stats.Missed != 1 || stats.RTT != 0 {
+		t.Fatalf("Stats = %+v, want the pong ignored", stats)
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	dead := make(chan struct{}, 2)
+	m := New(Config{Interval: time.Millisecond, Misses: 2}, func(uint32, time.Time) error { return nil }, func() {
+		dead <- struct{}{}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		m.Run(make(chan struct{}))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop on a dead connection")
+	}
+
+	if len(dead) != 1 {
+		t.Fatalf("onDead called %d times, want 1", len(dead))
+	}
+}
@@ -0,0 +1,103 @@
+// Структурные логи (log/slog) для всех сервисов. Узел, сессия, комната
+// и транспорт кладутся в context.Context через With и попадают в каждую
+// запись, сделанную с этим контекстом (slog.InfoContext и т.п.), поэтому
+// сессию одного игрока можно найти по node=<ID аккаунта> во всех сервисах
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Ключи атрибутов, общие для всех сервисов
+const (
+	KeyNode      = "node"
+	KeySession   = "session"
+	KeyRoom      = "room"
+	KeyTransport = "transport"
+	KeyPeer      = "peer"
+	KeyError     = "err"
+)
+
+// Форматы вывода
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type contextKey struct{}
+
+// With возвращает контекст, записи с которым дополняются attrs
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent, _ := ctx.Value(contextKey{}).([]slog.Attr)
+
+	merged := make([]slog.Attr, 0, len(parent)+len(attrs))
+	merged = append(merged, parent...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// Node, Session, Room и Transport - атрибуты для With
+func Node(id string) slog.Attr        { return slog.String(KeyNode, id) }
+func Session(id string) slog.Attr     { return slog.String(KeySession, id) }
+func Room(id string) slog.Attr        { return slog.String(KeyRoom, id) }
+func Transport(kind string) slog.Attr { return slog.String(KeyTransport, kind) }
+func Peer(id string) slog.Attr        { return slog.String(KeyPeer, id) }
+func Err(err error) slog.Attr         { return slog.Any(KeyError, err) }
+
+// Setup настраивает slog.Default: уровень (debug, info, warn, error) и формат (text, json).
+// Стандартный log после этого тоже пишет через slog
+func Setup(level, format string) error {
+	handler, err := NewHandler(os.Stderr, level, format)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(slog.New(handler))
+
+	return nil
+}
+
+// NewHandler создает обработчик, добавляющий атрибуты из контекста
+func NewHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("logging: unknown level %q", level)
+	}
+
+	options := &slog.HandlerOptions{Level: l}
+
+	switch strings.ToLower(format) {
+	case FormatText:
+		return contextHandler{slog.NewTextHandler(w, options)}, nil
+	case FormatJSON:
+		return contextHandler{slog.NewJSONHandler(w, options)}, nil
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q", format)
+	}
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
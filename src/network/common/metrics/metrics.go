@@ -0,0 +1,98 @@
+// Метрики Prometheus, общие для transmitter, commuter и узла: длительность
+// и коды gRPC-вызовов, число соединений по виду транспорта и HTTP-эндпоинт /metrics.
+// Метрики регистрируются в prometheus.DefaultRegisterer, каждый процесс
+// добавляет к ним свои (см. metrics.go в transmitter и node)
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const Namespace = "p2pmp"
+
+// Виды транспорта для Links
+const (
+	TransportRelay = "relay"
+	TransportP2P   = "p2p"
+)
+
+var (
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Duration of gRPC calls by side (server or client), method and status code.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"side", "method", "code"})
+
+	// Links - открытые соединения по виду транспорта: на transmitter - туннели узлов,
+	// на узле - туннель до transmitter и P2P-каналы
+	Links = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "links",
+		Help:      "Open links by transport (relay or p2p).",
+	}, []string{"transport"})
+)
+
+// Serve отдает /metrics на addr, блокируется до ошибки сервера
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	slog.Info("launching metrics HTTP server", "address", addr)
+
+	return http.ListenAndServe(addr, mux) //nolint:gosec
+}
+
+// ServerOptions - перехватчики, которые считают входящие вызовы gRPC-сервера
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			start := time.Now()
+			res, err := handler(ctx, req)
+			observe("server", info.FullMethod, start, err)
+
+			return res, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			observe("server", info.FullMethod, start, err)
+
+			return err
+		}),
+	}
+}
+
+// DialOptions - перехватчики исходящих вызовов gRPC-клиента.
+// Для потоков учитывается только время открытия потока
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			start := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			observe("client", method, start, err)
+
+			return err
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			start := time.Now()
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			observe("client", method, start, err)
+
+			return stream, err
+		}),
+	}
+}
+
+func observe(side, method string, start time.Time, err error) {
+	rpcDuration.WithLabelValues(side, method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,86 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: node.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package node
+
+import (
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_node_proto protoreflect.FileDescriptor
+
+const file_node_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"node.proto\x12\vcommon.node\x1a\x0fcontracts.proto2\xee\x02\n" +
+	"\x04Node\x12B\n" +
+	"\x0eCallFuncOnNode\x12\x16.common.contracts.Text\x1a\x16.common.contracts.Text\"\x00\x12P\n" +
+	"\fPushSnapshot\x12\x1f.common.contracts.WorldSnapshot\x1a\x1d.common.contracts.SnapshotAck\"\x00\x12D\n" +
+	"\fPushEnvelope\x12\x1a.common.contracts.Envelope\x1a\x16.common.contracts.Text\"\x00\x12C\n" +
+	"\bPushChat\x12\x1d.common.contracts.ChatMessage\x1a\x16.common.contracts.Text\"\x00\x12E\n" +
+	"\aMigrate\x12 .common.contracts.MigrateRequest\x1a\x16.common.contracts.Text\"\x00B1Z/github.com/matelq/p2pmp/src/network/common/nodeb\x06proto3"
+
+var file_node_proto_goTypes = []any{
+	(*contracts.Text)(nil),           // 0: common.contracts.Text
+	(*contracts.WorldSnapshot)(nil),  // 1: common.contracts.WorldSnapshot
+	(*contracts.Envelope)(nil),       // 2: common.contracts.Envelope
+	(*contracts.ChatMessage)(nil),    // 3: common.contracts.ChatMessage
+	(*contracts.MigrateRequest)(nil), // 4: common.contracts.MigrateRequest
+	(*contracts.SnapshotAck)(nil),    // 5: common.contracts.SnapshotAck
+}
+var file_node_proto_depIdxs = []int32{
+	0, // 0: common.node.Node.CallFuncOnNode:input_type -> common.contracts.Text
+	1, // 1: common.node.Node.PushSnapshot:input_type -> common.contracts.WorldSnapshot
+	2, // 2: common.node.Node.PushEnvelope:input_type -> common.contracts.Envelope
+	3, // 3: common.node.Node.PushChat:input_type -> common.contracts.ChatMessage
+	4, // 4: common.node.Node.Migrate:input_type -> common.contracts.MigrateRequest
+	0, // 5: common.node.Node.CallFuncOnNode:output_type -> common.contracts.Text
+	5, // 6: common.node.Node.PushSnapshot:output_type -> common.contracts.SnapshotAck
+	0, // 7: common.node.Node.PushEnvelope:output_type -> common.contracts.Text
+	0, // 8: common.node.Node.PushChat:output_type -> common.contracts.Text
+	0, // 9: common.node.Node.Migrate:output_type -> common.contracts.Text
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_node_proto_init() }
+func file_node_proto_init() {
+	if File_node_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_node_proto_rawDesc), len(file_node_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_node_proto_goTypes,
+		DependencyIndexes: file_node_proto_depIdxs,
+	}.Build()
+	File_node_proto = out.File
+	file_node_proto_goTypes = nil
+	file_node_proto_depIdxs = nil
+}
@@ -0,0 +1,282 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: node.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package node
+
+import (
+	context "context"
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Node_CallFuncOnNode_FullMethodName = "/common.node.Node/CallFuncOnNode"
+	Node_PushSnapshot_FullMethodName   = "/common.node.Node/PushSnapshot"
+	Node_PushEnvelope_FullMethodName   = "/common.node.Node/PushEnvelope"
+	Node_PushChat_FullMethodName       = "/common.node.Node/PushChat"
+	Node_Migrate_FullMethodName        = "/common.node.Node/Migrate"
+)
+
+// NodeClient is the client API for Node service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NodeClient interface {
+	CallFuncOnNode(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error)
+	// Бэкенд (через transmitter) присылает снимок, в ответ узел подтверждает последний декодированный тик
+	PushSnapshot(ctx context.Context, in *contracts.WorldSnapshot, opts ...grpc.CallOption) (*contracts.SnapshotAck, error)
+	// Прочие сообщения матча, например таблица лидеров
+	PushEnvelope(ctx context.Context, in *contracts.Envelope, opts ...grpc.CallOption) (*contracts.Text, error)
+	PushChat(ctx context.Context, in *contracts.ChatMessage, opts ...grpc.CallOption) (*contracts.Text, error)
+	// transmitter выводится из работы: узел переподключается к другому transmitter
+	Migrate(ctx context.Context, in *contracts.MigrateRequest, opts ...grpc.CallOption) (*contracts.Text, error)
+}
+
+type nodeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNodeClient(cc grpc.ClientConnInterface) NodeClient {
+	return &nodeClient{cc}
+}
+
+func (c *nodeClient) CallFuncOnNode(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Node_CallFuncOnNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) PushSnapshot(ctx context.Context, in *contracts.WorldSnapshot, opts ...grpc.CallOption) (*contracts.SnapshotAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.SnapshotAck)
+	err := c.cc.Invoke(ctx, Node_PushSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) PushEnvelope(ctx context.Context, in *contracts.Envelope, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Node_PushEnvelope_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) PushChat(ctx context.Context, in *contracts.ChatMessage, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Node_PushChat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Migrate(ctx context.Context, in *contracts.MigrateRequest, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Node_Migrate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NodeServer is the server API for Node service.
+// All implementations must embed UnimplementedNodeServer
+// for forward compatibility.
+type NodeServer interface {
+	CallFuncOnNode(context.Context, *contracts.Text) (*contracts.Text, error)
+	// Бэкенд (через transmitter) присылает снимок, в ответ узел подтверждает последний декодированный тик
+	PushSnapshot(context.Context, *contracts.WorldSnapshot) (*contracts.SnapshotAck, error)
+	// Прочие сообщения матча, например таблица лидеров
+	PushEnvelope(context.Context, *contracts.Envelope) (*contracts.Text, error)
+	PushChat(context.Context, *contracts.ChatMessage) (*contracts.Text, error)
+	// transmitter выводится из работы: узел переподключается к другому transmitter
+	Migrate(context.Context, *contracts.MigrateRequest) (*contracts.Text, error)
+	mustEmbedUnimplementedNodeServer()
+}
+
+// UnimplementedNodeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNodeServer struct{}
+
+func (UnimplementedNodeServer) CallFuncOnNode(context.Context, *contracts.Text) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CallFuncOnNode not implemented")
+}
+func (UnimplementedNodeServer) PushSnapshot(context.Context, *contracts.WorldSnapshot) (*contracts.SnapshotAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushSnapshot not implemented")
+}
+func (UnimplementedNodeServer) PushEnvelope(context.Context, *contracts.Envelope) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushEnvelope not implemented")
+}
+func (UnimplementedNodeServer) PushChat(context.Context, *contracts.ChatMessage) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushChat not implemented")
+}
+func (UnimplementedNodeServer) Migrate(context.Context, *contracts.MigrateRequest) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Migrate not implemented")
+}
+func (UnimplementedNodeServer) mustEmbedUnimplementedNodeServer() {}
+func (UnimplementedNodeServer) testEmbeddedByValue()              {}
+
+// UnsafeNodeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NodeServer will
+// result in compilation errors.
+type UnsafeNodeServer interface {
+	mustEmbedUnimplementedNodeServer()
+}
+
+func RegisterNodeServer(s grpc.ServiceRegistrar, srv NodeServer) {
+	// If the following call pancis, it indicates UnimplementedNodeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Node_ServiceDesc, srv)
+}
+
+func _Node_CallFuncOnNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).CallFuncOnNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_CallFuncOnNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).CallFuncOnNode(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_PushSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.WorldSnapshot)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).PushSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_PushSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).PushSnapshot(ctx, req.(*contracts.WorldSnapshot))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_PushEnvelope_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).PushEnvelope(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_PushEnvelope_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).PushEnvelope(ctx, req.(*contracts.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_PushChat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.ChatMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).PushChat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_PushChat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).PushChat(ctx, req.(*contracts.ChatMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Migrate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.MigrateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Migrate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Migrate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Migrate(ctx, req.(*contracts.MigrateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Node_ServiceDesc is the grpc.ServiceDesc for Node service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Node_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.node.Node",
+	HandlerType: (*NodeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CallFuncOnNode",
+			Handler:    _Node_CallFuncOnNode_Handler,
+		},
+		{
+			MethodName: "PushSnapshot",
+			Handler:    _Node_PushSnapshot_Handler,
+		},
+		{
+			MethodName: "PushEnvelope",
+			Handler:    _Node_PushEnvelope_Handler,
+		},
+		{
+			MethodName: "PushChat",
+			Handler:    _Node_PushChat_Handler,
+		},
+		{
+			MethodName: "Migrate",
+			Handler:    _Node_Migrate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "node.proto",
+}
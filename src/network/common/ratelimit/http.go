@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Handler ограничивает частоту запросов к next по IP клиента: когда у адреса
+// кончились токены, отвечает 429. Заголовки прокси не учитываются, их
+// подделывает кто угодно
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.Allow(ClientIP(r), time.Now()) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run раз в interval удаляет адреса, которые успели накопить полный запас,
+// пока не закрыт stop
+func (l *Limiter) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			l.Prune(now)
+		}
+	}
+}
+
+// ClientIP - адрес клиента запроса без порта
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,177 @@
+// Защита от флуда: ограничение частоты по ключу (token bucket), число
+// одновременных соединений с одного адреса и штрафной бокс, куда попадают
+// те, кто слишком часто упирается в ограничения. Ключ - ID узла или IP
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter - token bucket по ключу: ключ может сразу потратить Burst
+// токенов, дальше получает Rate токенов в секунду
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: burst, buckets: map[string]*bucket{}}
+}
+
+// Allow забирает токен ключа, если он есть
+func (l *Limiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(float64(l.burst), b.tokens+elapsed.Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// Forget удаляет состояние ключа, например когда узел отключился
+func (l *Limiter) Forget(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.buckets, key)
+}
+
+// Prune удаляет ключи, которые успели накопить полный запас: их состояние
+// не отличается от нового. Нужен для ключей-адресов, у которых нет отключения
+func (l *Limiter) Prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Conns - число одновременных соединений по адресу
+type Conns struct {
+	limit int
+
+	mu    sync.Mutex
+	conns map[string]int
+}
+
+func NewConns(limit int) *Conns {
+	return &Conns{limit: limit, conns: map[string]int{}}
+}
+
+// Acquire занимает место для соединения с адреса, если лимит не исчерпан.
+// Каждый успешный Acquire нужно закрыть Release
+func (c *Conns) Acquire(addr string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conns[addr] >= c.limit {
+		return false
+	}
+
+	c.conns[addr]++
+
+	return true
+}
+
+func (c *Conns) Release(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conns[addr]--; c.conns[addr] <= 0 {
+		delete(c.conns, addr)
+	}
+}
+
+// PenaltyConfig - Strikes нарушений за Window отправляют ключ в бокс на Duration
+type PenaltyConfig struct {
+	Strikes  int
+	Window   time.Duration
+	Duration time.Duration
+}
+
+func DefaultPenaltyConfig() PenaltyConfig {
+	return PenaltyConfig{Strikes: 50, Window: 10 * time.Second, Duration: time.Minute}
+}
+
+// PenaltyBox считает нарушения ключей. Что делать с попавшими в бокс,
+// решает вызывающий: выгнать узел, заблокировать адрес
+type PenaltyBox struct {
+	config PenaltyConfig
+
+	mu      sync.Mutex
+	strikes map[string]*strikes
+}
+
+type strikes struct {
+	count int
+	since time.Time
+}
+
+func NewPenaltyBox(config PenaltyConfig) *PenaltyBox {
+	return &PenaltyBox{config: config, strikes: map[string]*strikes{}}
+}
+
+// Strike учитывает нарушение и сообщает, набрал ли ключ бокс. После этого
+// счет ключа начинается заново
+func (p *PenaltyBox) Strike(key string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.strikes[key]
+	if !ok || now.Sub(s.since) > p.config.Window {
+		s = &strikes{since: now}
+		p.strikes[key] = s
+	}
+
+	if s.count++; s.count < p.config.Strikes {
+		return false
+	}
+
+	delete(p.strikes, key)
+
+	return true
+}
+
+// Duration - сколько длится бокс
+func (p *PenaltyBox) Duration() time.Duration {
+	return p.config.Duration
+}
+
+// Prune удаляет счет ключей, чье окно истекло
+func (p *PenaltyBox) Prune(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, s := range p.strikes {
+		if now.Sub(s.since) > p.config.Window {
+			delete(p.strikes, key)
+		}
+	}
+}
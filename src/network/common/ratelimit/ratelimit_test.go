@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestLimiter(t *testing.T) {
+	// step - вызов Allow через after после начала
+	type step struct {
+		after time.Duration
+		want  bool
+	}
+
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		steps []step
+	}{
+		{"burst", 1, 3, []step{{0, true}, {0, true}, {0, true}, {0, false}}},
+		{"refill", 2, 1, []step{{0, true}, {0, false}, {400 * time.Millisecond, false}, {500 * time.Millisecond, true}, {500 * time.Millisecond, false}}},
+		{"refill caps at burst", 10, 2, []step{{0, true}, {0, true}, {time.Hour, true}, {time.Hour, true}, {time.Hour, false}}},
+		{"fractional tokens add up", 1, 1, []step{{0, true}, {250 * time.Millisecond, false}, {500 * time.Millisecond, false}, {time.Second, true}}},
+		{"clock going back", 1, 1, []step{{time.Second, true}, {0, false}, {time.Second, false}, {2 * time.Second, true}}},
+		{"zero rate", 0, 2, []step{{0, true}, {0, true}, {time.Hour, false}}},
+		{"zero burst", 100, 0, []step{{0, false}, {time.Second, false}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := NewLimiter(test.rate, test.burst)
+
+			for i, s := range test.steps {
+				if got := l.Allow("key", start.Add(s.after)); got != s.want {
+					t.Fatalf("step %d at +%v: Allow = %v, want %v", i, s.after, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterKeys(t *testing.T) {
+	l := NewLimiter(1, 1)
+
+	if !l.Allow("a", start) || l.Allow("a", start) {
+		t.Fatal("a: want one token")
+	}
+
+	// У каждого ключа свой запас
+	if !l.Allow("b", start) {
+		t.Fatal("b: Allow = false, want true")
+	}
+
+	// После Forget ключ начинает с полным запасом
+	l.Forget("a")
+
+	if !l.Allow("a", start) {
+		t.Fatal("a after Forget: Allow = false, want true")
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	l := NewLimiter(1, 2)
+
+	l.Allow("full", start)
+	l.Allow("empty", start)
+	l.Allow("empty", start)
+
+	// Через секунду full накопил полный запас, а empty - только один токен
+	l.Prune(start.Add(time.Second))
+
+	if _, ok := l.buckets["full"]; ok {
+		t.Fatal("full bucket not pruned")
+	}
+
+	if _, ok := l.buckets["empty"]; !ok {
+		t.Fatal("empty bucket pruned")
+	}
+}
+
+func TestConns(t *testing.T) {
+	c := NewConns(2)
+
+	if !c.Acquire("a") || !c.Acquire("a") {
+		t.Fatal("Acquire under limit = false, want true")
+	}
+
+	if c.Acquire("a") {
+		t.Fatal("Acquire over limit = true, want false")
+	}
+
+	if !c.Acquire("b") {
+		t.Fatal("Acquire from another address = false, want true")
+	}
+
+	c.Release("a")
+
+	if !c.Acquire("a") {
+		t.Fatal("Acquire after Release = false, want true")
+	}
+
+	c.Release("b")
+
+	if _, ok := c.conns["b"]; ok {
+		t.Fatal("released address still counted")
+	}
+}
+
+func TestPenaltyBox(t *testing.T) {
+	config := PenaltyConfig{Strikes: 3, Window: 10 * time.Second, Duration: time.Minute}
+
+	tests := []struct {
+		name string
+		// strikes - моменты нарушений после начала
+		strikes []time.Duration
+		// boxed - номера нарушений, на которых ключ попадает в бокс
+		boxed []int
+	}{
+		{"below strikes", []time.Duration{0, time.Second}, nil},
+		{"strikes in window", []time.Duration{0, time.Second, 2 * time.Second}, []int{2}},
+		{"window edge", []time.Duration{0, 5 * time.Second, 10 * time.Second}, []int{2}},
+		{"window expired", []time.Duration{0, time.Second, 11 * time.Second, 12 * time.Second}, nil},
+		{"new window after expiry", []time.Duration{0, 11 * time.Second, 12 * time.Second, 13 * time.Second}, []int{3}},
+		{"count restarts after box", []time.Duration{0, 0, 0, 0, 0, 0, 0}, []int{2, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewPenaltyBox(config)
+
+			for i, after := range test.strikes {
+				want := slices.Contains(test.boxed, i)
+				if got := p.Strike("key", start.Add(after)); got != want {
+					t.Fatalf("strike %d at +%v: Strike = %v, want %v", i, after, got, want)
+				}
+			}
+		})
+	}
+
+	if got := NewPenaltyBox(config).Duration(); got != config.Duration {
+		t.Fatalf("Duration = %v, want %v", got, config.Duration)
+	}
+}
+
+func TestPenaltyBoxPrune(t *testing.T) {
+	p := NewPenaltyBox(PenaltyConfig{Strikes: 3, Window: 10 * time.Second, Duration: time.Minute})
+
+	p.Strike("old", start)
+	p.Strike("new", start.Add(5*time.Second))
+
+	p.Prune(start.Add(11 * time.Second))
+
+	if _, ok := p.strikes["old"]; ok {
+		t.Fatal("expired strikes not pruned")
+	}
+
+	if _, ok := p.strikes["new"]; !ok {
+		t.Fatal("strikes in window pruned")
+	}
+}
+
+func TestLimiterHandler(t *testing.T) {
+	handler := NewLimiter(0, 2).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		remoteAddr string
+		want       int
+	}{
+		{"10.0.0.1:1000", http.StatusOK},
+		{"10.0.0.1:1001", http.StatusOK},
+		// Порт не важен: запас общий на адрес
+		{"10.0.0.1:1002", http.StatusTooManyRequests},
+		{"10.0.0.2:1000", http.StatusOK},
+		{"[::1]:1000", http.StatusOK},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r.RemoteAddr = test.remoteAddr
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.want {
+			t.Fatalf("request %d from %s: status = %d, want %d", i, test.remoteAddr, w.Code, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: regulator.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package regulator
+
+import (
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_regulator_proto protoreflect.FileDescriptor
+
+const file_regulator_proto_rawDesc = "" +
+	"\n" +
+	"\x0fregulator.proto\x12\x10common.regulator\x1a\x0fcontracts.proto2\xf2\x04\n" +
+	"\tRegulator\x12G\n" +
+	"\x13CallFuncOnRegulator\x12\x16.common.contracts.Text\x1a\x16.common.contracts.Text\"\x00\x12H\n" +
+	"\aEnqueue\x12#.common.contracts.MatchmakingTicket\x1a\x16.common.contracts.Text\"\x00\x12E\n" +
+	"\x11CancelMatchmaking\x12\x16.common.contracts.Text\x1a\x16.common.contracts.Text\"\x00\x12M\n" +
+	"\x0eWaitAssignment\x12\x16.common.contracts.Text\x1a!.common.contracts.MatchAssignment\"\x00\x12H\n" +
+	"\x0fUploadHostState\x12\x1b.common.contracts.HostState\x1a\x16.common.contracts.Text\"\x00\x12H\n" +
+	"\x0eReportHostLost\x12\x1c.common.contracts.HostReport\x1a\x16.common.contracts.Text\"\x00\x12P\n" +
+	"\x12WaitHostAssignment\x12\x16.common.contracts.Text\x1a .common.contracts.HostAssignment\"\x00\x12V\n" +
+	"\tBootstrap\x12\".common.contracts.BootstrapRequest\x1a#.common.contracts.BootstrapResponse\"\x00B6Z4github.com/matelq/p2pmp/src/network/common/regulatorb\x06proto3"
+
+var file_regulator_proto_goTypes = []any{
+	(*contracts.Text)(nil),              // 0: common.contracts.Text
+	(*contracts.MatchmakingTicket)(nil), // 1: common.contracts.MatchmakingTicket
+	(*contracts.HostState)(nil),         // 2: common.contracts.HostState
+	(*contracts.HostReport)(nil),        // 3: common.contracts.HostReport
+	(*contracts.BootstrapRequest)(nil),  // 4: common.contracts.BootstrapRequest
+	(*contracts.MatchAssignment)(nil),   // 5: common.contracts.MatchAssignment
+	(*contracts.HostAssignment)(nil),    // 6: common.contracts.HostAssignment
+	(*contracts.BootstrapResponse)(nil), // 7: common.contracts.BootstrapResponse
+}
+var file_regulator_proto_depIdxs = []int32{
+	0, // 0: common.regulator.Regulator.CallFuncOnRegulator:input_type -> common.contracts.Text
+	1, // 1: common.regulator.Regulator.Enqueue:input_type -> common.contracts.MatchmakingTicket
+	0, // 2: common.regulator.Regulator.CancelMatchmaking:input_type -> common.contracts.Text
+	0, // 3: common.regulator.Regulator.WaitAssignment:input_type -> common.contracts.Text
+	2, // 4: common.regulator.Regulator.UploadHostState:input_type -> common.contracts.HostState
+	3, // 5: common.regulator.Regulator.ReportHostLost:input_type -> common.contracts.HostReport
+	0, // 6: common.regulator.Regulator.WaitHostAssignment:input_type -> common.contracts.Text
+	4, // 7: common.regulator.Regulator.Bootstrap:input_type -> common.contracts.BootstrapRequest
+	0, // 8: common.regulator.Regulator.CallFuncOnRegulator:output_type -> common.contracts.Text
+	0, // 9: common.regulator.Regulator.Enqueue:output_type -> common.contracts.Text
+	0, // 10: common.regulator.Regulator.CancelMatchmaking:output_type -> common.contracts.Text
+	5, // 11: common.regulator.Regulator.WaitAssignment:output_type -> common.contracts.MatchAssignment
+	0, // 12: common.regulator.Regulator.UploadHostState:output_type -> common.contracts.Text
+	0, // 13: common.regulator.Regulator.ReportHostLost:output_type -> common.contracts.Text
+	6, // 14: common.regulator.Regulator.WaitHostAssignment:output_type -> common.contracts.HostAssignment
+	7, // 15: common.regulator.Regulator.Bootstrap:output_type -> common.contracts.BootstrapResponse
+	8, // [8:16] is the sub-list for method output_type
+	0, // [0:8] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_regulator_proto_init() }
+func file_regulator_proto_init() {
+	if File_regulator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_regulator_proto_rawDesc), len(file_regulator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_regulator_proto_goTypes,
+		DependencyIndexes: file_regulator_proto_depIdxs,
+	}.Build()
+	File_regulator_proto = out.File
+	file_regulator_proto_goTypes = nil
+	file_regulator_proto_depIdxs = nil
+}
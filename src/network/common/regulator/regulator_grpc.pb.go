@@ -0,0 +1,396 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: regulator.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package regulator
+
+import (
+	context "context"
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Regulator_CallFuncOnRegulator_FullMethodName = "/common.regulator.Regulator/CallFuncOnRegulator"
+	Regulator_Enqueue_FullMethodName             = "/common.regulator.Regulator/Enqueue"
+	Regulator_CancelMatchmaking_FullMethodName   = "/common.regulator.Regulator/CancelMatchmaking"
+	Regulator_WaitAssignment_FullMethodName      = "/common.regulator.Regulator/WaitAssignment"
+	Regulator_UploadHostState_FullMethodName     = "/common.regulator.Regulator/UploadHostState"
+	Regulator_ReportHostLost_FullMethodName      = "/common.regulator.Regulator/ReportHostLost"
+	Regulator_WaitHostAssignment_FullMethodName  = "/common.regulator.Regulator/WaitHostAssignment"
+	Regulator_Bootstrap_FullMethodName           = "/common.regulator.Regulator/Bootstrap"
+)
+
+// RegulatorClient is the client API for Regulator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RegulatorClient interface {
+	CallFuncOnRegulator(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error)
+	// Матчмейкинг: постановка в очередь и ожидание подобранного матча
+	Enqueue(ctx context.Context, in *contracts.MatchmakingTicket, opts ...grpc.CallOption) (*contracts.Text, error)
+	CancelMatchmaking(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error)
+	WaitAssignment(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.MatchAssignment, error)
+	// Режим хоста: хост присылает состояние матча, участники сообщают о пропаже хоста
+	UploadHostState(ctx context.Context, in *contracts.HostState, opts ...grpc.CallOption) (*contracts.Text, error)
+	ReportHostLost(ctx context.Context, in *contracts.HostReport, opts ...grpc.CallOption) (*contracts.Text, error)
+	WaitHostAssignment(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.HostAssignment, error)
+	// Выбор transmitter или commuter для подключения узла
+	Bootstrap(ctx context.Context, in *contracts.BootstrapRequest, opts ...grpc.CallOption) (*contracts.BootstrapResponse, error)
+}
+
+type regulatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRegulatorClient(cc grpc.ClientConnInterface) RegulatorClient {
+	return &regulatorClient{cc}
+}
+
+func (c *regulatorClient) CallFuncOnRegulator(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Regulator_CallFuncOnRegulator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) Enqueue(ctx context.Context, in *contracts.MatchmakingTicket, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Regulator_Enqueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) CancelMatchmaking(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Regulator_CancelMatchmaking_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) WaitAssignment(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.MatchAssignment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.MatchAssignment)
+	err := c.cc.Invoke(ctx, Regulator_WaitAssignment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) UploadHostState(ctx context.Context, in *contracts.HostState, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Regulator_UploadHostState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) ReportHostLost(ctx context.Context, in *contracts.HostReport, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Regulator_ReportHostLost_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) WaitHostAssignment(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.HostAssignment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.HostAssignment)
+	err := c.cc.Invoke(ctx, Regulator_WaitHostAssignment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *regulatorClient) Bootstrap(ctx context.Context, in *contracts.BootstrapRequest, opts ...grpc.CallOption) (*contracts.BootstrapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.BootstrapResponse)
+	err := c.cc.Invoke(ctx, Regulator_Bootstrap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RegulatorServer is the server API for Regulator service.
+// All implementations must embed UnimplementedRegulatorServer
+// for forward compatibility.
+type RegulatorServer interface {
+	CallFuncOnRegulator(context.Context, *contracts.Text) (*contracts.Text, error)
+	// Матчмейкинг: постановка в очередь и ожидание подобранного матча
+	Enqueue(context.Context, *contracts.MatchmakingTicket) (*contracts.Text, error)
+	CancelMatchmaking(context.Context, *contracts.Text) (*contracts.Text, error)
+	WaitAssignment(context.Context, *contracts.Text) (*contracts.MatchAssignment, error)
+	// Режим хоста: хост присылает состояние матча, участники сообщают о пропаже хоста
+	UploadHostState(context.Context, *contracts.HostState) (*contracts.Text, error)
+	ReportHostLost(context.Context, *contracts.HostReport) (*contracts.Text, error)
+	WaitHostAssignment(context.Context, *contracts.Text) (*contracts.HostAssignment, error)
+	// Выбор transmitter или commuter для подключения узла
+	Bootstrap(context.Context, *contracts.BootstrapRequest) (*contracts.BootstrapResponse, error)
+	mustEmbedUnimplementedRegulatorServer()
+}
+
+// UnimplementedRegulatorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRegulatorServer struct{}
+
+func (UnimplementedRegulatorServer) CallFuncOnRegulator(context.Context, *contracts.Text) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CallFuncOnRegulator not implemented")
+}
+func (UnimplementedRegulatorServer) Enqueue(context.Context, *contracts.MatchmakingTicket) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Enqueue not implemented")
+}
+func (UnimplementedRegulatorServer) CancelMatchmaking(context.Context, *contracts.Text) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelMatchmaking not implemented")
+}
+func (UnimplementedRegulatorServer) WaitAssignment(context.Context, *contracts.Text) (*contracts.MatchAssignment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitAssignment not implemented")
+}
+func (UnimplementedRegulatorServer) UploadHostState(context.Context, *contracts.HostState) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadHostState not implemented")
+}
+func (UnimplementedRegulatorServer) ReportHostLost(context.Context, *contracts.HostReport) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportHostLost not implemented")
+}
+func (UnimplementedRegulatorServer) WaitHostAssignment(context.Context, *contracts.Text) (*contracts.HostAssignment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitHostAssignment not implemented")
+}
+func (UnimplementedRegulatorServer) Bootstrap(context.Context, *contracts.BootstrapRequest) (*contracts.BootstrapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Bootstrap not implemented")
+}
+func (UnimplementedRegulatorServer) mustEmbedUnimplementedRegulatorServer() {}
+func (UnimplementedRegulatorServer) testEmbeddedByValue()                   {}
+
+// UnsafeRegulatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RegulatorServer will
+// result in compilation errors.
+type UnsafeRegulatorServer interface {
+	mustEmbedUnimplementedRegulatorServer()
+}
+
+func RegisterRegulatorServer(s grpc.ServiceRegistrar, srv RegulatorServer) {
+	// If the following call pancis, it indicates UnimplementedRegulatorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Regulator_ServiceDesc, srv)
+}
+
+func _Regulator_CallFuncOnRegulator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).CallFuncOnRegulator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_CallFuncOnRegulator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).CallFuncOnRegulator(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_Enqueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.MatchmakingTicket)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).Enqueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_Enqueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).Enqueue(ctx, req.(*contracts.MatchmakingTicket))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_CancelMatchmaking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).CancelMatchmaking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_CancelMatchmaking_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).CancelMatchmaking(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_WaitAssignment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).WaitAssignment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_WaitAssignment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).WaitAssignment(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_UploadHostState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.HostState)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).UploadHostState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_UploadHostState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).UploadHostState(ctx, req.(*contracts.HostState))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_ReportHostLost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.HostReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).ReportHostLost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_ReportHostLost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).ReportHostLost(ctx, req.(*contracts.HostReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_WaitHostAssignment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).WaitHostAssignment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_WaitHostAssignment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).WaitHostAssignment(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Regulator_Bootstrap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.BootstrapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegulatorServer).Bootstrap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Regulator_Bootstrap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegulatorServer).Bootstrap(ctx, req.(*contracts.BootstrapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Regulator_ServiceDesc is the grpc.ServiceDesc for Regulator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Regulator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.regulator.Regulator",
+	HandlerType: (*RegulatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CallFuncOnRegulator",
+			Handler:    _Regulator_CallFuncOnRegulator_Handler,
+		},
+		{
+			MethodName: "Enqueue",
+			Handler:    _Regulator_Enqueue_Handler,
+		},
+		{
+			MethodName: "CancelMatchmaking",
+			Handler:    _Regulator_CancelMatchmaking_Handler,
+		},
+		{
+			MethodName: "WaitAssignment",
+			Handler:    _Regulator_WaitAssignment_Handler,
+		},
+		{
+			MethodName: "UploadHostState",
+			Handler:    _Regulator_UploadHostState_Handler,
+		},
+		{
+			MethodName: "ReportHostLost",
+			Handler:    _Regulator_ReportHostLost_Handler,
+		},
+		{
+			MethodName: "WaitHostAssignment",
+			Handler:    _Regulator_WaitHostAssignment_Handler,
+		},
+		{
+			MethodName: "Bootstrap",
+			Handler:    _Regulator_Bootstrap_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "regulator.proto",
+}
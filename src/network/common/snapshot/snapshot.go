@@ -0,0 +1,437 @@
+// Формат снимков состояния мира, которые бэкенд рассылает узлам.
+// Координаты и размеры квантуются, а снимок кодируется либо целиком,
+// либо как дельта относительно снимка, подтвержденного узлом (baseline)
+package snapshot
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// Масштабы квантования: позиция с точностью 1/8, размер с точностью 1/16.
+// Карта 3000x3000 при таком масштабе помещается в uint16
+const (
+	PositionScale = 8
+	SizeScale     = 16
+)
+
+const (
+	frameFull  byte = 0
+	frameDelta byte = 1
+)
+
+// Биты маски изменившихся полей в дельте
+const (
+	changedX byte = 1 << iota
+	changedY
+	changedSize
+	changedKind
+)
+
+var (
+	ErrMalformed       = errors.New("snapshot: malformed frame")
+	ErrBaselineMissing = errors.New("snapshot: baseline is missing")
+)
+
+type Kind uint8
+
+const (
+	KindPlayer Kind = iota
+	KindFood
+)
+
+// Entity - сущность мира в том виде, в котором ее видит рендер
+type Entity struct {
+	ID   uint32
+	Kind Kind
+	X    float32
+	Y    float32
+	Size float32
+}
+
+type Snapshot struct {
+	Tick     uint32
+	Entities []Entity
+}
+
+// Quantized - сущность после квантования, именно она уходит в сеть
+type Quantized struct {
+	ID   uint32
+	Kind Kind
+	X    uint16
+	Y    uint16
+	Size uint16
+}
+
+// State - квантованный снимок, сущности отсортированы по ID.
+// Используется как baseline и на сервере, и на узле, поэтому дельты
+// считаются и применяются без потери точности
+type State struct {
+	Tick     uint32
+	Entities []Quantized
+}
+
+func quantize(v float32, scale float32) uint16 {
+	q := math.Round(float64(v * scale))
+
+	return uint16(max(0, min(q, math.MaxUint16)))
+}
+
+func Quantize(s Snapshot) State {
+	state := State{Tick: s.Tick, Entities: make([]Quantized, len(s.Entities))}
+
+	for i, e := range s.Entities {
+		state.Entities[i] = Quantized{
+			ID:   e.ID,
+			Kind: e.Kind,
+			X:    quantize(e.X, PositionScale),
+			Y:    quantize(e.Y, PositionScale),
+			Size: quantize(e.Size, SizeScale),
+		}
+	}
+
+	sort.Slice(state.Entities, func(i, j int) bool { return state.Entities[i].ID < state.Entities[j].ID })
+
+	return state
+}
+
+func (s State) Snapshot() Snapshot {
+	snapshot := Snapshot{Tick: s.Tick, Entities: make([]Entity, len(s.Entities))}
+
+	for i, q := range s.Entities {
+		snapshot.Entities[i] = Entity{
+			ID:   q.ID,
+			Kind: q.Kind,
+			X:    float32(q.X) / PositionScale,
+			Y:    float32(q.Y) / PositionScale,
+			Size: float32(q.Size) / SizeScale,
+		}
+	}
+
+	return snapshot
+}
+
+// Header - заголовок кадра, нужен узлу, чтобы найти baseline до декодирования
+type Header struct {
+	Tick     uint32
+	BaseTick uint32
+	Full     bool
+}
+
+// AppendFull дописывает в dst полный снимок
+func AppendFull(dst []byte, cur State) []byte {
+	dst = append(dst, frameFull)
+	dst = binary.AppendUvarint(dst, uint64(cur.Tick))
+	dst = binary.AppendUvarint(dst, uint64(len(cur.Entities)))
+
+	prevID := uint32(0)
+
+	for _, e := range cur.Entities {
+		dst = appendEntity(dst, e, prevID)
+		prevID = e.ID
+	}
+
+	return dst
+}
+
+// AppendDelta дописывает в dst дельту cur относительно base.
+// Кадр содержит удаленные ID, новые сущности целиком и изменившиеся поля
+func AppendDelta(dst []byte, base, cur State) []byte {
+	var removed []uint32
+	var added, changed []Quantized
+	var baseline []Quantized
+
+	i, j := 0, 0
+
+	for i < len(base.Entities) || j < len(cur.Entities) {
+		switch {
+		case j == len(cur.Entities) || (i < len(base.Entities) && base.Entities[i].ID < cur.Entities[j].ID):
+			removed = append(removed, base.Entities[i].ID)
+			i++
+		case i == len(base.Entities) || cur.Entities[j].ID < base.Entities[i].ID:
+			added = append(added, cur.Entities[j])
+			j++
+		default:
+			if base.Entities[i] != cur.Entities[j] {
+				changed = append(changed, cur.Entities[j])
+				baseline = append(baseline, base.Entities[i])
+			}
+			i++
+			j++
+		}
+	}
+
+	dst = append(dst, frameDelta)
+	dst = binary.AppendUvarint(dst, uint64(cur.Tick))
+	dst = binary.AppendUvarint(dst, uint64(cur.Tick-base.Tick))
+
+	dst = binary.AppendUvarint(dst, uint64(len(removed)))
+	prevID := uint32(0)
+	for _, id := range removed {
+		dst = binary.AppendUvarint(dst, uint64(id-prevID))
+		prevID = id
+	}
+
+	dst = binary.AppendUvarint(dst, uint64(len(added)))
+	prevID = 0
+	for _, e := range added {
+		dst = appendEntity(dst, e, prevID)
+		prevID = e.ID
+	}
+
+	dst = binary.AppendUvarint(dst, uint64(len(changed)))
+	prevID = 0
+	for k, e := range changed {
+		old := baseline[k]
+		mask := byte(0)
+
+		if e.X != old.X {
+			mask |= changedX
+		}
+		if e.Y != old.Y {
+			mask |= changedY
+		}
+		if e.Size != old.Size {
+			mask |= changedSize
+		}
+		if e.Kind != old.Kind {
+			mask |= changedKind
+		}
+
+		dst = binary.AppendUvarint(dst, uint64(e.ID-prevID))
+		dst = append(dst, mask)
+		prevID = e.ID
+
+		if mask&changedX != 0 {
+			dst = binary.AppendVarint(dst, int64(e.X)-int64(old.X))
+		}
+		if mask&changedY != 0 {
+			dst = binary.AppendVarint(dst, int64(e.Y)-int64(old.Y))
+		}
+		if mask&changedSize != 0 {
+			dst = binary.AppendVarint(dst, int64(e.Size)-int64(old.Size))
+		}
+		if mask&changedKind != 0 {
+			dst = append(dst, byte(e.Kind))
+		}
+	}
+
+	return dst
+}
+
+func appendEntity(dst []byte, e Quantized, prevID uint32) []byte {
+	dst = binary.AppendUvarint(dst, uint64(e.ID-prevID))
+	dst = append(dst, byte(e.Kind))
+	dst = binary.AppendUvarint(dst, uint64(e.X))
+	dst = binary.AppendUvarint(dst, uint64(e.Y))
+
+	return binary.AppendUvarint(dst, uint64(e.Size))
+}
+
+// ReadHeader читает заголовок кадра, не декодируя его содержимое
+func ReadHeader(data []byte) (Header, error) {
+	r := reader{data: data}
+	header := r.header()
+
+	return header, r.err
+}
+
+// Decode восстанавливает снимок из кадра. Для дельты base должен быть
+// снимком с тиком Header.BaseTick, для полного кадра base игнорируется
+func Decode(base *State, data []byte) (State, error) {
+	r := reader{data: data}
+	header := r.header()
+
+	if r.err != nil {
+		return State{}, r.err
+	}
+
+	if header.Full {
+		return r.full(header.Tick)
+	}
+
+	if base == nil || base.Tick != header.BaseTick {
+		return State{}, ErrBaselineMissing
+	}
+
+	return r.delta(header.Tick, base)
+}
+
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = ErrMalformed
+		return 0
+	}
+
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *reader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = ErrMalformed
+		return 0
+	}
+
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+
+	if len(r.data) == 0 {
+		r.err = ErrMalformed
+		return 0
+	}
+
+	b := r.data[0]
+	r.data = r.data[1:]
+
+	return b
+}
+
+// count читает длину списка и отсекает заведомо невозможные значения,
+// чтобы битый кадр не заставил выделить гигантский слайс
+func (r *reader) count() int {
+	n := r.uvarint()
+
+	if n > uint64(len(r.data)) {
+		r.err = ErrMalformed
+		return 0
+	}
+
+	return int(n)
+}
+
+func (r *reader) header() Header {
+	header := Header{}
+	kind := r.byte()
+	header.Tick = uint32(r.uvarint())
+
+	switch kind {
+	case frameFull:
+		header.Full = true
+		header.BaseTick = header.Tick
+	case frameDelta:
+		header.BaseTick = header.Tick - uint32(r.uvarint())
+	default:
+		if r.err == nil {
+			r.err = ErrMalformed
+		}
+	}
+
+	return header
+}
+
+func (r *reader) entity(prevID uint32) Quantized {
+	return Quantized{
+		ID:   prevID + uint32(r.uvarint()),
+		Kind: Kind(r.byte()),
+		X:    uint16(r.uvarint()),
+		Y:    uint16(r.uvarint()),
+		Size: uint16(r.uvarint()),
+	}
+}
+
+func (r *reader) full(tick uint32) (State, error) {
+	n := r.count()
+	state := State{Tick: tick, Entities: make([]Quantized, 0, n)}
+
+	prevID := uint32(0)
+	for range n {
+		e := r.entity(prevID)
+		prevID = e.ID
+		state.Entities = append(state.Entities, e)
+	}
+
+	return state, r.err
+}
+
+func (r *reader) delta(tick uint32, base *State) (State, error) {
+	removed := map[uint32]bool{}
+	prevID := uint32(0)
+	for range r.count() {
+		prevID += uint32(r.uvarint())
+		removed[prevID] = true
+	}
+
+	var added []Quantized
+	prevID = 0
+	for range r.count() {
+		e := r.entity(prevID)
+		prevID = e.ID
+		added = append(added, e)
+	}
+
+	entities := make([]Quantized, 0, len(base.Entities)+len(added))
+	for _, e := range base.Entities {
+		if !removed[e.ID] {
+			entities = append(entities, e)
+		}
+	}
+
+	index := make(map[uint32]int, len(entities))
+	for i, e := range entities {
+		index[e.ID] = i
+	}
+
+	prevID = 0
+	for range r.count() {
+		prevID += uint32(r.uvarint())
+		mask := r.byte()
+
+		i, ok := index[prevID]
+		if !ok {
+			if r.err == nil {
+				r.err = ErrMalformed
+			}
+			break
+		}
+
+		e := &entities[i]
+
+		if mask&changedX != 0 {
+			e.X = uint16(int64(e.X) + r.varint())
+		}
+		if mask&changedY != 0 {
+			e.Y = uint16(int64(e.Y) + r.varint())
+		}
+		if mask&changedSize != 0 {
+			e.Size = uint16(int64(e.Size) + r.varint())
+		}
+		if mask&changedKind != 0 {
+			e.Kind = Kind(r.byte())
+		}
+	}
+
+	if r.err != nil {
+		return State{}, r.err
+	}
+
+	entities = append(entities, added...)
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+
+	return State{Tick: tick, Entities: entities}, nil
+}
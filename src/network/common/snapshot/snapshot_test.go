@@ -0,0 +1,181 @@
+package snapshot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func state(tick uint32, entities ...Quantized) State {
+	return State{Tick: tick, Entities: entities}
+}
+
+// equal сравнивает снимки, не различая пустой и nil список сущностей
+func equal(a, b State) bool {
+	return a.Tick == b.Tick && len(a.Entities) == len(b.Entities) && (len(a.Entities) == 0 || reflect.DeepEqual(a.Entities, b.Entities))
+}
+
+func TestFullRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cur  State
+	}{
+		{"empty", state(1)},
+		{"one", state(7, Quantized{ID: 3, Kind: KindPlayer, X: 100, Y: 200, Size: 160})},
+		{"many", state(1<<20,
+			Quantized{ID: 1, Kind: KindPlayer, X: 0, Y: 0, Size: 0},
+			Quantized{ID: 2, Kind: KindFood, X: 65535, Y: 65535, Size: 65535},
+			Quantized{ID: 300, Kind: KindFood, X: 12, Y: 34, Size: 56},
+		)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := AppendFull(nil, test.cur)
+
+			header, err := ReadHeader(data)
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			if !header.Full || header.Tick != test.cur.Tick {
+				t.Fatalf("header = %+v, want full frame of tick %d", header, test.cur.Tick)
+			}
+
+			got, err := Decode(nil, data)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+
+			if !equal(got, test.cur) {
+				t.Fatalf("Decode = %+v, want %+v", got, test.cur)
+			}
+		})
+	}
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	base := state(10,
+		Quantized{ID: 1, Kind: KindPlayer, X: 100, Y: 100, Size: 160},
+		Quantized{ID: 2, Kind: KindFood, X: 50, Y: 60, Size: 16},
+		Quantized{ID: 5, Kind: KindFood, X: 70, Y: 80, Size: 16},
+	)
+
+	tests := []struct {
+		name string
+		cur  State
+	}{
+		{"unchanged", state(11, base.Entities...)},
+		{"moved", state(11,
+			Quantized{ID: 1, Kind: KindPlayer, X: 90, Y: 120, Size: 170},
+			Quantized{ID: 2, Kind: KindFood, X: 50, Y: 60, Size: 16},
+			Quantized{ID: 5, Kind: KindFood, X: 70, Y: 80, Size: 16},
+		)},
+		{"removed and added", state(14,
+			Quantized{ID: 1, Kind: KindPlayer, X: 100, Y: 100, Size: 160},
+			Quantized{ID: 6, Kind: KindFood, X: 1, Y: 2, Size: 16},
+			Quantized{ID: 9, Kind: KindPlayer, X: 3000, Y: 4000, Size: 160},
+		)},
+		{"kind changed", state(12,
+			Quantized{ID: 1, Kind: KindFood, X: 100, Y: 100, Size: 160},
+			Quantized{ID: 2, Kind: KindFood, X: 50, Y: 60, Size: 16},
+			Quantized{ID: 5, Kind: KindFood, X: 70, Y: 80, Size: 16},
+		)},
+		{"everything removed", state(11)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := AppendDelta(nil, base, test.cur)
+
+			header, err := ReadHeader(data)
+			if err != nil {
+				t.Fatalf("ReadHeader: %v", err)
+			}
+
+			if header.Full || header.Tick != test.cur.Tick || header.BaseTick != base.Tick {
+				t.Fatalf("header = %+v, want delta from %d to %d", header, base.Tick, test.cur.Tick)
+			}
+
+			got, err := Decode(&base, data)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+
+			if !equal(got, test.cur) {
+				t.Fatalf("Decode = %+v, want %+v", got, test.cur)
+			}
+		})
+	}
+}
+
+func TestQuantizeRoundTrip(t *testing.T) {
+	s := Snapshot{Tick: 3, Entities: []Entity{
+		{ID: 2, Kind: KindFood, X: 10.5, Y: 20.25, Size: 1.0625},
+		{ID: 1, Kind: KindPlayer, X: 1500, Y: 2999.875, Size: 40},
+	}}
+
+	got, err := Decode(nil, AppendFull(nil, Quantize(s)))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	want := Snapshot{Tick: 3, Entities: []Entity{s.Entities[1], s.Entities[0]}}
+	if !reflect.DeepEqual(got.Snapshot(), want) {
+		t.Fatalf("Snapshot = %+v, want %+v", got.Snapshot(), want)
+	}
+}
+
+func TestDecodeBaselineMissing(t *testing.T) {
+	base := state(10, Quantized{ID: 1, Kind: KindPlayer, X: 1, Y: 2, Size: 3})
+	other := state(9, base.Entities...)
+	data := AppendDelta(nil, base, state(11, Quantized{ID: 1, Kind: KindPlayer, X: 2, Y: 2, Size: 3}))
+
+	tests := []struct {
+		name string
+		base *State
+	}{
+		{"no baseline", nil},
+		{"other tick", &other},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decode(test.base, data); !errors.Is(err, ErrBaselineMissing) {
+				t.Fatalf("Decode error = %v, want %v", err, ErrBaselineMissing)
+			}
+		})
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	base := state(10,
+		Quantized{ID: 1, Kind: KindPlayer, X: 1000, Y: 2000, Size: 300},
+		Quantized{ID: 4, Kind: KindFood, X: 5, Y: 6, Size: 16},
+	)
+	cur := state(12,
+		Quantized{ID: 1, Kind: KindPlayer, X: 900, Y: 2100, Size: 310},
+		Quantized{ID: 7, Kind: KindFood, X: 300, Y: 400, Size: 16},
+	)
+
+	frames := map[string][]byte{
+		"full":  AppendFull(nil, cur),
+		"delta": AppendDelta(nil, base, cur),
+	}
+
+	for name, data := range frames {
+		t.Run(name, func(t *testing.T) {
+			for n := range len(data) {
+				if _, err := Decode(&base, data[:n]); !errors.Is(err, ErrMalformed) {
+					t.Fatalf("Decode of %d/%d bytes: error = %v, want %v", n, len(data), err, ErrMalformed)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeUnknownFrame(t *testing.T) {
+	if _, err := ReadHeader([]byte{7, 1}); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("ReadHeader error = %v, want %v", err, ErrMalformed)
+	}
+}
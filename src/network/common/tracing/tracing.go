@@ -0,0 +1,118 @@
+// Трассировка OpenTelemetry для узла, transmitter и регулятора.
+// gRPC трассируется перехватчиками (ServerOptions, DialOptions), а для путей
+// без gRPC (data channel, сигналы через регулятор) контекст передается
+// строкой traceparent (W3C) внутри сообщения: Inject и Extract
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Куда отправлять трассы
+const (
+	// ExporterNone - трассировка выключена, Inject возвращает пустую строку
+	ExporterNone = "none"
+	// ExporterStdout - печатать спаны в stdout
+	ExporterStdout = "stdout"
+	// ExporterOTLP - отправлять в OTLP-коллектор по gRPC. Адрес берется из
+	// OTEL_EXPORTER_OTLP_ENDPOINT, по умолчанию localhost:4317 без TLS
+	ExporterOTLP = "otlp"
+)
+
+const (
+	instrumentation = "github.com/matelq/p2pmp"
+	traceparent     = "traceparent"
+)
+
+// Setup включает трассировку процесса service. Возвращает функцию,
+// которая досылает накопленные спаны при завершении
+func Setup(ctx context.Context, service, exporter string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	case ExporterOTLP:
+		spanExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", exporter)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// ServerOptions - перехватчики gRPC-сервера: спан на каждый входящий вызов
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler())}
+}
+
+// DialOptions - перехватчики gRPC-клиента: спан на каждый исходящий вызов
+// и передача контекста трассы в метаданных
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{grpc.WithStatsHandler(otelgrpc.NewClientHandler())}
+}
+
+// Handler оборачивает HTTP-обработчик, продолжая трассу из заголовков запроса
+func Handler(h http.Handler, operation string) http.Handler {
+	return otelhttp.NewHandler(h, operation)
+}
+
+// Transport - HTTP-транспорт клиента, передающий контекст трассы в заголовках
+func Transport() http.RoundTripper {
+	return otelhttp.NewTransport(http.DefaultTransport)
+}
+
+// Inject возвращает traceparent текущего спана для передачи вне gRPC
+// или пустую строку, если в ctx нет спана или трассировка выключена
+func Inject(ctx context.Context) string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ""
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	return carrier[traceparent]
+}
+
+// Extract продолжает трассу, переданную строкой Inject
+func Extract(ctx context.Context, parent string) context.Context {
+	if parent == "" {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{traceparent: parent})
+}
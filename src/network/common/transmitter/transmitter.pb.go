@@ -0,0 +1,88 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: transmitter.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package transmitter
+
+import (
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_transmitter_proto protoreflect.FileDescriptor
+
+const file_transmitter_proto_rawDesc = "" +
+	"\n" +
+	"\x11transmitter.proto\x12\x12common.transmitter\x1a\x0fcontracts.proto2\xbc\x03\n" +
+	"\vTransmitter\x12I\n" +
+	"\x15CallFuncOnTransmitter\x12\x16.common.contracts.Text\x1a\x16.common.contracts.Text\"\x00\x12D\n" +
+	"\tSendInput\x12\x1d.common.contracts.PlayerInput\x1a\x16.common.contracts.Text\"\x00\x12G\n" +
+	"\bSpectate\x12!.common.contracts.SpectateRequest\x1a\x16.common.contracts.Text\"\x00\x12C\n" +
+	"\x06Follow\x12\x1f.common.contracts.FollowRequest\x1a\x16.common.contracts.Text\"\x00\x12I\n" +
+	"\tJoinMatch\x12\".common.contracts.JoinMatchRequest\x1a\x16.common.contracts.Text\"\x00\x12C\n" +
+	"\bSendChat\x12\x1d.common.contracts.ChatMessage\x1a\x16.common.contracts.Text\"\x00B8Z6github.com/matelq/p2pmp/src/network/common/transmitterb\x06proto3"
+
+var file_transmitter_proto_goTypes = []any{
+	(*contracts.Text)(nil),             // 0: common.contracts.Text
+	(*contracts.PlayerInput)(nil),      // 1: common.contracts.PlayerInput
+	(*contracts.SpectateRequest)(nil),  // 2: common.contracts.SpectateRequest
+	(*contracts.FollowRequest)(nil),    // 3: common.contracts.FollowRequest
+	(*contracts.JoinMatchRequest)(nil), // 4: common.contracts.JoinMatchRequest
+	(*contracts.ChatMessage)(nil),      // 5: common.contracts.ChatMessage
+}
+var file_transmitter_proto_depIdxs = []int32{
+	0, // 0: common.transmitter.Transmitter.CallFuncOnTransmitter:input_type -> common.contracts.Text
+	1, // 1: common.transmitter.Transmitter.SendInput:input_type -> common.contracts.PlayerInput
+	2, // 2: common.transmitter.Transmitter.Spectate:input_type -> common.contracts.SpectateRequest
+	3, // 3: common.transmitter.Transmitter.Follow:input_type -> common.contracts.FollowRequest
+	4, // 4: common.transmitter.Transmitter.JoinMatch:input_type -> common.contracts.JoinMatchRequest
+	5, // 5: common.transmitter.Transmitter.SendChat:input_type -> common.contracts.ChatMessage
+	0, // 6: common.transmitter.Transmitter.CallFuncOnTransmitter:output_type -> common.contracts.Text
+	0, // 7: common.transmitter.Transmitter.SendInput:output_type -> common.contracts.Text
+	0, // 8: common.transmitter.Transmitter.Spectate:output_type -> common.contracts.Text
+	0, // 9: common.transmitter.Transmitter.Follow:output_type -> common.contracts.Text
+	0, // 10: common.transmitter.Transmitter.JoinMatch:output_type -> common.contracts.Text
+	0, // 11: common.transmitter.Transmitter.SendChat:output_type -> common.contracts.Text
+	6, // [6:12] is the sub-list for method output_type
+	0, // [0:6] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_transmitter_proto_init() }
+func file_transmitter_proto_init() {
+	if File_transmitter_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_transmitter_proto_rawDesc), len(file_transmitter_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_transmitter_proto_goTypes,
+		DependencyIndexes: file_transmitter_proto_depIdxs,
+	}.Build()
+	File_transmitter_proto = out.File
+	file_transmitter_proto_goTypes = nil
+	file_transmitter_proto_depIdxs = nil
+}
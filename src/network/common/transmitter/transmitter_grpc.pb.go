@@ -0,0 +1,320 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: transmitter.proto
+
+// TODO: надо еще почитать по поводу названий пакетов
+
+package transmitter
+
+import (
+	context "context"
+	contracts "github.com/matelq/p2pmp/src/network/common/contracts"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Transmitter_CallFuncOnTransmitter_FullMethodName = "/common.transmitter.Transmitter/CallFuncOnTransmitter"
+	Transmitter_SendInput_FullMethodName             = "/common.transmitter.Transmitter/SendInput"
+	Transmitter_Spectate_FullMethodName              = "/common.transmitter.Transmitter/Spectate"
+	Transmitter_Follow_FullMethodName                = "/common.transmitter.Transmitter/Follow"
+	Transmitter_JoinMatch_FullMethodName             = "/common.transmitter.Transmitter/JoinMatch"
+	Transmitter_SendChat_FullMethodName              = "/common.transmitter.Transmitter/SendChat"
+)
+
+// TransmitterClient is the client API for Transmitter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TransmitterClient interface {
+	CallFuncOnTransmitter(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error)
+	SendInput(ctx context.Context, in *contracts.PlayerInput, opts ...grpc.CallOption) (*contracts.Text, error)
+	// Режим зрителя: кадры приходят с задержкой, ввод не принимается
+	Spectate(ctx context.Context, in *contracts.SpectateRequest, opts ...grpc.CallOption) (*contracts.Text, error)
+	Follow(ctx context.Context, in *contracts.FollowRequest, opts ...grpc.CallOption) (*contracts.Text, error)
+	// Вход в матч из назначения матчмейкинга вместо первого матча со свободным местом
+	JoinMatch(ctx context.Context, in *contracts.JoinMatchRequest, opts ...grpc.CallOption) (*contracts.Text, error)
+	// Чат комнаты матча и личные сообщения; ответ - ошибка модерации, если сообщение не принято
+	SendChat(ctx context.Context, in *contracts.ChatMessage, opts ...grpc.CallOption) (*contracts.Text, error)
+}
+
+type transmitterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransmitterClient(cc grpc.ClientConnInterface) TransmitterClient {
+	return &transmitterClient{cc}
+}
+
+func (c *transmitterClient) CallFuncOnTransmitter(ctx context.Context, in *contracts.Text, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Transmitter_CallFuncOnTransmitter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transmitterClient) SendInput(ctx context.Context, in *contracts.PlayerInput, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Transmitter_SendInput_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transmitterClient) Spectate(ctx context.Context, in *contracts.SpectateRequest, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Transmitter_Spectate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transmitterClient) Follow(ctx context.Context, in *contracts.FollowRequest, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Transmitter_Follow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transmitterClient) JoinMatch(ctx context.Context, in *contracts.JoinMatchRequest, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Transmitter_JoinMatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transmitterClient) SendChat(ctx context.Context, in *contracts.ChatMessage, opts ...grpc.CallOption) (*contracts.Text, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(contracts.Text)
+	err := c.cc.Invoke(ctx, Transmitter_SendChat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransmitterServer is the server API for Transmitter service.
+// All implementations must embed UnimplementedTransmitterServer
+// for forward compatibility.
+type TransmitterServer interface {
+	CallFuncOnTransmitter(context.Context, *contracts.Text) (*contracts.Text, error)
+	SendInput(context.Context, *contracts.PlayerInput) (*contracts.Text, error)
+	// Режим зрителя: кадры приходят с задержкой, ввод не принимается
+	Spectate(context.Context, *contracts.SpectateRequest) (*contracts.Text, error)
+	Follow(context.Context, *contracts.FollowRequest) (*contracts.Text, error)
+	// Вход в матч из назначения матчмейкинга вместо первого матча со свободным местом
+	JoinMatch(context.Context, *contracts.JoinMatchRequest) (*contracts.Text, error)
+	// Чат комнаты матча и личные сообщения; ответ - ошибка модерации, если сообщение не принято
+	SendChat(context.Context, *contracts.ChatMessage) (*contracts.Text, error)
+	mustEmbedUnimplementedTransmitterServer()
+}
+
+// UnimplementedTransmitterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTransmitterServer struct{}
+
+func (UnimplementedTransmitterServer) CallFuncOnTransmitter(context.Context, *contracts.Text) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CallFuncOnTransmitter not implemented")
+}
+func (UnimplementedTransmitterServer) SendInput(context.Context, *contracts.PlayerInput) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendInput not implemented")
+}
+func (UnimplementedTransmitterServer) Spectate(context.Context, *contracts.SpectateRequest) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Spectate not implemented")
+}
+func (UnimplementedTransmitterServer) Follow(context.Context, *contracts.FollowRequest) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Follow not implemented")
+}
+func (UnimplementedTransmitterServer) JoinMatch(context.Context, *contracts.JoinMatchRequest) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinMatch not implemented")
+}
+func (UnimplementedTransmitterServer) SendChat(context.Context, *contracts.ChatMessage) (*contracts.Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendChat not implemented")
+}
+func (UnimplementedTransmitterServer) mustEmbedUnimplementedTransmitterServer() {}
+func (UnimplementedTransmitterServer) testEmbeddedByValue()                     {}
+
+// UnsafeTransmitterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TransmitterServer will
+// result in compilation errors.
+type UnsafeTransmitterServer interface {
+	mustEmbedUnimplementedTransmitterServer()
+}
+
+func RegisterTransmitterServer(s grpc.ServiceRegistrar, srv TransmitterServer) {
+	// If the following call pancis, it indicates UnimplementedTransmitterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Transmitter_ServiceDesc, srv)
+}
+
+func _Transmitter_CallFuncOnTransmitter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransmitterServer).CallFuncOnTransmitter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transmitter_CallFuncOnTransmitter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransmitterServer).CallFuncOnTransmitter(ctx, req.(*contracts.Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transmitter_SendInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.PlayerInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransmitterServer).SendInput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transmitter_SendInput_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransmitterServer).SendInput(ctx, req.(*contracts.PlayerInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transmitter_Spectate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.SpectateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransmitterServer).Spectate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transmitter_Spectate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransmitterServer).Spectate(ctx, req.(*contracts.SpectateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transmitter_Follow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.FollowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransmitterServer).Follow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transmitter_Follow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransmitterServer).Follow(ctx, req.(*contracts.FollowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transmitter_JoinMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.JoinMatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransmitterServer).JoinMatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transmitter_JoinMatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransmitterServer).JoinMatch(ctx, req.(*contracts.JoinMatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Transmitter_SendChat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(contracts.ChatMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransmitterServer).SendChat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Transmitter_SendChat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransmitterServer).SendChat(ctx, req.(*contracts.ChatMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Transmitter_ServiceDesc is the grpc.ServiceDesc for Transmitter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Transmitter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "common.transmitter.Transmitter",
+	HandlerType: (*TransmitterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CallFuncOnTransmitter",
+			Handler:    _Transmitter_CallFuncOnTransmitter_Handler,
+		},
+		{
+			MethodName: "SendInput",
+			Handler:    _Transmitter_SendInput_Handler,
+		},
+		{
+			MethodName: "Spectate",
+			Handler:    _Transmitter_Spectate_Handler,
+		},
+		{
+			MethodName: "Follow",
+			Handler:    _Transmitter_Follow_Handler,
+		},
+		{
+			MethodName: "JoinMatch",
+			Handler:    _Transmitter_JoinMatch_Handler,
+		},
+		{
+			MethodName: "SendChat",
+			Handler:    _Transmitter_SendChat_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "transmitter.proto",
+}
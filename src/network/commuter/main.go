@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	commuterpb "github.com/matelq/p2pmp/src/network/common/commuter"
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+	"github.com/matelq/p2pmp/src/network/common/health"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+
+	"google.golang.org/grpc"
+)
+
+// readyGRPC - условие готовности commuter для grpc.health.v1: слушатель поднят
+const readyGRPC = "grpc"
+
+// shutdownTimeout - сколько при остановке ждать начатых вызовов. Потоки
+// health Watch сами не заканчиваются, после таймаута они обрываются
+const shutdownTimeout = 10 * time.Second
+
+// commuterServer - сервис commuter.proto. Соединения узлов через commuter
+// еще не сделаны, пока это эхо, как CallFuncOnServer у transmitter
+type commuterServer struct {
+	commuterpb.UnimplementedCommuterServer
+}
+
+func (commuterServer) CallFuncOnCommuter(ctx context.Context, text *contracts.Text) (*contracts.Text, error) {
+	slog.DebugContext(ctx, "CallFuncOnCommuter called", "data", text.Data)
+
+	return &contracts.Text{Data: fmt.Sprintf("Echo: %s", text.Data)}, nil
+}
+
+func newServer(readiness *health.Readiness) *grpc.Server {
+	grpcServer := grpc.NewServer(append(metrics.ServerOptions(), tracing.ServerOptions()...)...)
+	commuterpb.RegisterCommuterServer(grpcServer, commuterServer{})
+	readiness.Register(grpcServer)
+
+	return grpcServer
+}
+
+func startMetrics(addr string) {
+	if err := metrics.Serve(addr); err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	addr := flag.String("address", ":3007", "Address that the commuter gRPC server is hosted on.")
+	metricsAddr := flag.String("metrics-address", ":9102", "Address that the Prometheus /metrics endpoint is hosted on.")
+	traceExporter := flag.String("trace", tracing.ExporterNone, "Where to export traces: none, stdout or otlp (OTEL_EXPORTER_OTLP_ENDPOINT).")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error.")
+	logFormat := flag.String("log-format", logging.FormatText, "Log output format: text or json.")
+	flag.Parse()
+
+	if err := logging.Setup(*logLevel, *logFormat); err != nil {
+		panic(err)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), "commuter", *traceExporter)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer shutdown(context.Background()) //nolint:errcheck
+
+	listener, err := net.Listen("tcp", *addr)
+
+	if err != nil {
+		panic(err)
+	}
+
+	readiness := health.NewReadiness(readyGRPC)
+	grpcServer := newServer(readiness)
+
+	go startMetrics(*metricsAddr)
+
+	go func() {
+		slog.Info("launching gRPC server", "address", listener.Addr().String())
+		readiness.Set(readyGRPC, true)
+
+		if err := grpcServer.Serve(listener); err != nil {
+			panic(err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	<-ctx.Done()
+	// Повторный сигнал завершает процесс сразу
+	stop()
+
+	// Пока начатые вызовы доходят, проверки состояния видят NOT_SERVING
+	readiness.Drain()
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		grpcServer.Stop()
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/account"
+)
+
+// accountClient - клиент HTTP API аккаунтов на transmitter
+type accountClient struct {
+	url    string
+	client *http.Client
+}
+
+func newAccountClient(url string) *accountClient {
+	return &accountClient{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (c *accountClient) register(login, password string) error {
+	return c.do(http.MethodPost, "/account/register", "", map[string]string{"login": login, "password": password}, nil)
+}
+
+func (c *accountClient) login(login, password string) (account.Session, error) {
+	var session account.Session
+	err := c.do(http.MethodPost, "/account/login", "", map[string]string{"login": login, "password": password}, &session)
+
+	return session, err
+}
+
+func (c *accountClient) profile(token string) (account.ProfileResponse, error) {
+	var profile account.ProfileResponse
+	err := c.do(http.MethodGet, "/account/profile", token, nil, &profile)
+
+	return profile, err
+}
+
+func (c *accountClient) do(method, path, token string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, payload)
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		text, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, res.Status, bytes.TrimSpace(text))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/handshake"
+)
+
+// maxRedirects - больше перенаправлений подряд означает петлю между transmitter
+const maxRedirects = 3
+
+// bootstrap спрашивает у регулятора, к какому transmitter подключаться
+func bootstrap(regulatorAddr, region string) (string, error) {
+	query := url.Values{"kind": {directory.KindTransmitter}, "region": {region}}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s/bootstrap?%s", regulatorAddr, query.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	var res struct {
+		Address string `json:"address"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+
+	return res.Address, nil
+}
+
+// connect подключается к transmitter и проходит рукопожатие, следуя перенаправлениям
+func connect(address, token string) (conn net.Conn, nodeID, sessionID string, err error) {
+	for range maxRedirects + 1 {
+		conn, err = net.DialTimeout("tcp", address, 10*time.Second)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		nodeID, sessionID, err = handshake.Dial(conn, token, handshake.DefaultTimeout)
+
+		var redirect *handshake.RedirectError
+		if !errors.As(err, &redirect) {
+			break
+		}
+
+		conn.Close()
+		slog.Info("transmitter redirected node", "from", address, "to", redirect.Address)
+		address = redirect.Address
+	}
+
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+
+		return nil, "", "", err
+	}
+
+	return conn, nodeID, sessionID, nil
+}
@@ -0,0 +1,368 @@
+// Локальный шлюз между рендером (Electron, client/game.js) и сетевым узлом.
+// Рендер подключается по WebSocket к ws://localhost:8080 и обменивается JSON-сообщениями
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/backend/stats"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+	"github.com/matelq/p2pmp/src/network/node/state"
+
+	"golang.org/x/net/websocket"
+)
+
+const DefaultAddr = "localhost:8080"
+
+// Типы сообщений между рендером и шлюзом
+const (
+	TypeInput   = "input"
+	TypeSelf    = "self"
+	TypePlayers = "players"
+	// TypeMode - переключение рендера в режим зрителя и обратно
+	TypeMode = "mode"
+	// TypeFollow - зритель выбрал игрока, за которым следит камера
+	TypeFollow = "follow"
+	// TypeLeaderboard - живая таблица лидеров матча
+	TypeLeaderboard = "leaderboard"
+	// TypeProfile - аккаунт, под которым узел подключен к transmitter
+	TypeProfile = "profile"
+	// TypeChat - сообщение чата в обе стороны
+	TypeChat = "chat"
+	// TypeMute и TypeBlock - изменение списков mute/block своего игрока
+	TypeMute  = "mute"
+	TypeBlock = "block"
+)
+
+// inbound - сообщение от рендера
+type inbound struct {
+	Type     string  `json:"type"`
+	DirX     float32 `json:"dirX"`
+	DirY     float32 `json:"dirY"`
+	PlayerID uint32  `json:"playerId"`
+	// Text и To - сообщение чата, To пустой для сообщения в комнату
+	Text string `json:"text"`
+	To   string `json:"to"`
+	// Target и Enabled - для TypeMute и TypeBlock
+	Target  string `json:"target"`
+	Enabled bool   `json:"enabled"`
+}
+
+type ModeMessage struct {
+	Type      string `json:"type"`
+	Spectator bool   `json:"spectator"`
+}
+
+// SelfMessage - предсказанное состояние своего игрока для отрисовки
+type SelfMessage struct {
+	Type string  `json:"type"`
+	Seq  uint32  `json:"seq"`
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Size float32 `json:"size"`
+}
+
+// PlayersMessage - сглаженные позиции чужих игроков (allPlayers в game.js)
+// PlayersMessage - другие игроки и еда из мира сервера или lockstep.
+// Получив его, рендер перестает сам раскладывать и есть еду
+type PlayersMessage struct {
+	Type    string       `json:"type"`
+	Players []PlayerView `json:"players"`
+	Foods   []FoodView   `json:"foods"`
+}
+
+type PlayerView struct {
+	ID   uint32  `json:"id"`
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Size float32 `json:"size"`
+}
+
+type FoodView struct {
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Size float32 `json:"size"`
+}
+
+// ProfileMessage - свой ID узла (он же ID аккаунта) и профиль для отрисовки
+type ProfileMessage struct {
+	Type   string `json:"type"`
+	NodeID string `json:"nodeId"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	Skin   string `json:"skin"`
+}
+
+// ChatMessage - сообщение чата для рендера
+type ChatMessage struct {
+	Type string `json:"type"`
+	Kind string `json:"kind"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	Text string `json:"text"`
+	// Sent - время в миллисекундах Unix
+	Sent int64 `json:"sent"`
+}
+
+// LeaderboardMessage - топ игроков матча по размеру
+type LeaderboardMessage struct {
+	Type    string           `json:"type"`
+	Entries []LeaderboardRow `json:"entries"`
+}
+
+type LeaderboardRow struct {
+	NodeID       string  `json:"nodeId"`
+	PlayerID     uint32  `json:"playerId"`
+	Size         float32 `json:"size"`
+	FoodEaten    uint32  `json:"foodEaten"`
+	PlayersEaten uint32  `json:"playersEaten"`
+}
+
+type Gateway struct {
+	reconciler *state.Reconciler
+	onInput    func(game.Input)
+
+	// OnFollow вызывается, когда зритель переключает камеру на игрока playerID
+	OnFollow func(playerID uint32)
+	// OnChat отправляет сообщение своего игрока (через transmitter или хоста)
+	OnChat func(m chat.Message) error
+
+	lists *chat.Lists
+
+	// direction - последнее направление от рендера, RunInput превращает его во ввод
+	inputMu      sync.Mutex
+	direction    [2]float32
+	hasDirection bool
+
+	mu    sync.Mutex
+	conns map[*websocket.Conn]struct{}
+	// profile - последний профиль, рендер получает его сразу после подключения
+	profile *ProfileMessage
+}
+
+// New создает шлюз. onInput вызывается для каждого пронумерованного ввода,
+// который нужно отправить бэкенду, из отдельной горутины (см. RunInput)
+func New(reconciler *state.Reconciler, onInput func(game.Input)) *Gateway {
+	return &Gateway{
+		reconciler: reconciler,
+		onInput:    onInput,
+		lists:      chat.NewLists(),
+		conns:      map[*websocket.Conn]struct{}{},
+	}
+}
+
+func (g *Gateway) Handler() http.Handler {
+	return websocket.Handler(g.serve)
+}
+
+func (g *Gateway) ListenAndServe(addr string) error {
+	slog.Info("launching renderer gateway", "address", addr)
+
+	return http.ListenAndServe(addr, g.Handler()) //nolint:gosec
+}
+
+// Reconcile сверяет предсказание с авторитетным состоянием от бэкенда
+// и отправляет рендеру исправленную позицию
+func (g *Gateway) Reconcile(authoritative game.Player, lastProcessed uint32) {
+	g.sendSelf(g.reconciler.Reconcile(authoritative, lastProcessed))
+}
+
+// SetSpectator переключает рендер в режим зрителя: ввод не отправляется,
+// камера следует за выбранным игроком
+func (g *Gateway) SetSpectator(spectator bool) {
+	g.Broadcast(ModeMessage{Type: TypeMode, Spectator: spectator})
+}
+
+// SetProfile сообщает рендеру, под каким аккаунтом играет узел
+func (g *Gateway) SetProfile(nodeID, name, color, skin string) {
+	msg := ProfileMessage{Type: TypeProfile, NodeID: nodeID, Name: name, Color: color, Skin: skin}
+
+	g.mu.Lock()
+	g.profile = &msg
+	g.mu.Unlock()
+
+	g.Broadcast(msg)
+}
+
+// Chat показывает входящее сообщение чата, если его отправитель не в mute или block
+func (g *Gateway) Chat(m chat.Message) {
+	if !g.lists.Accepts(m) {
+		return
+	}
+
+	g.Broadcast(ChatMessage{Type: TypeChat, Kind: m.Kind.String(), From: m.From, To: m.To, Text: m.Text, Sent: m.Sent.UnixMilli()})
+}
+
+// Leaderboard передает рендеру таблицу лидеров из конверта TypeLeaderboard
+func (g *Gateway) Leaderboard(payload []byte) error {
+	entries, err := stats.UnmarshalLeaderboard(payload)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]LeaderboardRow, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, LeaderboardRow{NodeID: e.NodeID, PlayerID: e.PlayerID, Size: e.Size, FoodEaten: e.FoodEaten, PlayersEaten: e.PlayersEaten})
+	}
+
+	g.Broadcast(LeaderboardMessage{Type: TypeLeaderboard, Entries: rows})
+
+	return nil
+}
+
+// Broadcast отправляет сообщение всем подключенным рендерам
+func (g *Gateway) Broadcast(msg any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for conn := range g.conns {
+		if err := websocket.JSON.Send(conn, msg); err != nil {
+			slog.Warn("cannot send to renderer", logging.Err(err))
+		}
+	}
+}
+
+// RunInput раз в interval превращает последнее направление от рендера в
+// пронумерованный ввод и применяет его к предсказанию. Рендер шлет
+// направление каждый кадр, а бэкенд ждет не больше одного ввода за тик.
+// onInput вызывается из своей горутины через очередь, поэтому ни чтение
+// рендера, ни этот цикл не ждут сеть
+func (g *Gateway) RunInput(interval time.Duration) {
+	queue := make(chan game.Input, state.MaxPendingInputs)
+	defer close(queue)
+
+	go func() {
+		for in := range queue {
+			if g.onInput != nil {
+				g.onInput(in)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		g.inputMu.Lock()
+		direction, ok := g.direction, g.hasDirection
+		g.hasDirection = false
+		g.inputMu.Unlock()
+
+		if !ok {
+			continue
+		}
+
+		in := g.reconciler.Input(direction[0], direction[1])
+		g.sendSelf(g.reconciler.Predicted())
+
+		select {
+		case queue <- in:
+		default:
+			// Сеть не успевает: самый старый ввод выбрасывается, в предсказании
+			// он остается, пока бэкенд не подтвердит более новый
+			select {
+			case <-queue:
+			default:
+			}
+
+			queue <- in
+		}
+	}
+}
+
+// StreamPlayers с периодом interval отправляет рендеру позиции чужих игроков и еды из буфера интерполяции
+func (g *Gateway) StreamPlayers(interpolator *state.Interpolator, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		entities := interpolator.Sample(now)
+		players := make([]PlayerView, 0, len(entities))
+		foods := make([]FoodView, 0, len(entities))
+
+		for _, e := range entities {
+			switch e.Kind {
+			case snapshot.KindPlayer:
+				players = append(players, PlayerView{ID: e.ID, X: e.X, Y: e.Y, Size: e.Size})
+			case snapshot.KindFood:
+				foods = append(foods, FoodView{X: e.X, Y: e.Y, Size: e.Size})
+			}
+		}
+
+		g.Broadcast(PlayersMessage{Type: TypePlayers, Players: players, Foods: foods})
+	}
+}
+
+func (g *Gateway) sendSelf(player game.Player) {
+	g.Broadcast(SelfMessage{Type: TypeSelf, Seq: player.LastInput, X: player.X.Float(), Y: player.Y.Float(), Size: player.Size.Float()})
+}
+
+func (g *Gateway) sendChat(msg inbound) {
+	m := chat.Message{Kind: chat.KindRoom, Text: msg.Text, To: msg.To}
+	if msg.To != "" {
+		m.Kind = chat.KindDirect
+	}
+
+	if g.OnChat == nil {
+		return
+	}
+
+	// Ошибку модерации игрок видит в чате как системное сообщение
+	if err := g.OnChat(m); err != nil {
+		g.Broadcast(ChatMessage{Type: TypeChat, Kind: chat.KindSystem.String(), Text: err.Error(), Sent: time.Now().UnixMilli()})
+	}
+}
+
+func (g *Gateway) serve(conn *websocket.Conn) {
+	g.mu.Lock()
+	g.conns[conn] = struct{}{}
+
+	if g.profile != nil {
+		if err := websocket.JSON.Send(conn, *g.profile); err != nil {
+			slog.Warn("cannot send to renderer", logging.Err(err))
+		}
+	}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.conns, conn)
+		g.mu.Unlock()
+	}()
+
+	slog.Info("renderer connected")
+
+	for {
+		var msg inbound
+
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			slog.Info("renderer disconnected", logging.Err(err))
+			return
+		}
+
+		switch msg.Type {
+		case TypeInput:
+			g.inputMu.Lock()
+			g.direction, g.hasDirection = [2]float32{msg.DirX, msg.DirY}, true
+			g.inputMu.Unlock()
+		case TypeFollow:
+			if g.OnFollow != nil {
+				g.OnFollow(msg.PlayerID)
+			}
+		case TypeChat:
+			g.sendChat(msg)
+		case TypeMute:
+			g.lists.SetMuted(msg.Target, msg.Enabled)
+		case TypeBlock:
+			g.lists.SetBlocked(msg.Target, msg.Enabled)
+		default:
+			slog.Warn("unknown renderer message", "type", msg.Type)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"github.com/matelq/p2pmp/src/network/common/health"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// watchHealth проверяет transmitter через туннель и закрывает туннель, если
+// transmitter не отвечает или не готов (например, выводится из работы):
+// полуоткрытое TCP-соединение иначе обнаружилось бы только по таймауту TCP
+func watchHealth(ctx context.Context, session *yamux.Session, interval time.Duration, failures int) {
+	conn, err := dialTunnel(session)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot create health client", logging.Err(err))
+		return
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-session.CloseChan()
+		cancel()
+	}()
+
+	health.Watch(ctx, conn, interval, health.DefaultTimeout, failures, func(err error) {
+		slog.WarnContext(ctx, "transmitter is unhealthy, closing tunnel", logging.Err(err))
+		session.Close()
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// tunnelMonitor - пульс текущего туннеля, при миграции он меняется
+	tunnelMonitor        atomic.Pointer[heartbeat.Monitor]
+	heartbeatMetricsOnce sync.Once
+)
+
+// watchHeartbeat проверяет туннель пульсом yamux и закрывает его, если
+// transmitter перестал отвечать. RTT и джиттер туннеля отдаются в /metrics
+func watchHeartbeat(ctx context.Context, session *yamux.Session, config heartbeat.Config) {
+	var monitor *heartbeat.Monitor
+
+	monitor = heartbeat.New(config, func(seq uint32, sent time.Time) error {
+		go func() {
+			if _, err := session.Ping(); err == nil {
+				monitor.Pong(seq, sent)
+			}
+		}()
+
+		return nil
+	}, func() {
+		slog.WarnContext(ctx, "transmitter missed heartbeats, closing tunnel", "misses", config.Misses)
+		session.Close()
+	})
+
+	tunnelMonitor.Store(monitor)
+
+	heartbeatMetricsOnce.Do(func() {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "node",
+			Name:      "tunnel_rtt_seconds",
+			Help:      "Smoothed round-trip time of the tunnel to the transmitter by heartbeats.",
+		}, func() float64 { return tunnelMonitor.Load().Stats().RTT.Seconds() })
+
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "node",
+			Name:      "tunnel_jitter_seconds",
+			Help:      "Round-trip time jitter of the tunnel to the transmitter by heartbeats.",
+		}, func() float64 { return tunnelMonitor.Load().Stats().Jitter.Seconds() })
+	})
+
+	monitor.Run(session.CloseChan())
+}
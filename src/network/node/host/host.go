@@ -0,0 +1,372 @@
+// Режим хоста на узле: если регулятор назначил этот узел хостом, узел
+// запускает у себя игровую симуляцию бэкенда и обслуживает остальных
+// участников по P2P, иначе отправляет свой ввод хосту и получает от него снимки
+package host
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/backend/match"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+	"github.com/matelq/p2pmp/src/network/regulator/hosting"
+)
+
+var ErrNoSpectators = errors.New("host: spectators are not supported in host mode")
+
+// DefaultUploadInterval должен быть заметно меньше hosting.DefaultHostTimeout
+const DefaultUploadInterval = time.Second
+
+// Regulator - то, что узлу нужно от регулятора в режиме хоста
+type Regulator interface {
+	UploadState(matchID, nodeID string, epoch uint64, tick uint32, state []byte) error
+	ReportHostLost(matchID, nodeID string) error
+}
+
+// Local принимает снимки для своего игрока (в state.Decoder)
+// и прочие сообщения матча, например таблицу лидеров
+type Local interface {
+	Snapshot(frame []byte, playerID, lastInput uint32)
+	Envelope(e envelope.Envelope)
+}
+
+// Session - участие узла в матче в режиме хоста, реализует p2p.Handler
+type Session struct {
+	self           string
+	links          *p2p.Manager
+	regulator      Regulator
+	local          Local
+	uploadInterval time.Duration
+	// chat - маршрутизация чата, пока этот узел хост (как на transmitter в режиме relay)
+	chat *chat.Relay
+	log  *slog.Logger
+
+	mu       sync.Mutex
+	matchID  string
+	hostID   string
+	epoch    uint64
+	manager  *match.Manager
+	stopHost chan struct{}
+}
+
+func NewSession(self string, regulator Regulator, local Local, uploadInterval time.Duration) *Session {
+	s := &Session{
+		self:           self,
+		regulator:      regulator,
+		local:          local,
+		uploadInterval: uploadInterval,
+		log:            slog.With(logging.Node(self), logging.Transport(metrics.TransportP2P)),
+	}
+	s.chat = chat.NewRelay(chat.DefaultConfig(), nil, s.deliverChat)
+
+	return s
+}
+
+// SetLinks задает P2P-менеджер (он сам создается с Session в роли обработчика)
+func (s *Session) SetLinks(links *p2p.Manager) {
+	s.links = links
+}
+
+// Assign применяет назначение хоста от регулятора: поднимает симуляцию,
+// если хостом выбран этот узел, или подключается к новому хосту
+func (s *Session) Assign(a hosting.Assignment) error {
+	s.mu.Lock()
+
+	if a.Epoch <= s.epoch && a.MatchID == s.matchID {
+		s.mu.Unlock()
+		return nil
+	}
+
+	previousHost := s.hostID
+	previous := s.stop()
+	// Пока прошлая симуляция завершается, хоста нет: закрытие ее соединений
+	// не считается потерей нового хоста
+	s.matchID, s.hostID, s.epoch = a.MatchID, "", a.Epoch
+	s.mu.Unlock()
+
+	// Симуляция прошлой эпохи завершается до перехода: ее CloseRoom закрывает
+	// все соединения и после перехода задел бы соединение с новым хостом
+	if previous != nil {
+		previous.Shutdown()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.epoch != a.Epoch || s.matchID != a.MatchID {
+		// Пока завершалась симуляция, пришло более новое назначение
+		return nil
+	}
+
+	s.hostID = a.Host
+
+	if a.Host != s.self {
+		s.log.Info("match is hosted by peer", logging.Room(a.MatchID), logging.Peer(a.Host), "epoch", a.Epoch)
+
+		if previousHost != "" && previousHost != s.self {
+			s.links.Close(previousHost)
+		}
+
+		return s.links.Connect(a.Host)
+	}
+
+	s.log.Info("hosting match", logging.Room(a.MatchID), "epoch", a.Epoch)
+
+	s.manager = match.NewManager(match.DefaultConfig(), s, s, nil)
+
+	if len(a.State) > 0 {
+		if _, err := s.manager.Restore(a.MatchID, a.State); err != nil {
+			return err
+		}
+	}
+
+	for _, member := range a.Members {
+		if _, err := s.manager.Join(a.MatchID, member); err != nil {
+			s.log.Warn("cannot join hosted match", logging.Room(a.MatchID), logging.Peer(member), logging.Err(err))
+		}
+	}
+
+	s.stopHost = make(chan struct{})
+	go s.upload(s.manager, a.MatchID, a.Epoch, s.stopHost)
+
+	return nil
+}
+
+// Input отправляет ввод своего игрока в симуляцию: локально или хосту
+func (s *Session) Input(in game.Input) error {
+	s.mu.Lock()
+	manager, hostID := s.manager, s.hostID
+	s.mu.Unlock()
+
+	if manager != nil {
+		return manager.Input(s.self, in)
+	}
+
+	return s.links.Send(hostID, envelope.Envelope{Type: envelope.TypeInput, Payload: envelope.InputPayload(in.Seq, in.DirX, in.DirY)})
+}
+
+// Chat отправляет сообщение чата своего игрока: хост маршрутизирует его сам,
+// остальные узлы передают хосту
+func (s *Session) Chat(m chat.Message) error {
+	s.mu.Lock()
+	manager, hostID := s.manager, s.hostID
+	s.mu.Unlock()
+
+	m.From = s.self
+
+	if manager != nil {
+		return s.chat.Route(m, s.members())
+	}
+
+	return s.links.Send(hostID, envelope.Envelope{Type: envelope.TypeChat, From: s.self, Payload: chat.Marshal(m)})
+}
+
+// Ack подтверждает декодированный снимок
+func (s *Session) Ack(tick uint32) error {
+	s.mu.Lock()
+	manager, hostID := s.manager, s.hostID
+	s.mu.Unlock()
+
+	if manager != nil {
+		return manager.Ack(s.self, tick)
+	}
+
+	return s.links.Send(hostID, envelope.Envelope{Type: envelope.TypeSnapshotAck, Payload: envelope.TickPayload(tick)})
+}
+
+func (s *Session) HandleEnvelope(peer string, e envelope.Envelope) {
+	s.mu.Lock()
+	manager, hostID := s.manager, s.hostID
+	s.mu.Unlock()
+
+	switch {
+	case manager != nil && e.Type == envelope.TypeInput:
+		seq, dirX, dirY, err := envelope.ReadInput(e.Payload)
+		if err == nil {
+			err = manager.Input(peer, game.Input{Seq: seq, DirX: dirX, DirY: dirY})
+		}
+
+		if err != nil {
+			s.log.Warn("bad input", logging.Peer(peer), logging.Err(err))
+		}
+	case manager != nil && e.Type == envelope.TypeSnapshotAck:
+		if tick, err := envelope.ReadTick(e.Payload); err == nil {
+			manager.Ack(peer, tick)
+		}
+	case manager != nil && e.Type == envelope.TypeChat:
+		m, err := chat.Unmarshal(e.Payload)
+		if err != nil {
+			s.log.Warn("bad chat message", logging.Peer(peer), logging.Err(err))
+			return
+		}
+
+		// Отправитель - узел на том конце канала, а не поле сообщения
+		m.From = peer
+
+		if err := s.chat.Route(m, s.members()); err != nil {
+			s.log.Info("chat message is not delivered", logging.Peer(peer), logging.Err(err))
+		}
+	case peer == hostID && e.Type == envelope.TypeSnapshot:
+		playerID, lastInput, frame, err := envelope.ReadSnapshot(e.Payload)
+		if err != nil {
+			s.log.Warn("bad snapshot", logging.Peer(peer), logging.Err(err))
+			return
+		}
+
+		s.local.Snapshot(frame, playerID, lastInput)
+	case peer == hostID && (e.Type == envelope.TypeLeaderboard || e.Type == envelope.TypeChat):
+		s.local.Envelope(e)
+	}
+}
+
+func (s *Session) HandleLinkState(peer string, up bool) {
+	s.mu.Lock()
+	matchID, hostID, manager := s.matchID, s.hostID, s.manager
+	s.mu.Unlock()
+
+	if !up {
+		s.chat.Forget(peer)
+	}
+
+	// Участник сам к хосту не переподключается, поэтому потеря соединения
+	// (в том числе по пульсу) выводит его из матча
+	if !up && manager != nil {
+		s.log.Info("peer disconnected, leaving match", logging.Room(matchID), logging.Peer(peer))
+		manager.Leave(peer)
+	}
+
+	if up || peer != hostID {
+		return
+	}
+
+	s.log.Warn("lost link to host", logging.Room(matchID), logging.Peer(peer))
+
+	if err := s.regulator.ReportHostLost(matchID, s.self); err != nil {
+		s.log.Error("cannot report lost host", logging.Room(matchID), logging.Err(err))
+	}
+}
+
+func (s *Session) members() []string {
+	return append(s.links.Peers(), s.self)
+}
+
+func (s *Session) deliverChat(to string, m chat.Message) error {
+	e := envelope.Envelope{Type: envelope.TypeChat, From: s.self, Payload: chat.Marshal(m)}
+
+	if to == s.self {
+		s.local.Envelope(e)
+		return nil
+	}
+
+	return s.links.Send(to, e)
+}
+
+// Leave завершает участие в матче
+func (s *Session) Leave() {
+	s.mu.Lock()
+	previous := s.stop()
+	s.matchID, s.hostID = "", ""
+	s.mu.Unlock()
+
+	if previous != nil {
+		previous.Shutdown()
+	}
+
+	s.links.CloseAll()
+}
+
+// SendSnapshot реализует match.Sender для симуляции на хосте
+func (s *Session) SendSnapshot(nodeID string, data []byte, playerID, lastInput uint32) error {
+	if nodeID == s.self {
+		s.local.Snapshot(data, playerID, lastInput)
+		return nil
+	}
+
+	return s.links.Send(nodeID, envelope.Envelope{Type: envelope.TypeSnapshot, Payload: envelope.SnapshotPayload(playerID, lastInput, data)})
+}
+
+// Комнаты хосту не нужны: все участники подключены к нему напрямую,
+// а при завершении матча закрываются P2P-соединения
+
+func (s *Session) CreateRoom(string) error        { return nil }
+func (s *Session) JoinRoom(string, string) error  { return nil }
+func (s *Session) LeaveRoom(string, string) error { return nil }
+
+// Зрителей в режиме хоста нет: их трафик пошел бы через канал игрока-хоста
+func (s *Session) SpectateRoom(string, string) error { return ErrNoSpectators }
+
+// Publish рассылает конверт всем участникам по P2P и своему игроку
+func (s *Session) Publish(_ string, e envelope.Envelope) error {
+	var errs []error
+	for _, peer := range s.links.Peers() {
+		if err := s.links.Send(peer, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	s.local.Envelope(e)
+
+	return errors.Join(errs...)
+}
+
+func (s *Session) CloseRoom(string) error {
+	s.links.CloseAll()
+	return nil
+}
+
+// stop останавливает выгрузку состояния и возвращает симуляцию, которую
+// вызывающий должен завершить (Shutdown) уже без s.mu. Вызывается под s.mu
+func (s *Session) stop() *match.Manager {
+	if s.stopHost != nil {
+		close(s.stopHost)
+		s.stopHost = nil
+	}
+
+	manager := s.manager
+	s.manager = nil
+
+	return manager
+}
+
+// upload периодически отправляет состояние матча регулятору для будущей миграции
+// и передает симуляции RTT участников по пульсу для компенсации задержки
+func (s *Session) upload(manager *match.Manager, matchID string, epoch uint64, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.uploadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		m, ok := manager.Get(matchID)
+		if !ok {
+			return
+		}
+
+		for _, peer := range s.links.Peers() {
+			if stats, ok := s.links.Stats(peer); ok && stats.RTT > 0 {
+				manager.SetRTT(peer, stats.RTT)
+			}
+		}
+
+		state, tick, err := m.Save()
+		if err != nil {
+			s.log.Error("cannot save match state", logging.Room(matchID), logging.Err(err))
+			continue
+		}
+
+		if err := s.regulator.UploadState(matchID, s.self, epoch, tick, state); err != nil {
+			s.log.Warn("cannot upload match state", logging.Room(matchID), logging.Err(err))
+		}
+	}
+}
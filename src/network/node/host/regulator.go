@@ -0,0 +1,85 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/matelq/p2pmp/src/network/regulator/hosting"
+)
+
+// HTTPRegulator - клиент HTTP-эндпоинтов режима хоста на регуляторе
+type HTTPRegulator struct {
+	Addr string
+	// Credential - учетные данные узла на регуляторе (p2p.Authenticate)
+	Credential string
+}
+
+func (r HTTPRegulator) UploadState(matchID, nodeID string, epoch uint64, tick uint32, state []byte) error {
+	query := url.Values{
+		"match": {matchID},
+		"node":  {nodeID},
+		"epoch": {fmt.Sprint(epoch)},
+		"tick":  {fmt.Sprint(tick)},
+	}
+
+	return r.post("/host/state", query, state)
+}
+
+func (r HTTPRegulator) ReportHostLost(matchID, nodeID string) error {
+	return r.post("/host/lost", url.Values{"match": {matchID}, "node": {nodeID}}, nil)
+}
+
+// PollAssignment забирает назначение хоста, если оно появилось
+func (r HTTPRegulator) PollAssignment(nodeID string) (hosting.Assignment, bool, error) {
+	resp, err := r.do(http.MethodGet, "/host/assignment", url.Values{"node": {nodeID}}, nil)
+	if err != nil {
+		return hosting.Assignment{}, false, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return hosting.Assignment{}, false, nil
+	case http.StatusOK:
+	default:
+		return hosting.Assignment{}, false, fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	var a hosting.Assignment
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return hosting.Assignment{}, false, err
+	}
+
+	return a, true, nil
+}
+
+func (r HTTPRegulator) post(path string, query url.Values, body []byte) error {
+	resp, err := r.do(http.MethodPost, path, query, body)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (r HTTPRegulator) do(method, path string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s?%s", r.Addr, path, query.Encode()), bytes.NewReader(body)) // nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", "Bearer "+r.Credential)
+
+	return http.DefaultClient.Do(req)
+}
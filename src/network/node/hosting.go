@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/account"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/node/gateway"
+	"github.com/matelq/p2pmp/src/network/node/host"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+)
+
+// hostMatch - настройки режима хоста: матч и хоста назначает регулятор
+type hostMatch struct {
+	regulator      string
+	signalInterval time.Duration
+	// pollInterval - как часто узел спрашивает регулятор о назначении хоста
+	pollInterval   time.Duration
+	uploadInterval time.Duration
+	heartbeats     heartbeat.Config
+}
+
+// hostLocal передает рендеру снимки и сообщения P2P-матча и подтверждает
+// декодированные снимки хосту
+type hostLocal struct {
+	view    *matchView
+	session *host.Session
+}
+
+func (l *hostLocal) Snapshot(frame []byte, playerID, lastInput uint32) {
+	tick, err := l.view.snapshot(context.Background(), frame, playerID, lastInput)
+	if err != nil {
+		slog.Warn("bad snapshot from host", logging.Err(err))
+		return
+	}
+
+	if tick == 0 {
+		return
+	}
+
+	if err := l.session.Ack(tick); err != nil {
+		slog.Debug("cannot ack snapshot", "tick", tick, logging.Err(err))
+	}
+}
+
+func (l *hostLocal) Envelope(e envelope.Envelope) {
+	if err := l.view.envelope(e.Type, e.Payload); err != nil {
+		slog.Warn("bad message from host", "type", e.Type, logging.Err(err))
+	}
+}
+
+// runHost играет P2P-матч в режиме хоста: узел ждет от регулятора назначения
+// и либо сам ведет симуляцию, либо подключается к назначенному хосту.
+// Блокируется до остановки шлюза
+func runHost(profile account.ProfileResponse, token string, m hostMatch) error {
+	creds, err := p2p.Authenticate(m.regulator, token)
+	if err != nil {
+		return fmt.Errorf("cannot authenticate on regulator: %w", err)
+	}
+
+	regulator := host.HTTPRegulator{Addr: m.regulator, Credential: creds.Credential}
+	local := &hostLocal{}
+	session := host.NewSession(creds.NodeID, regulator, local, m.uploadInterval)
+
+	gw := newGateway(profile, session)
+	local.view, local.session = newMatchView(gw), session
+
+	signaler := p2p.NewHTTPSignaler(m.regulator, creds, m.signalInterval)
+	defer signaler.Close()
+
+	manager := p2p.NewManager(creds.NodeID, signaler, session, p2p.DefaultConfiguration())
+	manager.SetHeartbeat(m.heartbeats)
+	session.SetLinks(manager)
+
+	go manager.Run()
+	defer session.Leave()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go pollHost(session, regulator, creds.NodeID, m.pollInterval, stop)
+	go gw.RunInput(time.Second / game.TickRate)
+	go gw.StreamPlayers(local.view.interpolator, time.Second/60)
+
+	slog.Info("waiting for host assignment", logging.Node(creds.NodeID))
+
+	return gw.ListenAndServe(gateway.DefaultAddr)
+}
+
+// pollHost применяет назначения хоста от регулятора, пока не закрыт stop
+func pollHost(session *host.Session, regulator host.HTTPRegulator, nodeID string, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		a, ok, err := regulator.PollAssignment(nodeID)
+		if err != nil {
+			slog.Warn("cannot poll host assignment", logging.Err(err))
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		// Повтор того же назначения Session пропускает по эпохе
+		if err := session.Assign(a); err != nil {
+			slog.Error("cannot apply host assignment", logging.Room(a.MatchID), logging.Peer(a.Host), logging.Err(err))
+		}
+	}
+}
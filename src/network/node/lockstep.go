@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/account"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+	"github.com/matelq/p2pmp/src/network/node/gateway"
+	"github.com/matelq/p2pmp/src/network/node/lockstep"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+	"github.com/matelq/p2pmp/src/network/node/state"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var lockstepDesyncs = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "node",
+	Name:      "lockstep_desyncs_total",
+	Help:      "State hash mismatches with peers in lockstep matches.",
+})
+
+// simulation - общее у lockstep.Lockstep и lockstep.Rollback, что нужно узлу
+type simulation interface {
+	p2p.Handler
+	SetDirection(dirX, dirY float32)
+	Run(stop <-chan struct{})
+	Frame() uint32
+	Player() (game.Player, bool)
+	Snapshot() snapshot.Snapshot
+}
+
+// lockstepMatch - настройки P2P-матча без сервера: состав и seed должны совпадать на всех узлах
+type lockstepMatch struct {
+	regulator string
+	members   []string
+	seed      uint64
+	config    lockstep.Config
+	// maxRollback - окно отката в кадрах; 0 - чистый lockstep без предсказания
+	maxRollback    uint32
+	signalInterval time.Duration
+	heartbeats     heartbeat.Config
+}
+
+// peerLinks отдает lockstep отправку через p2p.Manager: менеджер создается
+// уже с матчем в роли обработчика, поэтому ссылка на него задается позже
+type peerLinks struct {
+	manager atomic.Pointer[p2p.Manager]
+}
+
+func (l *peerLinks) Send(peer string, e envelope.Envelope) error {
+	manager := l.manager.Load()
+	if manager == nil {
+		return p2p.ErrNoLink
+	}
+
+	return manager.Send(peer, e)
+}
+
+// runLockstep играет матч в lockstep с участниками m.members по P2P: сигналы
+// идут через регулятор, а transmitter не нужен. Блокируется до остановки шлюза
+func runLockstep(profile account.ProfileResponse, token string, m lockstepMatch) error {
+	creds, err := p2p.Authenticate(m.regulator, token)
+	if err != nil {
+		return fmt.Errorf("cannot authenticate on regulator: %w", err)
+	}
+
+	members := slices.Clone(m.members)
+	if !slices.Contains(members, creds.NodeID) {
+		members = append(members, creds.NodeID)
+	}
+
+	links := &peerLinks{}
+
+	reconciler := state.NewReconciler(*game.NewPlayer(0, game.FixedFromInt(game.MapWidth/2), game.FixedFromInt(game.MapHeight/2)))
+	// Номер последнего ввода рендера: его позиция уже учтена в направлении матча
+	var lastInput atomic.Uint32
+	// Рендер шлет ввод только после запуска шлюза, когда матч уже создан
+	var match simulation
+	gw := gateway.New(reconciler, func(in game.Input) {
+		match.SetDirection(in.DirX, in.DirY)
+		lastInput.Store(in.Seq)
+	})
+	gw.SetProfile(profile.ID, profile.Profile.Name, profile.Profile.Color, profile.Profile.Skin)
+
+	// Вызывается под блокировкой матча, поэтому только считает и сообщает рендеру
+	match, err = newSimulation(creds.NodeID, members, links, m, func(frame uint32, peer string) {
+		lockstepDesyncs.Inc()
+		gw.Broadcast(gateway.ChatMessage{
+			Type: gateway.TypeChat,
+			Kind: chat.KindSystem.String(),
+			Text: fmt.Sprintf("state desync with %s at frame %d", peer, frame),
+			Sent: time.Now().UnixMilli(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	signaler := p2p.NewHTTPSignaler(m.regulator, creds, m.signalInterval)
+	defer signaler.Close()
+
+	manager := p2p.NewManager(creds.NodeID, signaler, match, p2p.DefaultConfiguration())
+	manager.SetHeartbeat(m.heartbeats)
+	links.manager.Store(manager)
+
+	go manager.Run()
+	defer manager.CloseAll()
+
+	// Соединение открывает узел с меньшим ID, чтобы два offer не встретились
+	for _, member := range members {
+		if member <= creds.NodeID {
+			continue
+		}
+
+		if err := manager.Connect(member); err != nil {
+			slog.Warn("cannot connect to lockstep peer", logging.Peer(member), logging.Err(err))
+		}
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	interpolator := state.NewInterpolator(state.DefaultInterpolationDelay, state.DefaultMaxExtrapolation)
+
+	go match.Run(stop)
+	go renderLockstep(match, gw, interpolator, &lastInput, stop)
+	go gw.RunInput(time.Second / game.TickRate)
+	go gw.StreamPlayers(interpolator, time.Second/60)
+
+	slog.Info("playing lockstep match", logging.Node(creds.NodeID), "members", members, "seed", m.seed, "rollback", m.maxRollback)
+
+	return gw.ListenAndServe(gateway.DefaultAddr)
+}
+
+// newSimulation создает матч с откатом, если задано окно отката, иначе чистый lockstep
+func newSimulation(self string, members []string, links lockstep.Links, m lockstepMatch, onDesync func(frame uint32, peer string)) (simulation, error) {
+	if m.maxRollback == 0 {
+		match, err := lockstep.New(self, members, m.seed, links, m.config)
+		if err != nil {
+			return nil, err
+		}
+
+		match.OnDesync = onDesync
+
+		return match, nil
+	}
+
+	match, err := lockstep.NewRollback(self, members, m.seed, links, m.config, m.maxRollback)
+	if err != nil {
+		return nil, err
+	}
+
+	match.OnDesync = onDesync
+	watchRollback(match)
+
+	return match, nil
+}
+
+// renderLockstep после каждого просчитанного кадра отдает рендеру свою
+// позицию и чужих игроков. Свой игрок в lockstep отстает на InputDelay
+// кадров, поэтому предсказание шлюза сбрасывается на состояние матча
+func renderLockstep(match simulation, gw *gateway.Gateway, interpolator *state.Interpolator, lastInput *atomic.Uint32, stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Second / game.TickRate)
+	defer ticker.Stop()
+
+	var rendered uint32
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			frame := match.Frame()
+			if frame == rendered {
+				continue
+			}
+
+			rendered = frame
+
+			player, ok := match.Player()
+			if !ok {
+				continue
+			}
+
+			interpolator.Push(now, match.Snapshot(), player.ID)
+			gw.Reconcile(player, lastInput.Load())
+		}
+	}
+}
+
+// watchRollback публикует метрики отката: частоту (rollbacks к frames) и глубину
+func watchRollback(match *lockstep.Rollback) {
+	counter := func(name, help string, value func(lockstep.RollbackStats) uint64) {
+		promauto.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "node",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(match.Stats())) })
+	}
+
+	counter("rollback_frames_total", "Frames simulated in the rollback match.", func(s lockstep.RollbackStats) uint64 { return s.Frames })
+	counter("rollbacks_total", "Rollbacks after a mispredicted peer input.", func(s lockstep.RollbackStats) uint64 { return s.Rollbacks })
+	counter("rollback_resimulated_frames_total", "Frames simulated again after rollbacks.", func(s lockstep.RollbackStats) uint64 { return s.ResimulatedFrames })
+	counter("rollback_stalls_total", "Ticks the rollback match waited for peer input at the rollback window.", func(s lockstep.RollbackStats) uint64 { return s.Stalls })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "node",
+		Name:      "rollback_max_depth_frames",
+		Help:      "Deepest rollback in the match, in frames.",
+	}, func() float64 { return float64(match.Stats().MaxDepth) })
+}
@@ -0,0 +1,70 @@
+package lockstep
+
+import (
+	"log/slog"
+
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// hashes сверяет хеши состояния участников по кадрам (общая часть lockstep и отката)
+type hashes struct {
+	self     string
+	members  int
+	history  uint32
+	byFrame  map[uint32]map[string]uint64
+	onDesync func(frame uint32, peer string)
+}
+
+func newHashes(self string, members int, history uint32, onDesync func(frame uint32, peer string)) *hashes {
+	return &hashes{self: self, members: members, history: history, byFrame: map[uint32]map[string]uint64{}, onDesync: onDesync}
+}
+
+// add запоминает хеш участника и сверяет его с нашим, если оба уже известны
+func (h *hashes) add(frame uint32, member string, hash uint64) {
+	if h.byFrame[frame] == nil {
+		h.byFrame[frame] = make(map[string]uint64, h.members)
+	}
+
+	h.byFrame[frame][member] = hash
+
+	own, ok := h.byFrame[frame][h.self]
+	if !ok {
+		return
+	}
+
+	for peer, hash := range h.byFrame[frame] {
+		// Каждую пару сверяем один раз: когда пришел второй из двух хешей
+		if peer == h.self || hash == own || (member != h.self && member != peer) {
+			continue
+		}
+
+		slog.Warn("state desync", logging.Node(h.self), logging.Peer(peer), "frame", frame)
+		h.onDesync(frame, peer)
+	}
+
+	if len(h.byFrame[frame]) == h.members {
+		delete(h.byFrame, frame)
+	}
+}
+
+// trim выбрасывает хеши, которые так и не дождались хешей других участников
+func (h *hashes) trim(frame uint32) {
+	for old := range h.byFrame {
+		if old+h.history < frame {
+			delete(h.byFrame, old)
+		}
+	}
+}
+
+func broadcast(links Links, self string, members []string, e envelope.Envelope) {
+	for _, member := range members {
+		if member == self {
+			continue
+		}
+
+		if err := links.Send(member, e); err != nil {
+			slog.Warn("cannot send to peer", logging.Node(self), logging.Peer(member), "type", e.Type.String(), logging.Err(err))
+		}
+	}
+}
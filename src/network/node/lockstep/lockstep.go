@@ -0,0 +1,281 @@
+// Детерминированный lockstep для небольших P2P-матчей: узлы обмениваются
+// только вводом на каждый кадр, а симуляцию game.World ведет каждый узел сам.
+// Кадр продвигается, когда пришел ввод всех участников, а совпадение
+// состояний проверяется периодическим обменом хешами
+package lockstep
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+var ErrNotMember = errors.New("lockstep: node is not a member of the match")
+
+type Config struct {
+	// InputDelay - через сколько кадров применяется ввод. Задержка скрывает
+	// пинг: пока ввод идет до остальных, симуляция не стоит
+	InputDelay uint32
+	// HashInterval - раз во сколько кадров узлы сверяют хеш состояния
+	HashInterval uint32
+	FrameRate    int
+	// HashHistory - сколько кадров хранятся хеши в ожидании хешей других узлов
+	HashHistory uint32
+}
+
+func DefaultConfig() Config {
+	return Config{
+		InputDelay:   3,
+		HashInterval: game.TickRate,
+		FrameRate:    game.TickRate,
+		HashHistory:  game.TickRate * 10,
+	}
+}
+
+// Links - отправка конвертов другим участникам (p2p.Manager)
+type Links interface {
+	Send(peer string, e envelope.Envelope) error
+}
+
+type direction struct {
+	x, y float32
+}
+
+// Lockstep реализует p2p.Handler
+type Lockstep struct {
+	self    string
+	members []string
+	links   Links
+	config  Config
+
+	// OnDesync вызывается, если хеш состояния peer на кадре frame не совпал с нашим.
+	// Вызывается под блокировкой, поэтому не должен обращаться к Lockstep
+	OnDesync func(frame uint32, peer string)
+
+	mu        sync.Mutex
+	world     *game.World
+	players   map[string]uint32
+	direction direction
+	// sent - последний кадр, для которого отправлен свой ввод
+	sent   uint32
+	inputs map[uint32]map[string]direction
+	hashes *hashes
+}
+
+// New создает матч; seed и members должны совпадать на всех узлах
+func New(self string, members []string, seed uint64, links Links, config Config) (*Lockstep, error) {
+	members = slices.Clone(members)
+	slices.Sort(members)
+
+	if !slices.Contains(members, self) {
+		return nil, ErrNotMember
+	}
+
+	l := &Lockstep{
+		self:    self,
+		members: members,
+		links:   links,
+		config:  config,
+		world:   game.NewWorld(seed),
+		players: make(map[string]uint32, len(members)),
+		sent:    config.InputDelay,
+		inputs:  map[uint32]map[string]direction{},
+	}
+	l.hashes = newHashes(self, len(members), config.HashHistory, l.desync)
+
+	// Игроки добавляются в порядке участников, поэтому ID совпадают на всех узлах
+	for _, member := range members {
+		l.players[member] = l.world.AddPlayer().ID
+	}
+
+	return l, nil
+}
+
+// SetDirection задает направление движения своего игрока для следующих кадров.
+// Направление ограничивается до отправки, чтобы участники получали его уже приведенным
+func (l *Lockstep) SetDirection(dirX, dirY float32) {
+	dirX, dirY = game.ClampDirection(dirX, dirY)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.direction = direction{x: dirX, y: dirY}
+}
+
+// Run продвигает симуляцию с частотой FrameRate до закрытия stop
+func (l *Lockstep) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Second / time.Duration(l.config.FrameRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.Tick()
+		}
+	}
+}
+
+// Tick отправляет свой ввод на кадр текущий+InputDelay и делает не больше
+// одного кадра симуляции. Возвращает false, если ждем ввод других участников
+func (l *Lockstep) Tick() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for target := l.world.Tick + 1 + l.config.InputDelay; l.sent < target; {
+		l.sent++
+		l.submit(l.sent, l.direction)
+	}
+
+	frame := l.world.Tick + 1
+	if !l.ready(frame) {
+		return false
+	}
+
+	l.step(frame)
+
+	return true
+}
+
+// Frame - номер последнего просчитанного кадра
+func (l *Lockstep) Frame() uint32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.world.Tick
+}
+
+func (l *Lockstep) Snapshot() snapshot.Snapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.world.Snapshot()
+}
+
+// Player возвращает своего игрока
+func (l *Lockstep) Player() (game.Player, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.world.Players[l.players[l.self]]
+	if !ok {
+		return game.Player{}, false
+	}
+
+	return *p, true
+}
+
+func (l *Lockstep) HandleEnvelope(peer string, e envelope.Envelope) {
+	if !slices.Contains(l.members, peer) || peer == l.self {
+		return
+	}
+
+	switch e.Type {
+	case envelope.TypeLockstepInput:
+		frame, dirX, dirY, err := envelope.ReadInput(e.Payload)
+		if err != nil {
+			slog.Warn("bad lockstep input", logging.Node(l.self), logging.Peer(peer), logging.Err(err))
+			return
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		// Участник не может обогнать нас больше чем на два окна задержки:
+		// ему для этого нужен наш ввод, а он отправлен не дальше текущего окна
+		if frame <= l.world.Tick || frame > l.world.Tick+2+2*l.config.InputDelay {
+			return
+		}
+
+		l.input(frame, peer, direction{x: dirX, y: dirY})
+	case envelope.TypeStateHash:
+		frame, hash, err := envelope.ReadHash(e.Payload)
+		if err != nil {
+			slog.Warn("bad state hash", logging.Node(l.self), logging.Peer(peer), logging.Err(err))
+			return
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.hashes.add(frame, peer, hash)
+	}
+}
+
+// HandleLinkState только логирует: без ввода пропавшего участника
+// симуляция встанет, решать судьбу матча должен регулятор
+func (l *Lockstep) HandleLinkState(peer string, up bool) {
+	if !up {
+		slog.Warn("lost link to peer, waiting for its input", logging.Node(l.self), logging.Peer(peer))
+	}
+}
+
+func (l *Lockstep) desync(frame uint32, peer string) {
+	if l.OnDesync != nil {
+		l.OnDesync(frame, peer)
+	}
+}
+
+func (l *Lockstep) submit(frame uint32, d direction) {
+	l.input(frame, l.self, d)
+
+	payload := envelope.InputPayload(frame, d.x, d.y)
+	l.broadcast(envelope.Envelope{Type: envelope.TypeLockstepInput, From: l.self, Payload: payload})
+}
+
+func (l *Lockstep) input(frame uint32, member string, d direction) {
+	if l.inputs[frame] == nil {
+		l.inputs[frame] = make(map[string]direction, len(l.members))
+	}
+
+	l.inputs[frame][member] = d
+}
+
+// ready проверяет, что для кадра есть ввод всех участников.
+// Первые InputDelay кадров идут без ввода: его никто не мог отправить
+func (l *Lockstep) ready(frame uint32) bool {
+	return frame <= l.config.InputDelay || len(l.inputs[frame]) == len(l.members)
+}
+
+func (l *Lockstep) step(frame uint32) {
+	for _, member := range l.members {
+		d, ok := l.inputs[frame][member]
+		if !ok {
+			continue
+		}
+
+		l.world.QueueInput(l.players[member], game.Input{Seq: frame, DirX: d.x, DirY: d.y})
+	}
+
+	delete(l.inputs, frame)
+
+	// Съеденные игроки появляются заново, обход в порядке участников сохраняет детерминизм
+	eaten := l.world.Step()
+	for _, member := range l.members {
+		if slices.Contains(eaten, l.players[member]) {
+			l.world.RemovePlayer(l.players[member])
+			l.players[member] = l.world.AddPlayer().ID
+		}
+	}
+
+	if l.config.HashInterval == 0 || frame%l.config.HashInterval != 0 {
+		return
+	}
+
+	hash := l.world.Hash()
+	l.hashes.add(frame, l.self, hash)
+	l.broadcast(envelope.Envelope{Type: envelope.TypeStateHash, From: l.self, Payload: envelope.HashPayload(frame, hash)})
+	l.hashes.trim(frame)
+}
+
+func (l *Lockstep) broadcast(e envelope.Envelope) {
+	broadcast(l.links, l.self, l.members, e)
+}
@@ -0,0 +1,367 @@
+package lockstep
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+// DefaultMaxRollback - 8 кадров при 20 тиках в секунду покрывают 400мс задержки
+const DefaultMaxRollback = 8
+
+// RollbackStats - метрики отката: частота (Rollbacks к Frames) и глубина
+type RollbackStats struct {
+	Frames            uint64
+	Rollbacks         uint64
+	ResimulatedFrames uint64
+	MaxDepth          uint32
+	// Stalls - тики, когда симуляция стояла, упершись в MaxRollback
+	Stalls uint64
+}
+
+// rollbackFrame - сохраненное состояние перед кадром и ввод, с которым кадр был просчитан
+type rollbackFrame struct {
+	saved   *game.Saved
+	players map[string]uint32
+	used    map[string]direction
+	hash    uint64
+	hashed  bool
+}
+
+// Rollback - вариант Lockstep, который не ждет ввод остальных участников:
+// недостающий ввод предсказывается повтором последнего известного, а при
+// получении настоящего ввода мир откатывается к сохраненному кадру и
+// пересчитывается. Реализует p2p.Handler
+type Rollback struct {
+	self        string
+	members     []string
+	links       Links
+	config      Config
+	maxRollback uint32
+
+	// OnDesync вызывается, если хеш подтвержденного кадра peer не совпал с нашим.
+	// Вызывается под блокировкой, поэтому не должен обращаться к Rollback
+	OnDesync func(frame uint32, peer string)
+
+	mu        sync.Mutex
+	world     *game.World
+	players   map[string]uint32
+	direction direction
+	sent      uint32
+	inputs    map[uint32]map[string]direction
+	// confirmed - последний кадр, для которого известен ввод всех участников
+	confirmed     uint32
+	lastConfirmed map[string]direction
+	frames        map[uint32]*rollbackFrame
+	// rollbackFrom - самый ранний кадр, где предсказание не совпало с настоящим вводом
+	rollbackFrom uint32
+	hashes       *hashes
+	stats        RollbackStats
+}
+
+// NewRollback создает матч с откатом не больше чем на maxRollback кадров;
+// seed и members должны совпадать на всех узлах
+func NewRollback(self string, members []string, seed uint64, links Links, config Config, maxRollback uint32) (*Rollback, error) {
+	members = slices.Clone(members)
+	slices.Sort(members)
+
+	if !slices.Contains(members, self) {
+		return nil, ErrNotMember
+	}
+
+	r := &Rollback{
+		self:          self,
+		members:       members,
+		links:         links,
+		config:        config,
+		maxRollback:   maxRollback,
+		world:         game.NewWorld(seed),
+		players:       make(map[string]uint32, len(members)),
+		sent:          config.InputDelay,
+		inputs:        map[uint32]map[string]direction{},
+		confirmed:     config.InputDelay,
+		lastConfirmed: map[string]direction{},
+		frames:        map[uint32]*rollbackFrame{},
+	}
+	r.hashes = newHashes(self, len(members), config.HashHistory, r.desync)
+
+	for _, member := range members {
+		r.players[member] = r.world.AddPlayer().ID
+	}
+
+	return r, nil
+}
+
+func (r *Rollback) SetDirection(dirX, dirY float32) {
+	dirX, dirY = game.ClampDirection(dirX, dirY)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.direction = direction{x: dirX, y: dirY}
+}
+
+// Run продвигает симуляцию с частотой FrameRate до закрытия stop
+func (r *Rollback) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Second / time.Duration(r.config.FrameRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.Tick()
+		}
+	}
+}
+
+// Tick отправляет свой ввод, при необходимости откатывает и пересчитывает
+// мир и просчитывает следующий кадр. Возвращает false, если предсказание
+// уперлось в MaxRollback и нужно ждать ввод других участников
+func (r *Rollback) Tick() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for target := r.world.Tick + 1 + r.config.InputDelay; r.sent < target; {
+		r.sent++
+		r.input(r.sent, r.self, r.direction)
+		broadcast(r.links, r.self, r.members, envelope.Envelope{
+			Type:    envelope.TypeLockstepInput,
+			From:    r.self,
+			Payload: envelope.InputPayload(r.sent, r.direction.x, r.direction.y),
+		})
+	}
+
+	r.rollback()
+
+	frame := r.world.Tick + 1
+	if frame > r.confirmed+r.maxRollback {
+		r.stats.Stalls++
+		return false
+	}
+
+	r.simulate(frame)
+	r.stats.Frames++
+	r.confirm()
+
+	return true
+}
+
+// Stats возвращает накопленные метрики отката
+func (r *Rollback) Stats() RollbackStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
+
+// Frame - номер последнего просчитанного (возможно, предсказанного) кадра
+func (r *Rollback) Frame() uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.world.Tick
+}
+
+// Confirmed - номер последнего кадра, просчитанного с настоящим вводом всех участников
+func (r *Rollback) Confirmed() uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return min(r.confirmed, r.world.Tick)
+}
+
+func (r *Rollback) Snapshot() snapshot.Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.world.Snapshot()
+}
+
+func (r *Rollback) Player() (game.Player, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.world.Players[r.players[r.self]]
+	if !ok {
+		return game.Player{}, false
+	}
+
+	return *p, true
+}
+
+func (r *Rollback) HandleEnvelope(peer string, e envelope.Envelope) {
+	if !slices.Contains(r.members, peer) || peer == r.self {
+		return
+	}
+
+	switch e.Type {
+	case envelope.TypeLockstepInput:
+		frame, dirX, dirY, err := envelope.ReadInput(e.Payload)
+		if err != nil {
+			slog.Warn("bad rollback input", logging.Node(r.self), logging.Peer(peer), logging.Err(err))
+			return
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if frame <= r.confirmed || frame > r.world.Tick+2+2*r.config.InputDelay+r.maxRollback {
+			return
+		}
+
+		d := direction{x: dirX, y: dirY}
+		r.input(frame, peer, d)
+
+		// Кадр уже просчитан с предсказанием: если оно не сбылось, нужен откат
+		if f, ok := r.frames[frame]; ok && f.used[peer] != d && (r.rollbackFrom == 0 || frame < r.rollbackFrom) {
+			r.rollbackFrom = frame
+		}
+	case envelope.TypeStateHash:
+		frame, hash, err := envelope.ReadHash(e.Payload)
+		if err != nil {
+			slog.Warn("bad state hash", logging.Node(r.self), logging.Peer(peer), logging.Err(err))
+			return
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.hashes.add(frame, peer, hash)
+	}
+}
+
+func (r *Rollback) HandleLinkState(peer string, up bool) {
+	if !up {
+		slog.Warn("lost link to peer, predicting its input", logging.Node(r.self), logging.Peer(peer))
+	}
+}
+
+func (r *Rollback) desync(frame uint32, peer string) {
+	if r.OnDesync != nil {
+		r.OnDesync(frame, peer)
+	}
+}
+
+func (r *Rollback) input(frame uint32, member string, d direction) {
+	if r.inputs[frame] == nil {
+		r.inputs[frame] = make(map[string]direction, len(r.members))
+	}
+
+	r.inputs[frame][member] = d
+}
+
+// predict - ввод участника на кадр: настоящий, если уже пришел, иначе последний известный
+func (r *Rollback) predict(frame uint32, member string) direction {
+	for f := frame; f > r.confirmed; f-- {
+		if d, ok := r.inputs[f][member]; ok {
+			return d
+		}
+	}
+
+	return r.lastConfirmed[member]
+}
+
+// rollback восстанавливает мир перед кадром rollbackFrom и пересчитывает кадры до текущего
+func (r *Rollback) rollback() {
+	from := r.rollbackFrom
+	if from == 0 {
+		return
+	}
+
+	r.rollbackFrom = 0
+
+	f, ok := r.frames[from]
+	if !ok {
+		return
+	}
+
+	to := r.world.Tick
+	depth := to - from + 1
+
+	r.world.Restore(f.saved)
+	r.players = maps.Clone(f.players)
+
+	for frame := from; frame <= to; frame++ {
+		r.simulate(frame)
+	}
+
+	r.stats.Rollbacks++
+	r.stats.ResimulatedFrames += uint64(depth)
+	r.stats.MaxDepth = max(r.stats.MaxDepth, depth)
+}
+
+// simulate сохраняет состояние и просчитывает кадр с настоящим или предсказанным вводом
+func (r *Rollback) simulate(frame uint32) {
+	f := &rollbackFrame{saved: r.world.Save(), players: maps.Clone(r.players)}
+	r.frames[frame] = f
+
+	if frame > r.config.InputDelay {
+		f.used = make(map[string]direction, len(r.members))
+
+		for _, member := range r.members {
+			d := r.predict(frame, member)
+			f.used[member] = d
+			r.world.QueueInput(r.players[member], game.Input{Seq: frame, DirX: d.x, DirY: d.y})
+		}
+	}
+
+	eaten := r.world.Step()
+	for _, member := range r.members {
+		if slices.Contains(eaten, r.players[member]) {
+			r.world.RemovePlayer(r.players[member])
+			r.players[member] = r.world.AddPlayer().ID
+		}
+	}
+
+	if r.config.HashInterval != 0 && frame%r.config.HashInterval == 0 {
+		f.hash, f.hashed = r.world.Hash(), true
+	}
+
+	if frame <= r.confirmed {
+		r.publish(frame, f)
+	}
+}
+
+// confirm продвигает подтвержденный кадр, пока для просчитанных кадров есть ввод всех участников,
+// и выбрасывает состояния, к которым откатываться уже не придется
+func (r *Rollback) confirm() {
+	for r.confirmed < r.world.Tick && len(r.inputs[r.confirmed+1]) == len(r.members) &&
+		(r.rollbackFrom == 0 || r.confirmed+1 < r.rollbackFrom) {
+		r.confirmed++
+
+		maps.Copy(r.lastConfirmed, r.inputs[r.confirmed])
+		delete(r.inputs, r.confirmed)
+
+		if f, ok := r.frames[r.confirmed]; ok {
+			r.publish(r.confirmed, f)
+		}
+	}
+
+	for frame := range r.frames {
+		if frame <= r.confirmed {
+			delete(r.frames, frame)
+		}
+	}
+}
+
+// publish рассылает хеш кадра, когда он просчитан с настоящим вводом
+func (r *Rollback) publish(frame uint32, f *rollbackFrame) {
+	if !f.hashed {
+		return
+	}
+
+	f.hashed = false
+
+	r.hashes.add(frame, r.self, f.hash)
+	broadcast(r.links, r.self, r.members, envelope.Envelope{Type: envelope.TypeStateHash, From: r.self, Payload: envelope.HashPayload(frame, f.hash)})
+	r.hashes.trim(frame)
+}
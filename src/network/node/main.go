@@ -2,13 +2,27 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
-	"net"
+	"log/slog"
+	"strings"
 	"time"
 
-	"github.com/hashicorp/yamux"
 	"github.com/matelq/p2pmp/examples/yamux/common"
+	"github.com/matelq/p2pmp/src/backend/account"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/health"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+	"github.com/matelq/p2pmp/src/network/node/gateway"
+	"github.com/matelq/p2pmp/src/network/node/host"
+	"github.com/matelq/p2pmp/src/network/node/lockstep"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+	"github.com/matelq/p2pmp/src/network/node/state"
+	"github.com/matelq/p2pmp/src/network/regulator/matchmaking"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -19,13 +33,14 @@ type ClientServerImpl struct {
 }
 
 func (server ClientServerImpl) CallFuncOnClient(context context.Context, text *common.Text) (*common.Text, error) {
-	log.Printf("CallFuncOnClient called: %s", text.Data)
+	slog.DebugContext(context, "CallFuncOnClient called", "data", text.Data)
 
 	return &common.Text{Data: fmt.Sprintf("Echo: %s", text.Data)}, nil
 }
 
-func callServer() {
-	conn, err := grpc.NewClient("89.169.34.96:3000", grpc.WithTransportCredentials(insecure.NewCredentials()))
+func callServer(ctx context.Context) {
+	options := append(metrics.DialOptions(), tracing.DialOptions()...)
+	conn, err := grpc.NewClient("89.169.34.96:3000", append(options, grpc.WithTransportCredentials(insecure.NewCredentials()))...)
 
 	if err != nil {
 		panic(err)
@@ -35,41 +50,255 @@ func callServer() {
 
 	for {
 		client := common.NewServerServerClient(conn)
-		res, err := client.CallFuncOnServer(context.Background(), &common.Text{Data: "Message from client to server"})
+		res, err := client.CallFuncOnServer(ctx, &common.Text{Data: "Message from client to server"})
 
 		if err != nil {
 			panic(err)
 		}
 
-		log.Printf("Response: %s", res.Data)
+		slog.DebugContext(ctx, "response", "data", res.Data)
 		time.Sleep(time.Second * 2)
 	}
 }
 
+// playerLink - куда шлюз отправляет действия своего игрока: transmitter
+// или P2P-матч в режиме хоста
+type playerLink interface {
+	Input(in game.Input) error
+	Chat(m chat.Message) error
+}
+
+// newGateway создает шлюз рендера для игрока с профилем profile
+func newGateway(profile account.ProfileResponse, link playerLink) *gateway.Gateway {
+	reconciler := state.NewReconciler(*game.NewPlayer(0, game.FixedFromInt(game.MapWidth/2), game.FixedFromInt(game.MapHeight/2)))
+	gw := gateway.New(reconciler, func(in game.Input) {
+		if err := link.Input(in); err != nil {
+			slog.Warn("cannot send input", "seq", in.Seq, logging.Err(err))
+		}
+	})
+	// Входящие сообщения приходят в PushChat (nodeServer) или от хоста
+	gw.OnChat = link.Chat
+
+	gw.SetProfile(profile.ID, profile.Profile.Name, profile.Profile.Color, profile.Profile.Skin)
+
+	return gw
+}
+
+// startGateway запускает шлюз рендера; interpolator наполняет matchView
+func startGateway(gw *gateway.Gateway, interpolator *state.Interpolator) {
+	go gw.RunInput(time.Second / game.TickRate)
+	go gw.StreamPlayers(interpolator, time.Second/60)
+
+	err := gw.ListenAndServe(gateway.DefaultAddr)
+
+	if err != nil {
+		panic(err)
+	}
+}
+
 func main() {
-	conn, err := net.DialTimeout("tcp", "89.169.34.96:3001", 10*time.Second)
+	accountsURL := flag.String("accounts", "http://89.169.34.96:3003", "URL of the transmitter accounts API.")
+	login := flag.String("login", "", "Account login.")
+	password := flag.String("password", "", "Account password.")
+	transmitterAddr := flag.String("transmitter", "89.169.34.96:3001", "Tunnel address of the transmitter, used when bootstrap is disabled or fails.")
+	regulatorAddr := flag.String("regulator", "", "Address of the regulator that picks the least loaded transmitter. Empty connects to -transmitter directly.")
+	region := flag.String("region", "", "Preferred region of the transmitter.")
+	spectate := flag.String("spectate", "", "ID of a running match to watch as a spectator instead of playing.")
+	metricsAddr := flag.String("metrics-address", "localhost:9101", "Address that the local Prometheus /metrics endpoint is hosted on.")
+	traceExporter := flag.String("trace", tracing.ExporterNone, "Where to export traces: none, stdout or otlp (OTEL_EXPORTER_OTLP_ENDPOINT).")
+	register := flag.Bool("register", false, "Create the account before logging in.")
+	healthInterval := flag.Duration("health-interval", health.DefaultInterval, "How often the transmitter health is checked through the tunnel.")
+	healthFailures := flag.Int("health-failures", health.DefaultFailures, "Failed health checks in a row after which the tunnel is closed.")
+	heartbeats := heartbeat.DefaultConfig()
+	flag.DurationVar(&heartbeats.Interval, "heartbeat-interval", heartbeats.Interval, "How often the tunnel to the transmitter is checked with a heartbeat.")
+	flag.IntVar(&heartbeats.Misses, "heartbeat-misses", heartbeats.Misses, "Missed heartbeats in a row after which the tunnel is closed.")
+	lockstepPeers := flag.String("lockstep", "", "Comma-separated node IDs of the other members of a lockstep P2P match played without a transmitter. Needs -regulator for signaling.")
+	lockstepSeed := flag.Uint64("lockstep-seed", 0, "World seed of the lockstep match, the same on every member.")
+	inputDelay := flag.Uint("input-delay", uint(lockstep.DefaultConfig().InputDelay), "Frames after which lockstep input is applied.")
+	maxRollback := flag.Uint("rollback", 0, "Rollback window of the lockstep match in frames, e.g. 8. Zero waits for every peer input instead of predicting it.")
+	hostMode := flag.Bool("host-mode", false, "Play P2P matches in host mode: the regulator picks one member to run the simulation and elects a new one when it is lost. Needs -regulator.")
+	matchmake := flag.Bool("matchmaking", false, "Find a match through the regulator matchmaking queue instead of joining the first free match. Needs -regulator.")
+	skill := flag.Float64("skill", 1000, "Rating sent to matchmaking; players are grouped with others of close rating.")
+	signalInterval := flag.Duration("signal-interval", 250*time.Millisecond, "How often P2P signals are polled from the regulator.")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error.")
+	logFormat := flag.String("log-format", logging.FormatText, "Log output format: text or json.")
+	flag.Parse()
+
+	if err := logging.Setup(*logLevel, *logFormat); err != nil {
+		panic(err)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), "node", *traceExporter)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer shutdown(context.Background()) //nolint:errcheck
+
+	accounts := newAccountClient(*accountsURL)
+
+	if *register {
+		if err := accounts.register(*login, *password); err != nil {
+			panic(err)
+		}
+	}
+
+	session, err := accounts.login(*login, *password)
 
 	if err != nil {
 		panic(err)
 	}
 
-	yamuxSession, err := yamux.Server(conn, yamux.DefaultConfig())
+	profile, err := accounts.profile(session.Token)
 
 	if err != nil {
 		panic(err)
 	}
 
-	grpcServer := grpc.NewServer()
-	clientServerImpl := &ClientServerImpl{}
-	common.RegisterClientServerServer(grpcServer, clientServerImpl)
+	// pick выбирает transmitter: через регулятор, если он задан, иначе -transmitter
+	pick := func() string {
+		if *regulatorAddr == "" {
+			return *transmitterAddr
+		}
+
+		picked, err := bootstrap(*regulatorAddr, *region)
+		if err != nil {
+			slog.Warn("cannot bootstrap, connecting to default transmitter", "address", *transmitterAddr, logging.Err(err))
+			return *transmitterAddr
+		}
+
+		return picked
+	}
+
+	// assigned - матч от матчмейкинга регулятора, пустой без -matchmaking
+	var assigned matchmaking.Assignment
+
+	if *matchmake {
+		if *regulatorAddr == "" {
+			panic("-matchmaking needs -regulator")
+		}
+
+		creds, err := p2p.Authenticate(*regulatorAddr, session.Token)
+
+		if err != nil {
+			panic(err)
+		}
+
+		assigned, err = findMatch(*regulatorAddr, creds, *skill, []string{pick()}, *hostMode, time.Second)
+
+		if err != nil {
+			panic(err)
+		}
+
+		slog.Info("match found", logging.Room(assigned.MatchID), "transport", assigned.Transport, "relay", assigned.Relay, "members", len(assigned.Members))
+	}
+
+	if *lockstepPeers != "" {
+		if *regulatorAddr == "" {
+			panic("-lockstep needs -regulator for signaling")
+		}
+
+		config := lockstep.DefaultConfig()
+		config.InputDelay = uint32(*inputDelay)
+
+		match := lockstepMatch{
+			regulator:      *regulatorAddr,
+			members:        strings.Split(*lockstepPeers, ","),
+			seed:           *lockstepSeed,
+			config:         config,
+			maxRollback:    uint32(*maxRollback),
+			signalInterval: *signalInterval,
+			heartbeats:     heartbeats,
+		}
+
+		go startMetrics(*metricsAddr)
+
+		if err := runLockstep(profile, session.Token, match); err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	// Матч через relay узел играет через transmitter и в режиме хоста
+	if *hostMode && (!*matchmake || assigned.Transport == matchmaking.TransportP2P) {
+		if *regulatorAddr == "" {
+			panic("-host-mode needs -regulator for signaling")
+		}
+
+		match := hostMatch{
+			regulator:      *regulatorAddr,
+			signalInterval: *signalInterval,
+			pollInterval:   time.Second,
+			uploadInterval: host.DefaultUploadInterval,
+			heartbeats:     heartbeats,
+		}
+
+		go startMetrics(*metricsAddr)
 
-	log.Println("launching gRPC server over TCP connection...")
+		if err := runHost(profile, session.Token, match); err != nil {
+			panic(err)
+		}
 
-	go callServer()
+		return
+	}
 
-	err = grpcServer.Serve(yamuxSession)
+	config := tunnelConfig{healthInterval: *healthInterval, healthFailures: *healthFailures, heartbeats: heartbeats}
+	link := &transmitterLink{}
+	gw := newGateway(profile, link)
+	gw.OnFollow = link.Follow
+	view := newMatchView(gw)
+	node := newNodeServer(view)
+
+	address := assigned.Relay
+	if address == "" {
+		address = pick()
+	}
+
+	current, err := openTunnel(address, session.Token, node, config)
 
 	if err != nil {
 		panic(err)
 	}
+
+	link.set(current.transmitter)
+	slog.InfoContext(current.ctx, "connected to transmitter", "name", profile.Profile.Name)
+
+	go callServer(current.ctx)
+	go startGateway(gw, view.interpolator)
+
+	if *spectate != "" {
+		go watchMatch(current.ctx, current.transmitter, gw, *spectate)
+	} else if assigned.MatchID != "" {
+		go joinMatch(current.ctx, current.transmitter, assigned.MatchID)
+	}
+	go startMetrics(*metricsAddr)
+
+	for {
+		select {
+		case err := <-current.done:
+			panic(err)
+		case address := <-node.migrations:
+			if address == "" {
+				address = pick()
+			}
+
+			// Новый туннель открывается до закрытия старого, чтобы узел не остался без transmitter
+			next, err := openTunnel(address, session.Token, node, config)
+			if err != nil {
+				slog.ErrorContext(current.ctx, "cannot migrate, staying on the current transmitter", "address", address, logging.Err(err))
+				continue
+			}
+
+			// Новый transmitter не знает baseline старого и сажает узел в новый матч
+			view.decoder.Reset()
+			link.set(next.transmitter)
+			gw.SetSpectator(false)
+			current.Close()
+			current = next
+
+			slog.InfoContext(current.ctx, "migrated to transmitter", "address", address)
+		}
+	}
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+	"github.com/matelq/p2pmp/src/network/regulator/matchmaking"
+)
+
+// ticket - заявка в очередь матчмейкинга регулятора (/matchmaking/enqueue)
+type ticket struct {
+	NodeID     string           `json:"nodeId"`
+	Skill      float64          `json:"skill"`
+	RTTMillis  map[string]int64 `json:"rttMillis"`
+	P2PCapable bool             `json:"p2pCapable"`
+}
+
+// measureRTT оценивает задержку до relay по времени TCP-подключения к нему
+func measureRTT(address string) (time.Duration, error) {
+	start := time.Now()
+
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return 0, err
+	}
+
+	conn.Close()
+
+	return time.Since(start), nil
+}
+
+// findMatch ставит узел в очередь матчмейкинга с RTT до relays и опрашивает
+// регулятор раз в interval, пока тот не подберет матч
+func findMatch(regulator string, creds p2p.Credentials, skill float64, relays []string, p2pCapable bool, interval time.Duration) (matchmaking.Assignment, error) {
+	t := ticket{NodeID: creds.NodeID, Skill: skill, RTTMillis: map[string]int64{}, P2PCapable: p2pCapable}
+
+	for _, relay := range relays {
+		rtt, err := measureRTT(relay)
+		if err != nil {
+			slog.Warn("cannot measure relay RTT", "relay", relay, logging.Err(err))
+			continue
+		}
+
+		t.RTTMillis[relay] = max(rtt.Milliseconds(), 1)
+	}
+
+	body, err := json.Marshal(t)
+	if err != nil {
+		return matchmaking.Assignment{}, err
+	}
+
+	resp, err := regulatorRequest(http.MethodPost, regulator, "/matchmaking/enqueue", creds.Credential, nil, body)
+	if err != nil {
+		return matchmaking.Assignment{}, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return matchmaking.Assignment{}, fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	slog.Info("waiting for a match", "skill", skill, "relays", len(t.RTTMillis), "p2p_capable", p2pCapable)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a, ok, err := pollMatch(regulator, creds)
+		if err != nil {
+			slog.Warn("cannot poll match assignment", logging.Err(err))
+			continue
+		}
+
+		if ok {
+			return a, nil
+		}
+	}
+
+	return matchmaking.Assignment{}, nil
+}
+
+// pollMatch забирает назначение матча, если матчмейкинг его уже подобрал
+func pollMatch(regulator string, creds p2p.Credentials) (matchmaking.Assignment, bool, error) {
+	resp, err := regulatorRequest(http.MethodGet, regulator, "/matchmaking/assignment", creds.Credential, url.Values{"node": {creds.NodeID}}, nil)
+	if err != nil {
+		return matchmaking.Assignment{}, false, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return matchmaking.Assignment{}, false, nil
+	case http.StatusOK:
+	default:
+		return matchmaking.Assignment{}, false, fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	var a matchmaking.Assignment
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return matchmaking.Assignment{}, false, err
+	}
+
+	return a, true, nil
+}
+
+func regulatorRequest(method, regulator, path, credential string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s?%s", regulator, path, query.Encode()), bytes.NewReader(body)) // nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+credential)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return client.Do(req)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/yamux"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// tunnelSession - текущий туннель до transmitter, при миграции он меняется
+	tunnelSession     atomic.Pointer[yamux.Session]
+	tunnelMetricsOnce sync.Once
+)
+
+// watchTunnel публикует состояние туннеля до transmitter: он считается
+// relay-соединением узла, пока yamux-сессия не закрыта
+func watchTunnel(session *yamux.Session) {
+	tunnelSession.Store(session)
+
+	tunnelMetricsOnce.Do(func() {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "node",
+			Name:      "yamux_streams",
+			Help:      "Active yamux streams in the tunnel to the transmitter.",
+		}, func() float64 { return float64(tunnelSession.Load().NumStreams()) })
+	})
+
+	links := metrics.Links.WithLabelValues(metrics.TransportRelay)
+	links.Set(1)
+
+	go func() {
+		<-session.CloseChan()
+
+		// Старый туннель закрывается уже после того, как открыт новый
+		if tunnelSession.Load() == session {
+			links.Set(0)
+		}
+	}()
+}
+
+func startMetrics(addr string) {
+	if err := metrics.Serve(addr); err != nil {
+		panic(err)
+	}
+}
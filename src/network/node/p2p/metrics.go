@@ -0,0 +1,23 @@
+package p2p
+
+import (
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	p2pBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "p2p",
+		Name:      "bytes_total",
+		Help:      "Bytes sent and received over P2P data channels.",
+	}, []string{"direction"})
+
+	p2pMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "p2p",
+		Name:      "messages_total",
+		Help:      "Envelopes sent and received over P2P data channels by type.",
+	}, []string{"direction", "type"})
+)
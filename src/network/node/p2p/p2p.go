@@ -0,0 +1,540 @@
+// P2P-соединения между узлами поверх WebRTC data channel (pion).
+// Обмен SDP и ICE-кандидатами идет через регулятор (см. Signaler),
+// по установленному каналу передаются конверты envelope.Envelope
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+	"github.com/pion/webrtc/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrNoLink     = errors.New("p2p: no link to peer")
+	ErrLinkClosed = errors.New("p2p: data channel is not open")
+	ErrLinkExists = errors.New("p2p: link to peer is already connected")
+	// ErrTooManyEarly - кандидатов без offer больше, чем хранит Manager
+	ErrTooManyEarly = errors.New("p2p: too many candidates before offer")
+)
+
+const dataChannelLabel = "data"
+
+// Пределы early: кандидаты от узлов, которые так и не прислали offer, не
+// должны копиться без ограничения
+const (
+	maxEarlyPeers      = 16
+	maxEarlyCandidates = 32
+)
+
+// Виды сигнальных сообщений
+const (
+	SignalOffer     = "offer"
+	SignalAnswer    = "answer"
+	SignalCandidate = "candidate"
+)
+
+type Signal struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	Data string `json:"data"`
+	// Trace - контекст трассы установки соединения (tracing.Inject)
+	Trace string `json:"trace,omitempty"`
+}
+
+// Signaler доставляет сигнальные сообщения между узлами
+type Signaler interface {
+	Send(s Signal) error
+	Signals() <-chan Signal
+}
+
+type Handler interface {
+	// HandleEnvelope вызывается для каждого сообщения из data channel
+	HandleEnvelope(peer string, e envelope.Envelope)
+	// HandleLinkState вызывается при открытии (up == true) и потере соединения
+	HandleLinkState(peer string, up bool)
+}
+
+type Link struct {
+	peer string
+	pc   *webrtc.PeerConnection
+
+	mu                sync.Mutex
+	dc                *webrtc.DataChannel
+	pendingCandidates []webrtc.ICECandidateInit
+	// open - канал был открыт и учтен в metrics.Links
+	open bool
+	// heartbeat работает, пока канал открыт; stop останавливает его при закрытии
+	heartbeat *heartbeat.Monitor
+	stop      chan struct{}
+}
+
+type Manager struct {
+	self     string
+	signaler Signaler
+	handler  Handler
+	config   webrtc.Configuration
+	log      *slog.Logger
+	// heartbeats - пульс соединений, см. SetHeartbeat
+	heartbeats heartbeat.Config
+
+	mu    sync.Mutex
+	links map[string]*Link
+	// early - кандидаты, пришедшие раньше offer (сигналы от одного узла могут обгонять друг друга)
+	early map[string][]webrtc.ICECandidateInit
+}
+
+func DefaultConfiguration() webrtc.Configuration {
+	return webrtc.Configuration{
+		ICEServers: []webrtc.ICEServer{
+			{
+				URLs: []string{"stun:stun.l.google.com:19302"},
+			},
+		},
+	}
+}
+
+func NewManager(self string, signaler Signaler, handler Handler, config webrtc.Configuration) *Manager {
+	return &Manager{
+		self:       self,
+		signaler:   signaler,
+		handler:    handler,
+		config:     config,
+		log:        slog.With(logging.Node(self), logging.Transport(metrics.TransportP2P)),
+		heartbeats: heartbeat.DefaultConfig(),
+		links:      map[string]*Link{},
+		early:      map[string][]webrtc.ICECandidateInit{},
+	}
+}
+
+// SetHeartbeat задает пульс для соединений, открытых после вызова
+func (m *Manager) SetHeartbeat(config heartbeat.Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heartbeats = config
+}
+
+// Stats возвращает RTT и джиттер соединения с peer по пульсу
+func (m *Manager) Stats(peer string) (heartbeat.Stats, bool) {
+	link, err := m.link(peer)
+	if err != nil {
+		return heartbeat.Stats{}, false
+	}
+
+	link.mu.Lock()
+	defer link.mu.Unlock()
+
+	if link.heartbeat == nil {
+		return heartbeat.Stats{}, false
+	}
+
+	return link.heartbeat.Stats(), true
+}
+
+// Run обрабатывает входящие сигнальные сообщения, блокируется до закрытия канала сигналов
+func (m *Manager) Run() {
+	for s := range m.signaler.Signals() {
+		if err := m.handleSignal(s); err != nil {
+			m.log.Warn("cannot handle signal", logging.Peer(s.From), "kind", s.Kind, logging.Err(err))
+		}
+	}
+}
+
+// Connect инициирует соединение с peer: создает data channel и отправляет offer
+func (m *Manager) Connect(peer string) error {
+	ctx, span := tracing.Tracer().Start(context.Background(), "p2p.connect", trace.WithAttributes(attribute.String("peer", peer)))
+	defer span.End()
+
+	link, err := m.newLink(peer)
+	if err != nil {
+		return err
+	}
+
+	dc, err := link.pc.CreateDataChannel(dataChannelLabel, nil)
+	if err != nil {
+		return err
+	}
+
+	m.attach(link, dc)
+
+	offer, err := link.pc.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+
+	if err = link.pc.SetLocalDescription(offer); err != nil {
+		return err
+	}
+
+	return m.sendDescription(ctx, peer, SignalOffer, offer)
+}
+
+func (m *Manager) Send(peer string, e envelope.Envelope) error {
+	return m.SendContext(context.Background(), peer, e)
+}
+
+// SendContext отправляет конверт с контекстом трассы из ctx, если в ctx есть спан:
+// получатель продолжит трассу при обработке конверта
+func (m *Manager) SendContext(ctx context.Context, peer string, e envelope.Envelope) error {
+	m.mu.Lock()
+	link, ok := m.links[peer]
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrNoLink
+	}
+
+	link.mu.Lock()
+	dc := link.dc
+	link.mu.Unlock()
+
+	if dc == nil || dc.ReadyState() != webrtc.DataChannelStateOpen {
+		return ErrLinkClosed
+	}
+
+	e.From = m.self
+
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		var span trace.Span
+		ctx, span = tracing.Tracer().Start(ctx, "p2p.send "+e.Type.String(), trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(attribute.String("peer", peer)))
+		defer span.End()
+
+		e.Trace = tracing.Inject(ctx)
+	}
+
+	data := e.Marshal()
+
+	if err := dc.Send(data); err != nil {
+		return err
+	}
+
+	p2pBytes.WithLabelValues("sent").Add(float64(len(data)))
+	p2pMessages.WithLabelValues("sent", e.Type.String()).Inc()
+
+	return nil
+}
+
+// Peers возвращает узлы, с которыми есть соединение
+func (m *Manager) Peers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	peers := make([]string, 0, len(m.links))
+	for peer := range m.links {
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+func (m *Manager) Close(peer string) {
+	m.mu.Lock()
+	link, ok := m.links[peer]
+	delete(m.links, peer)
+	m.mu.Unlock()
+
+	if ok {
+		if err := link.pc.Close(); err != nil {
+			m.log.Warn("cannot close link", logging.Peer(peer), logging.Err(err))
+		}
+	}
+}
+
+func (m *Manager) CloseAll() {
+	for _, peer := range m.Peers() {
+		m.Close(peer)
+	}
+}
+
+func (m *Manager) newLink(peer string) (*Link, error) {
+	pc, err := webrtc.NewPeerConnection(m.config)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &Link{peer: peer, pc: pc}
+
+	m.mu.Lock()
+	if old, ok := m.links[peer]; ok {
+		old.pc.Close()
+	}
+	m.links[peer] = link
+	link.pendingCandidates = m.early[peer]
+	delete(m.early, peer)
+	m.mu.Unlock()
+
+	pc.OnICECandidate(func(c *webrtc.ICECandidate) {
+		if c == nil {
+			return
+		}
+
+		if err := m.signaler.Send(Signal{From: m.self, To: peer, Kind: SignalCandidate, Data: c.ToJSON().Candidate}); err != nil {
+			m.log.Warn("cannot signal candidate", logging.Peer(peer), logging.Err(err))
+		}
+	})
+
+	pc.OnConnectionStateChange(func(s webrtc.PeerConnectionState) {
+		m.log.Info("link state changed", logging.Peer(peer), "state", s.String())
+
+		if s == webrtc.PeerConnectionStateFailed || s == webrtc.PeerConnectionStateClosed {
+			m.mu.Lock()
+			if m.links[peer] == link {
+				delete(m.links, peer)
+			}
+			m.mu.Unlock()
+
+			link.mu.Lock()
+			if link.open {
+				link.open = false
+				metrics.Links.WithLabelValues(metrics.TransportP2P).Dec()
+				close(link.stop)
+			}
+			link.mu.Unlock()
+
+			m.handler.HandleLinkState(peer, false)
+		}
+	})
+
+	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+		m.attach(link, dc)
+	})
+
+	return link, nil
+}
+
+func (m *Manager) attach(link *Link, dc *webrtc.DataChannel) {
+	link.mu.Lock()
+	link.dc = dc
+	link.mu.Unlock()
+
+	dc.OnOpen(func() {
+		m.mu.Lock()
+		config := m.heartbeats
+		m.mu.Unlock()
+
+		link.mu.Lock()
+		if !link.open {
+			link.open = true
+			metrics.Links.WithLabelValues(metrics.TransportP2P).Inc()
+
+			link.stop = make(chan struct{})
+			link.heartbeat = heartbeat.New(config, func(seq uint32, sent time.Time) error {
+				return m.Send(link.peer, envelope.Envelope{Type: envelope.TypePing, Payload: envelope.HeartbeatPayload(seq, sent)})
+			}, func() {
+				m.log.Warn("peer missed heartbeats, closing link", logging.Peer(link.peer), "misses", config.Misses)
+				m.Close(link.peer)
+			})
+			go link.heartbeat.Run(link.stop)
+		}
+		link.mu.Unlock()
+
+		m.handler.HandleLinkState(link.peer, true)
+	})
+
+	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		p2pBytes.WithLabelValues("received").Add(float64(len(msg.Data)))
+
+		e, err := envelope.Unmarshal(msg.Data)
+		if err != nil {
+			m.log.Warn("bad message", logging.Peer(link.peer), logging.Err(err))
+			return
+		}
+
+		p2pMessages.WithLabelValues("received", e.Type.String()).Inc()
+
+		// Пульс обрабатывается здесь и не доходит до обработчика
+		switch e.Type {
+		case envelope.TypePing:
+			if err := m.Send(link.peer, envelope.Envelope{Type: envelope.TypePong, Payload: e.Payload}); err != nil {
+				m.log.Debug("cannot answer heartbeat", logging.Peer(link.peer), logging.Err(err))
+			}
+
+			return
+		case envelope.TypePong:
+			seq, sent, err := envelope.ReadHeartbeat(e.Payload)
+			if err != nil {
+				m.log.Warn("bad heartbeat", logging.Peer(link.peer), logging.Err(err))
+				return
+			}
+
+			link.mu.Lock()
+			monitor := link.heartbeat
+			link.mu.Unlock()
+
+			if monitor != nil {
+				monitor.Pong(seq, sent)
+			}
+
+			return
+		}
+
+		if e.Trace != "" {
+			_, span := tracing.Tracer().Start(tracing.Extract(context.Background(), e.Trace), "p2p.receive "+e.Type.String(),
+				trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attribute.String("peer", link.peer)))
+			defer span.End()
+		}
+
+		m.handler.HandleEnvelope(link.peer, e)
+	})
+}
+
+func (m *Manager) handleSignal(s Signal) error {
+	switch s.Kind {
+	case SignalOffer:
+		ctx, span := tracing.Tracer().Start(tracing.Extract(context.Background(), s.Trace), "p2p.answer",
+			trace.WithAttributes(attribute.String("peer", s.From)))
+		defer span.End()
+
+		return m.handleOffer(ctx, s)
+	case SignalAnswer:
+		_, span := tracing.Tracer().Start(tracing.Extract(context.Background(), s.Trace), "p2p.accept",
+			trace.WithAttributes(attribute.String("peer", s.From)))
+		defer span.End()
+
+		link, err := m.link(s.From)
+		if err != nil {
+			return err
+		}
+
+		var answer webrtc.SessionDescription
+		if err := json.Unmarshal([]byte(s.Data), &answer); err != nil {
+			return err
+		}
+
+		if err := link.pc.SetRemoteDescription(answer); err != nil {
+			return err
+		}
+
+		return link.flushCandidates()
+	case SignalCandidate:
+		candidate := webrtc.ICECandidateInit{Candidate: s.Data}
+
+		link, err := m.link(s.From)
+		if errors.Is(err, ErrNoLink) {
+			return m.keepEarly(s.From, candidate)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return link.addCandidate(candidate)
+	default:
+		return fmt.Errorf("unknown signal %q", s.Kind)
+	}
+}
+
+// keepEarly откладывает кандидата до offer от peer
+func (m *Manager) keepEarly(peer string, candidate webrtc.ICECandidateInit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending, ok := m.early[peer]
+	if (!ok && len(m.early) >= maxEarlyPeers) || len(pending) >= maxEarlyCandidates {
+		return fmt.Errorf("%s: %w", peer, ErrTooManyEarly)
+	}
+
+	m.early[peer] = append(pending, candidate)
+
+	return nil
+}
+
+func (m *Manager) handleOffer(ctx context.Context, s Signal) error {
+	var offer webrtc.SessionDescription
+	if err := json.Unmarshal([]byte(s.Data), &offer); err != nil {
+		return err
+	}
+
+	// Offer не заменяет рабочее соединение: иначе сигнал от имени peer разорвал бы его
+	if link, err := m.link(s.From); err == nil && link.pc.ConnectionState() == webrtc.PeerConnectionStateConnected {
+		return fmt.Errorf("%s: %w", s.From, ErrLinkExists)
+	}
+
+	link, err := m.newLink(s.From)
+	if err != nil {
+		return err
+	}
+
+	if err := link.pc.SetRemoteDescription(offer); err != nil {
+		return err
+	}
+
+	answer, err := link.pc.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+
+	if err := link.pc.SetLocalDescription(answer); err != nil {
+		return err
+	}
+
+	if err := link.flushCandidates(); err != nil {
+		return err
+	}
+
+	return m.sendDescription(ctx, s.From, SignalAnswer, answer)
+}
+
+func (m *Manager) link(peer string) (*Link, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	link, ok := m.links[peer]
+	if !ok {
+		return nil, ErrNoLink
+	}
+
+	return link, nil
+}
+
+func (m *Manager) sendDescription(ctx context.Context, peer, kind string, desc webrtc.SessionDescription) error {
+	payload, err := json.Marshal(desc)
+	if err != nil {
+		return err
+	}
+
+	return m.signaler.Send(Signal{From: m.self, To: peer, Kind: kind, Data: string(payload), Trace: tracing.Inject(ctx)})
+}
+
+// addCandidate добавляет кандидата или откладывает его до получения удаленного SDP
+func (l *Link) addCandidate(c webrtc.ICECandidateInit) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pc.RemoteDescription() == nil {
+		l.pendingCandidates = append(l.pendingCandidates, c)
+		return nil
+	}
+
+	return l.pc.AddICECandidate(c)
+}
+
+func (l *Link) flushCandidates() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, c := range l.pendingCandidates {
+		if err := l.pc.AddICECandidate(c); err != nil {
+			return err
+		}
+	}
+
+	l.pendingCandidates = nil
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+)
+
+// Credentials - учетные данные узла на регуляторе из POST /auth: ID узла
+// (это ID аккаунта) и секрет для заголовка "Authorization: Bearer <credential>"
+type Credentials struct {
+	NodeID     string    `json:"nodeId"`
+	Credential string    `json:"credential"`
+	Expires    time.Time `json:"expires"`
+}
+
+// Authenticate получает учетные данные на регуляторе по токену сессии аккаунта
+func Authenticate(regulatorAddr, token string) (Credentials, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s/auth", regulatorAddr), nil) //nolint:noctx
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	var creds Credentials
+	if err := json.NewDecoder(resp.Body).Decode(&creds); err != nil {
+		return Credentials{}, err
+	}
+
+	return creds, nil
+}
+
+// HTTPSignaler обменивается сигналами через почтовый ящик на регуляторе:
+// POST /signal/send кладет сообщение адресату, GET /signal/poll забирает свои
+type HTTPSignaler struct {
+	regulator string
+	self      string
+	// credential - учетные данные self на регуляторе, см. Authenticate
+	credential string
+	interval   time.Duration
+	signals    chan Signal
+	stop       chan struct{}
+	client     *http.Client
+}
+
+func NewHTTPSignaler(regulatorAddr string, creds Credentials, interval time.Duration) *HTTPSignaler {
+	s := &HTTPSignaler{
+		regulator:  regulatorAddr,
+		self:       creds.NodeID,
+		credential: creds.Credential,
+		interval:   interval,
+		signals:    make(chan Signal, 64),
+		stop:       make(chan struct{}),
+		client:     &http.Client{Transport: tracing.Transport()},
+	}
+
+	go s.poll()
+
+	return s
+}
+
+func (s *HTTPSignaler) Send(signal Signal) error {
+	payload, err := json.Marshal(signal)
+	if err != nil {
+		return err
+	}
+
+	// Запрос продолжает трассу сигнала, чтобы был виден переход через регулятор
+	ctx := tracing.Extract(context.Background(), signal.Trace)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/signal/send", s.regulator), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.credential)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *HTTPSignaler) Signals() <-chan Signal {
+	return s.signals
+}
+
+func (s *HTTPSignaler) Close() {
+	close(s.stop)
+}
+
+func (s *HTTPSignaler) poll() {
+	defer close(s.signals)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		signals, err := s.fetch()
+		if err != nil {
+			slog.Warn("cannot poll signals", logging.Node(s.self), logging.Err(err))
+			continue
+		}
+
+		for _, signal := range signals {
+			s.signals <- signal
+		}
+	}
+}
+
+func (s *HTTPSignaler) fetch() ([]Signal, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/signal/poll?node=%s", s.regulator, url.QueryEscape(s.self)), nil) // nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.credential)
+
+	// Опросы идут постоянно, поэтому не трассируются
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("regulator responded %s", resp.Status)
+	}
+
+	var signals []Signal
+	if err := json.NewDecoder(resp.Body).Decode(&signals); err != nil {
+		return nil, err
+	}
+
+	return signals, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	nodepb "github.com/matelq/p2pmp/src/network/common/node"
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+	"github.com/matelq/p2pmp/src/network/node/gateway"
+	"github.com/matelq/p2pmp/src/network/node/state"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// matchView передает рендеру то, что узел получает о своем матче: снимки,
+// чат и прочие сообщения - от transmitter или от хоста P2P-матча
+type matchView struct {
+	decoder      *state.Decoder
+	interpolator *state.Interpolator
+	gateway      *gateway.Gateway
+}
+
+func newMatchView(gw *gateway.Gateway) *matchView {
+	return &matchView{
+		decoder:      state.NewDecoder(state.DefaultHistorySize),
+		interpolator: state.NewInterpolator(state.DefaultInterpolationDelay, state.DefaultMaxExtrapolation),
+		gateway:      gw,
+	}
+}
+
+// snapshot декодирует кадр и возвращает последний декодированный тик:
+// по нему бэкенд выбирает baseline следующей дельты
+func (v *matchView) snapshot(ctx context.Context, data []byte, playerID, lastInput uint32) (uint32, error) {
+	world, err := v.decoder.Decode(data)
+
+	switch {
+	case errors.Is(err, snapshot.ErrBaselineMissing), errors.Is(err, state.ErrStale):
+		// Кадр пропускается, подтверждение остается прежним: бэкенд сам перейдет на полный снимок
+		slog.DebugContext(ctx, "skipping snapshot", logging.Err(err))
+	case err != nil:
+		return 0, err
+	default:
+		// Свой игрок рисуется по предсказанию, в буфер интерполяции идут только чужие
+		v.interpolator.Push(time.Now(), world, playerID)
+		v.reconcile(world, playerID, lastInput)
+	}
+
+	tick, _ := v.decoder.Ack()
+
+	return tick, nil
+}
+
+// reconcile сверяет предсказание своего игрока с его состоянием в снимке
+func (v *matchView) reconcile(world snapshot.Snapshot, playerID, lastInput uint32) {
+	if playerID == 0 {
+		return
+	}
+
+	for _, e := range world.Entities {
+		if e.ID != playerID || e.Kind != snapshot.KindPlayer {
+			continue
+		}
+
+		v.gateway.Reconcile(game.Player{
+			ID:   e.ID,
+			X:    game.FixedFromFloat(e.X),
+			Y:    game.FixedFromFloat(e.Y),
+			Size: game.FixedFromFloat(e.Size),
+		}, lastInput)
+
+		return
+	}
+}
+
+// envelope передает рендеру прочие сообщения матча
+func (v *matchView) envelope(t envelope.Type, payload []byte) error {
+	switch t {
+	case envelope.TypeLeaderboard:
+		return v.gateway.Leaderboard(payload)
+	case envelope.TypeChat:
+		m, err := chat.Unmarshal(payload)
+		if err != nil {
+			return err
+		}
+
+		v.gateway.Chat(m)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", errUnhandled, t)
+	}
+}
+
+var errUnhandled = errors.New("message is not handled by the node")
+
+// nodeServer - сервис node.proto, его вызывает transmitter через туннель
+type nodeServer struct {
+	nodepb.UnimplementedNodeServer
+	view *matchView
+	// migrations - адреса transmitter для перехода, их обрабатывает main
+	migrations chan string
+}
+
+func newNodeServer(view *matchView) *nodeServer {
+	return &nodeServer{view: view, migrations: make(chan string, 1)}
+}
+
+func (s *nodeServer) CallFuncOnNode(ctx context.Context, text *contracts.Text) (*contracts.Text, error) {
+	slog.DebugContext(ctx, "CallFuncOnNode called", "data", text.Data)
+
+	return &contracts.Text{Data: fmt.Sprintf("Echo: %s", text.Data)}, nil
+}
+
+func (s *nodeServer) PushSnapshot(ctx context.Context, frame *contracts.WorldSnapshot) (*contracts.SnapshotAck, error) {
+	tick, err := s.view.snapshot(ctx, frame.Data, frame.PlayerId, frame.LastProcessedInput)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &contracts.SnapshotAck{Tick: tick}, nil
+}
+
+// PushChat показывает входящее сообщение чата
+func (s *nodeServer) PushChat(_ context.Context, m *contracts.ChatMessage) (*contracts.Text, error) {
+	message, err := chat.FromContract(m)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.view.gateway.Chat(message)
+
+	return &contracts.Text{}, nil
+}
+
+// PushEnvelope передает рендеру прочие сообщения матча
+func (s *nodeServer) PushEnvelope(_ context.Context, e *contracts.Envelope) (*contracts.Text, error) {
+	err := s.view.envelope(envelope.Type(e.Type), e.Payload)
+
+	switch {
+	case errors.Is(err, errUnhandled):
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &contracts.Text{}, nil
+}
+
+// Migrate отвечает сразу, а переподключается main, пока старый туннель еще открыт.
+// Пустой адрес - узел выбирает transmitter сам
+func (s *nodeServer) Migrate(ctx context.Context, req *contracts.MigrateRequest) (*contracts.Text, error) {
+	slog.InfoContext(ctx, "transmitter asked to migrate", "address", req.Address, "deadline", time.UnixMilli(req.DeadlineUnixMillis))
+
+	select {
+	case s.migrations <- req.Address:
+	default:
+		// Переход уже запрошен
+	}
+
+	return &contracts.Text{}, nil
+}
@@ -0,0 +1,102 @@
+// Состояние мира на стороне узла: декодирование снимков от бэкенда
+package state
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+// DefaultHistorySize должен быть не меньше истории кодировщика на бэкенде,
+// иначе узел может выкинуть baseline, на который бэкенд еще ссылается
+const DefaultHistorySize = 32
+
+var ErrStale = errors.New("state: snapshot is older than the latest one")
+
+// Decoder хранит последние декодированные снимки как baseline для дельт
+// и отдает тик, который нужно подтвердить бэкенду
+type Decoder struct {
+	mu      sync.Mutex
+	history []snapshot.State
+	next    int
+	latest  *snapshot.State
+}
+
+func NewDecoder(historySize int) *Decoder {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+
+	return &Decoder{history: make([]snapshot.State, 0, historySize)}
+}
+
+// Decode декодирует кадр от бэкенда. Если baseline не найден (например, после
+// переподключения), возвращается snapshot.ErrBaselineMissing - подтверждать
+// такой кадр не нужно, бэкенд сам перейдет на полный снимок
+func (d *Decoder) Decode(data []byte) (snapshot.Snapshot, error) {
+	header, err := snapshot.ReadHeader(data)
+	if err != nil {
+		return snapshot.Snapshot{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.latest != nil && int32(header.Tick-d.latest.Tick) <= 0 {
+		return snapshot.Snapshot{}, ErrStale
+	}
+
+	state, err := snapshot.Decode(d.find(header.BaseTick), data)
+	if err != nil {
+		return snapshot.Snapshot{}, err
+	}
+
+	d.store(state)
+
+	return state.Snapshot(), nil
+}
+
+// Ack возвращает тик последнего декодированного снимка
+func (d *Decoder) Ack() (uint32, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.latest == nil {
+		return 0, false
+	}
+
+	return d.latest.Tick, true
+}
+
+// Reset сбрасывает историю, например при переподключении к другому серверу
+func (d *Decoder) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.history = d.history[:0]
+	d.next = 0
+	d.latest = nil
+}
+
+func (d *Decoder) store(state snapshot.State) {
+	if len(d.history) < cap(d.history) {
+		d.history = append(d.history, state)
+		d.latest = &d.history[len(d.history)-1]
+		return
+	}
+
+	d.history[d.next] = state
+	d.latest = &d.history[d.next]
+	d.next = (d.next + 1) % len(d.history)
+}
+
+func (d *Decoder) find(tick uint32) *snapshot.State {
+	for i := range d.history {
+		if d.history[i].Tick == tick {
+			return &d.history[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package state
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+// Значения по умолчанию: задержка рендера в два интервала рассылки при 20 тиках в секунду
+// и короткая экстраполяция, чтобы пережить одиночную потерю пакета
+const (
+	DefaultInterpolationDelay = 100 * time.Millisecond
+	DefaultMaxExtrapolation   = 250 * time.Millisecond
+)
+
+// maxSamples - сколько последних состояний храним на сущность
+const maxSamples = 32
+
+type sample struct {
+	at   time.Time
+	x    float32
+	y    float32
+	size float32
+}
+
+type track struct {
+	kind    snapshot.Kind
+	samples []sample
+	// prev - последнее выкинутое из буфера состояние, по нему считается скорость для экстраполяции
+	prev    sample
+	hasPrev bool
+	// removedAt - время снимка, в котором сущность пропала; нулевое, пока она есть
+	removedAt time.Time
+}
+
+// Interpolator сглаживает движение чужих сущностей: отметки времени ставятся
+// при получении снимка, а рисуются сущности с задержкой delay, интерполируя
+// между соседними состояниями. Если новых состояний нет, позиция
+// экстраполируется по последней скорости не дольше maxExtrapolation
+type Interpolator struct {
+	mu               sync.Mutex
+	delay            time.Duration
+	maxExtrapolation time.Duration
+	tracks           map[uint32]*track
+}
+
+func NewInterpolator(delay, maxExtrapolation time.Duration) *Interpolator {
+	return &Interpolator{
+		delay:            delay,
+		maxExtrapolation: maxExtrapolation,
+		tracks:           map[uint32]*track{},
+	}
+}
+
+// Push добавляет в буфер снимок, полученный в момент at.
+// Сущности с ID из exclude (например, свой игрок) пропускаются
+func (ip *Interpolator) Push(at time.Time, s snapshot.Snapshot, exclude ...uint32) {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+
+	seen := make(map[uint32]bool, len(s.Entities))
+
+	for _, id := range exclude {
+		seen[id] = true
+	}
+
+	for _, e := range s.Entities {
+		if seen[e.ID] {
+			continue
+		}
+
+		seen[e.ID] = true
+
+		t, ok := ip.tracks[e.ID]
+		if !ok {
+			t = &track{}
+			ip.tracks[e.ID] = t
+		}
+
+		t.kind = e.Kind
+		t.removedAt = time.Time{}
+
+		if len(t.samples) == maxSamples {
+			t.samples = t.samples[1:]
+		}
+
+		t.samples = append(t.samples, sample{at: at, x: e.X, y: e.Y, size: e.Size})
+	}
+
+	for id, t := range ip.tracks {
+		if !seen[id] && t.removedAt.IsZero() {
+			t.removedAt = at
+		}
+	}
+}
+
+// Sample возвращает сглаженное состояние сущностей на момент now-delay
+func (ip *Interpolator) Sample(now time.Time) []snapshot.Entity {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+
+	renderAt := now.Add(-ip.delay)
+	entities := make([]snapshot.Entity, 0, len(ip.tracks))
+
+	for id, t := range ip.tracks {
+		if !t.removedAt.IsZero() && !renderAt.Before(t.removedAt) {
+			delete(ip.tracks, id)
+			continue
+		}
+
+		t.trim(renderAt)
+
+		x, y, size, ok := t.at(renderAt, ip.maxExtrapolation)
+		if !ok {
+			continue
+		}
+
+		entities = append(entities, snapshot.Entity{ID: id, Kind: t.kind, X: x, Y: y, Size: size})
+	}
+
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+
+	return entities
+}
+
+// trim выкидывает состояния, которые уже не понадобятся для интерполяции
+func (t *track) trim(renderAt time.Time) {
+	n := 0
+	for n+1 < len(t.samples) && !t.samples[n+1].at.After(renderAt) {
+		n++
+	}
+
+	if n > 0 {
+		t.prev = t.samples[n-1]
+		t.hasPrev = true
+		t.samples = t.samples[n:]
+	}
+}
+
+func (t *track) at(renderAt time.Time, maxExtrapolation time.Duration) (float32, float32, float32, bool) {
+	if len(t.samples) == 0 {
+		return 0, 0, 0, false
+	}
+
+	first := t.samples[0]
+
+	// Сущность только появилась и ее первое состояние еще "в будущем"
+	if renderAt.Before(first.at) {
+		return first.x, first.y, first.size, true
+	}
+
+	if len(t.samples) >= 2 {
+		next := t.samples[1]
+		k := float32(renderAt.Sub(first.at)) / float32(next.at.Sub(first.at))
+
+		return lerp(first.x, next.x, k), lerp(first.y, next.y, k), lerp(first.size, next.size, k), true
+	}
+
+	// Новых состояний нет - экстраполируем по скорости между двумя последними
+	if !t.hasPrev || !first.at.After(t.prev.at) {
+		return first.x, first.y, first.size, true
+	}
+
+	ahead := min(renderAt.Sub(first.at), maxExtrapolation)
+	k := float32(ahead) / float32(first.at.Sub(t.prev.at))
+
+	return first.x + (first.x-t.prev.x)*k, first.y + (first.y-t.prev.y)*k, first.size, true
+}
+
+func lerp(a, b, k float32) float32 {
+	return a + (b-a)*k
+}
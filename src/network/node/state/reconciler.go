@@ -0,0 +1,84 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+)
+
+// MaxPendingInputs ограничивает очередь неподтвержденных вводов:
+// если бэкенд долго не отвечает, старые вводы выкидываются
+const MaxPendingInputs = 128
+
+// Reconciler реализует предсказание на клиенте: ввод сразу применяется
+// к локальной копии игрока, а при получении авторитетного состояния
+// неподтвержденные сервером вводы проигрываются поверх него заново
+type Reconciler struct {
+	mu        sync.Mutex
+	seq       uint32
+	pending   []game.Input
+	predicted game.Player
+}
+
+func NewReconciler(player game.Player) *Reconciler {
+	return &Reconciler{predicted: player, seq: player.LastInput}
+}
+
+// Input нумерует очередной ввод, применяет его локально и
+// возвращает то, что нужно отправить бэкенду
+func (r *Reconciler) Input(dirX, dirY float32) game.Input {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	in := game.Input{Seq: r.seq, DirX: dirX, DirY: dirY}
+
+	if len(r.pending) == MaxPendingInputs {
+		r.pending = r.pending[1:]
+	}
+
+	r.pending = append(r.pending, in)
+	r.predicted.Apply(in)
+
+	return in
+}
+
+// Reconcile принимает авторитетное состояние игрока и номер последнего
+// обработанного бэкендом ввода, и возвращает новое предсказанное состояние
+func (r *Reconciler) Reconcile(authoritative game.Player, lastProcessed uint32) game.Player {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(r.pending) && int32(r.pending[n].Seq-lastProcessed) <= 0 {
+		n++
+	}
+
+	r.pending = r.pending[n:]
+
+	predicted := authoritative
+	predicted.LastInput = lastProcessed
+
+	for _, in := range r.pending {
+		predicted.Apply(in)
+	}
+
+	r.predicted = predicted
+
+	return predicted
+}
+
+func (r *Reconciler) Predicted() game.Player {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.predicted
+}
+
+// Pending возвращает количество вводов, еще не подтвержденных бэкендом
+func (r *Reconciler) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/snapshot"
+)
+
+func TestReconcile(t *testing.T) {
+	center := game.FixedFromInt(game.MapWidth / 2)
+	start := *game.NewPlayer(1, center, center)
+
+	// after - игрок from после вводов с номерами из seqs вправо
+	after := func(from game.Player, seqs ...uint32) game.Player {
+		for _, seq := range seqs {
+			from.Apply(game.Input{Seq: seq, DirX: 1})
+		}
+
+		return from
+	}
+
+	// Бэкенд увел игрока вниз, например, поправкой античита
+	corrected := start
+	corrected.Y += game.FixedFromInt(50)
+
+	tests := []struct {
+		name          string
+		authoritative game.Player
+		lastProcessed uint32
+		wantPending   int
+		want          game.Player
+	}{
+		{"nothing processed", start, 0, 3, after(start, 1, 2, 3)},
+		{"some processed", after(start, 1), 1, 2, after(after(start, 1), 2, 3)},
+		{"all processed", after(start, 1, 2, 3), 3, 0, after(start, 1, 2, 3)},
+		{"correction", corrected, 1, 2, after(corrected, 2, 3)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewReconciler(start)
+			for range 3 {
+				r.Input(1, 0)
+			}
+
+			got := r.Reconcile(test.authoritative, test.lastProcessed)
+
+			if r.Pending() != test.wantPending {
+				t.Fatalf("Pending = %d, want %d", r.Pending(), test.wantPending)
+			}
+
+			if got != test.want || r.Predicted() != test.want {
+				t.Fatalf("Reconcile = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReconcilerContinuesSeq(t *testing.T) {
+	player := *game.NewPlayer(1, 0, 0)
+	player.LastInput = 41
+
+	r := NewReconciler(player)
+
+	if in := r.Input(1, 0); in.Seq != 42 {
+		t.Fatalf("first Seq = %d, want 42", in.Seq)
+	}
+}
+
+func TestReconcilerPendingLimit(t *testing.T) {
+	r := NewReconciler(*game.NewPlayer(1, 0, 0))
+
+	for range MaxPendingInputs + 10 {
+		r.Input(1, 0)
+	}
+
+	if r.Pending() != MaxPendingInputs {
+		t.Fatalf("Pending = %d, want %d", r.Pending(), MaxPendingInputs)
+	}
+}
+
+func entity(id uint32, x float32) snapshot.Entity {
+	return snapshot.Entity{ID: id, Kind: snapshot.KindPlayer, X: x, Y: 0, Size: 10}
+}
+
+func TestInterpolatorSample(t *testing.T) {
+	start := time.Unix(1000, 0)
+	ms := func(n int) time.Time { return start.Add(time.Duration(n) * time.Millisecond) }
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		wantX float32
+	}{
+		// Задержка рендера 100 мс, снимки в 0, 100 и 200 мс с X = 0, 10, 20
+		{"before first", ms(50), 0},
+		{"between", ms(150), 5},
+		{"on sample", ms(200), 10},
+		{"last sample", ms(300), 20},
+		{"extrapolated", ms(350), 25},
+		{"extrapolation capped", ms(1000), 45},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ip := NewInterpolator(100*time.Millisecond, 250*time.Millisecond)
+
+			for i, x := range []float32{0, 10, 20} {
+				ip.Push(ms(100*i), snapshot.Snapshot{Entities: []snapshot.Entity{entity(7, x)}})
+			}
+
+			got := ip.Sample(test.now)
+			if len(got) != 1 || got[0].ID != 7 || got[0].X != test.wantX {
+				t.Fatalf("Sample = %+v, want entity 7 at X = %v", got, test.wantX)
+			}
+		})
+	}
+}
+
+func TestInterpolatorRemoval(t *testing.T) {
+	start := time.Unix(1000, 0)
+	ip := NewInterpolator(100*time.Millisecond, 250*time.Millisecond)
+
+	ip.Push(start, snapshot.Snapshot{Entities: []snapshot.Entity{entity(1, 0), entity(2, 0), entity(3, 0)}}, 3)
+	ip.Push(start.Add(100*time.Millisecond), snapshot.Snapshot{Entities: []snapshot.Entity{entity(1, 10)}})
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []uint32
+	}{
+		// Пропавшая сущность рисуется, пока рендер не дошел до снимка без нее
+		{"still rendered", start.Add(150 * time.Millisecond), []uint32{1, 2}},
+		{"removed", start.Add(200 * time.Millisecond), []uint32{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ids []uint32
+			for _, e := range ip.Sample(test.now) {
+				ids = append(ids, e.ID)
+			}
+
+			if !reflect.DeepEqual(ids, test.want) {
+				t.Fatalf("Sample IDs = %v, want %v", ids, test.want)
+			}
+		})
+	}
+}
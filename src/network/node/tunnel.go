@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"github.com/matelq/p2pmp/examples/yamux/common"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	nodepb "github.com/matelq/p2pmp/src/network/common/node"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+	transmitterpb "github.com/matelq/p2pmp/src/network/common/transmitter"
+	"github.com/matelq/p2pmp/src/network/node/gateway"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultCallTimeout ограничивает вызов transmitter через туннель
+const DefaultCallTimeout = 5 * time.Second
+
+// dialTunnel создает gRPC-клиент transmitter поверх туннеля: каждое
+// соединение - поток yamux, который открывает узел
+func dialTunnel(session *yamux.Session) (*grpc.ClientConn, error) {
+	options := append(metrics.DialOptions(), tracing.DialOptions()...)
+	options = append(options, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return session.Open() }))
+
+	return grpc.NewClient(":3000", options...)
+}
+
+// tunnelConfig - проверки, которые запускаются на каждом туннеле
+type tunnelConfig struct {
+	healthInterval time.Duration
+	healthFailures int
+	heartbeats     heartbeat.Config
+}
+
+// tunnel - подключение к одному transmitter: yamux-сессия, сервер node.proto
+// на потоках от transmitter и клиент transmitter.proto на потоках узла
+type tunnel struct {
+	// ctx - контекст логов с узлом и сессией transmitter
+	ctx         context.Context
+	session     *yamux.Session
+	server      *grpc.Server
+	conn        *grpc.ClientConn
+	transmitter transmitterpb.TransmitterClient
+	// done получает ошибку Serve, когда туннель закрыт
+	done chan error
+}
+
+// openTunnel подключается к transmitter по адресу address и начинает
+// обслуживать вызовы node.proto сервером node
+func openTunnel(address, token string, node *nodeServer, config tunnelConfig) (*tunnel, error) {
+	// ID узла выдает transmitter по токену сессии - это ID аккаунта
+	conn, nodeID, sessionID, err := connect(address, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Те же узел и сессия, что в логах transmitter
+	ctx := logging.With(context.Background(), logging.Node(nodeID), logging.Session(sessionID), logging.Transport(metrics.TransportRelay))
+
+	session, err := yamux.Server(conn, yamux.DefaultConfig())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := dialTunnel(session)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	t := &tunnel{
+		ctx:         ctx,
+		session:     session,
+		server:      grpc.NewServer(append(metrics.ServerOptions(), tracing.ServerOptions()...)...),
+		conn:        client,
+		transmitter: transmitterpb.NewTransmitterClient(client),
+		done:        make(chan error, 1),
+	}
+
+	common.RegisterClientServerServer(t.server, &ClientServerImpl{})
+	nodepb.RegisterNodeServer(t.server, node)
+
+	watchTunnel(session)
+
+	go watchHealth(ctx, session, config.healthInterval, config.healthFailures)
+	go watchHeartbeat(ctx, session, config.heartbeats)
+
+	slog.InfoContext(ctx, "launching gRPC server over TCP connection", "address", address)
+
+	go func() {
+		t.done <- t.server.Serve(session)
+	}()
+
+	return t, nil
+}
+
+// Close закрывает туннель, не дожидаясь идущих вызовов
+func (t *tunnel) Close() {
+	t.conn.Close()
+	t.server.Stop()
+	t.session.Close()
+}
+
+// transmitterLink отдает клиент текущего transmitter: при миграции туннель
+// меняется, а шлюз рендера продолжает работать
+type transmitterLink struct {
+	mu     sync.Mutex
+	client transmitterpb.TransmitterClient
+}
+
+func (l *transmitterLink) Client() transmitterpb.TransmitterClient {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.client
+}
+
+func (l *transmitterLink) set(client transmitterpb.TransmitterClient) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.client = client
+}
+
+// Input отправляет ввод своего игрока. Ввод уходит по одному вызову,
+// чтобы бэкенд получал его в порядке номеров
+func (l *transmitterLink) Input(in game.Input) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+	defer cancel()
+
+	_, err := l.Client().SendInput(ctx, &contracts.PlayerInput{Seq: in.Seq, DirX: in.DirX, DirY: in.DirY})
+
+	return err
+}
+
+func (l *transmitterLink) Chat(m chat.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+	defer cancel()
+
+	res, err := l.Client().SendChat(ctx, m.Contract())
+	if err != nil {
+		return err
+	}
+
+	// Непустой ответ - причина, по которой сообщение не принято
+	if res.Data != "" {
+		return errors.New(res.Data)
+	}
+
+	return nil
+}
+
+// Follow переключает камеру зрителя на игрока playerID
+func (l *transmitterLink) Follow(playerID uint32) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+	defer cancel()
+
+	if _, err := l.Client().Follow(ctx, &contracts.FollowRequest{PlayerId: playerID}); err != nil {
+		slog.Warn("cannot follow player", "player", playerID, logging.Err(err))
+	}
+}
+
+// joinMatch переводит узел в матч, подобранный матчмейкингом
+func joinMatch(ctx context.Context, transmitter transmitterpb.TransmitterClient, matchID string) {
+	callCtx, cancel := context.WithTimeout(ctx, DefaultCallTimeout)
+	defer cancel()
+
+	if _, err := transmitter.JoinMatch(callCtx, &contracts.JoinMatchRequest{MatchId: matchID}); err != nil {
+		slog.ErrorContext(ctx, "cannot join assigned match", logging.Room(matchID), logging.Err(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "joined assigned match", logging.Room(matchID))
+}
+
+// watchMatch подключается к матчу зрителем и переключает рендер в режим зрителя
+func watchMatch(ctx context.Context, transmitter transmitterpb.TransmitterClient, gw *gateway.Gateway, matchID string) {
+	callCtx, cancel := context.WithTimeout(ctx, DefaultCallTimeout)
+	defer cancel()
+
+	if _, err := transmitter.Spectate(callCtx, &contracts.SpectateRequest{MatchId: matchID}); err != nil {
+		slog.ErrorContext(ctx, "cannot spectate match", logging.Room(matchID), logging.Err(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "spectating match", logging.Room(matchID))
+	gw.SetSpectator(true)
+}
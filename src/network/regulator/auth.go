@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/backend/account"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/ratelimit"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+)
+
+// DefaultCredentialTTL - сколько живут учетные данные, выданные узлу в /auth
+const DefaultCredentialTTL = 12 * time.Hour
+
+var errUnauthenticated = errors.New("missing or expired regulator credential")
+
+type credential struct {
+	nodeID  string
+	expires time.Time
+}
+
+// credentials - учетные данные узлов. Узел получает их, предъявив токен
+// сессии аккаунта, как при рукопожатии с transmitter, и дальше не может
+// выдавать себя за другой узел
+type credentials struct {
+	// accounts - адрес HTTP API аккаунтов, по нему проверяются токены сессий
+	accounts string
+	client   *http.Client
+	ttl      time.Duration
+
+	mu     sync.Mutex
+	issued map[string]credential
+}
+
+func newCredentials(accounts string, ttl time.Duration) *credentials {
+	return &credentials{accounts: accounts, client: &http.Client{Timeout: 5 * time.Second}, ttl: ttl, issued: map[string]credential{}}
+}
+
+// issue проверяет токен сессии аккаунта и выдает узлу учетные данные
+func (c *credentials) issue(token string) (p2p.Credentials, error) {
+	req, err := http.NewRequest(http.MethodGet, c.accounts+"/account/profile", nil) //nolint:noctx
+	if err != nil {
+		return p2p.Credentials{}, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return p2p.Credentials{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return p2p.Credentials{}, fmt.Errorf("accounts responded %s", resp.Status)
+	}
+
+	var profile account.ProfileResponse
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return p2p.Credentials{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return p2p.Credentials{}, err
+	}
+
+	now := time.Now()
+	issued := p2p.Credentials{NodeID: profile.ID, Credential: hex.EncodeToString(secret), Expires: now.Add(c.ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, cred := range c.issued {
+		if now.After(cred.expires) {
+			delete(c.issued, key)
+		}
+	}
+
+	c.issued[issued.Credential] = credential{nodeID: issued.NodeID, expires: issued.Expires}
+
+	return issued, nil
+}
+
+// node возвращает узел, которому выданы учетные данные из заголовка запроса
+func (c *credentials) node(r *http.Request) (string, bool) {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || given == "" {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cred, ok := c.issued[given]
+	if !ok {
+		return "", false
+	}
+
+	if time.Now().After(cred.expires) {
+		delete(c.issued, given)
+		return "", false
+	}
+
+	return cred.nodeID, true
+}
+
+// authenticated пропускает запрос к next только с действующими учетными
+// данными и передает ему ID узла, которому они выданы
+func (c *credentials) authenticated(next func(w http.ResponseWriter, r *http.Request, nodeID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nodeID, ok := c.node(r)
+		if !ok {
+			http.Error(w, errUnauthenticated.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r, nodeID)
+	}
+}
+
+// sameNode проверяет, что узел, указанный в запросе, - это узел учетных данных.
+// Иначе отвечает 403: узел не может действовать от имени другого
+func sameNode(w http.ResponseWriter, r *http.Request, nodeID, claimed string) bool {
+	if nodeID == claimed {
+		return true
+	}
+
+	slog.WarnContext(r.Context(), "rejecting request for another node", logging.Node(nodeID), "claimed", claimed, "path", r.URL.Path)
+	http.Error(w, "node does not match the credential", http.StatusForbidden)
+
+	return false
+}
+
+// handleAuth - рукопожатие узла с регулятором: токен сессии аккаунта в
+// заголовке "Authorization: Bearer <token>" меняется на учетные данные.
+// Каждая попытка проверяется в API аккаунтов, поэтому limiter ограничивает их по IP
+func handleAuth(c *credentials, limiter *ratelimit.Limiter) {
+	http.Handle("POST /auth", limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			http.Error(w, "missing session token", http.StatusUnauthorized)
+			return
+		}
+
+		issued, err := c.issue(token)
+		if err != nil {
+			slog.WarnContext(r.Context(), "rejecting node authentication", "address", r.RemoteAddr, logging.Err(err))
+			http.Error(w, "invalid session token", http.StatusUnauthorized)
+			return
+		}
+
+		slog.InfoContext(r.Context(), "node authenticated", logging.Node(issued.NodeID))
+		writeJSON(w, issued)
+	})))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// DefaultDirectoryTimeout ограничивает запросы к общему каталогу
+const DefaultDirectoryTimeout = 2 * time.Second
+
+type bootstrapResponse struct {
+	Address string `json:"address"`
+	Region  string `json:"region,omitempty"`
+}
+
+// handleBootstrap - первый запрос узла перед подключением: адрес transmitter
+// или commuter по нагрузке, которую экземпляры объявляют в общем каталоге
+func handleBootstrap(locations directory.Directory) {
+	// ?kind=transmitter|commuter&region=...; 503 - свободных экземпляров нет,
+	// узел подключается по адресу из своей конфигурации
+	http.HandleFunc("/bootstrap", func(w http.ResponseWriter, r *http.Request) {
+		kind := r.URL.Query().Get("kind")
+		if kind == "" {
+			kind = directory.KindTransmitter
+		}
+
+		if kind != directory.KindTransmitter && kind != directory.KindCommuter {
+			http.Error(w, "kind must be transmitter or commuter", http.StatusBadRequest)
+			return
+		}
+
+		instances, err := locations.Instances(kind)
+		if err != nil {
+			slog.Error("cannot list instances", "kind", kind, logging.Err(err))
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		instance, ok := directory.Pick(instances, r.URL.Query().Get("region"))
+		if !ok {
+			http.Error(w, "no "+kind+" is available", http.StatusServiceUnavailable)
+			return
+		}
+
+		writeJSON(w, bootstrapResponse{Address: instance.Address, Region: instance.Region})
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/matelq/p2pmp/src/network/regulator/hosting"
+)
+
+// MaxHostState ограничивает тело /host/state: сериализованный матч заметно меньше
+const MaxHostState = 4 << 20
+
+// Эндпоинты режима хоста, клиент - host.HTTPRegulator на узле. Узел в запросе
+// должен совпадать с узлом учетных данных (см. auth.go)
+func handleHosting(n *notifier, creds *credentials) {
+	// Хост присылает сериализованное состояние матча
+	http.HandleFunc("/host/state", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		query := r.URL.Query()
+
+		if !sameNode(w, r, nodeID, query.Get("node")) {
+			return
+		}
+
+		epoch, err := strconv.ParseUint(query.Get("epoch"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		tick, err := strconv.ParseUint(query.Get("tick"), 10, 32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		state, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxHostState))
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = n.hosting.UploadState(query.Get("match"), nodeID, epoch, uint32(tick), state)
+		writeHostingError(w, err)
+	}))
+
+	// Участник не может достучаться до хоста
+	http.HandleFunc("/host/lost", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		query := r.URL.Query()
+
+		if sameNode(w, r, nodeID, query.Get("node")) {
+			writeHostingError(w, n.hosting.ReportHostLost(query.Get("match"), nodeID))
+		}
+	}))
+
+	http.HandleFunc("/host/leave", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		query := r.URL.Query()
+
+		if sameNode(w, r, nodeID, query.Get("node")) {
+			writeHostingError(w, n.hosting.Leave(query.Get("match"), nodeID))
+		}
+	}))
+
+	http.HandleFunc("/host/assignment", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		if !sameNode(w, r, nodeID, r.URL.Query().Get("node")) {
+			return
+		}
+
+		a, ok := n.hosts.latest(nodeID)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		writeJSON(w, a)
+	}))
+}
+
+func writeHostingError(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, hosting.ErrSessionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, hosting.ErrNotReporter):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, hosting.ErrNotHost):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,276 @@
+// Режим хоста: регулятор назначает один из узлов матча авторитетным хостом,
+// остальные подключаются к нему по P2P. Хост периодически присылает сюда
+// состояние матча, и если он пропал, регулятор выбирает нового хоста
+// и передает ему последнее сохраненное состояние
+package hosting
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+var (
+	ErrSessionNotFound = errors.New("hosting: session not found")
+	ErrNotHost         = errors.New("hosting: node is not the current host")
+	ErrNoCandidates    = errors.New("hosting: no members left to host")
+	// ErrNotReporter - о пропаже хоста сообщают только остальные участники матча
+	ErrNotReporter = errors.New("hosting: only members other than the host can report it lost")
+)
+
+// DefaultHostTimeout - через сколько без обновлений состояния хост считается пропавшим
+const DefaultHostTimeout = 3 * time.Second
+
+// Assignment рассылается всем участникам при назначении хоста.
+// State заполнен только у нового хоста после миграции
+type Assignment struct {
+	MatchID string
+	Host    string
+	Epoch   uint64
+	Members []string
+	State   []byte
+}
+
+type Notifier interface {
+	NotifyHost(nodeID string, a Assignment) error
+}
+
+type session struct {
+	matchID string
+	// members - участники и их пригодность к роли хоста (больше - лучше)
+	members   map[string]float64
+	host      string
+	epoch     uint64
+	state     []byte
+	stateTick uint32
+	updated   time.Time
+	// suspects - кто из участников сообщил, что хост недоступен
+	suspects map[string]bool
+}
+
+type Hosting struct {
+	mu          sync.Mutex
+	notifier    Notifier
+	hostTimeout time.Duration
+	sessions    map[string]*session
+}
+
+func New(notifier Notifier, hostTimeout time.Duration) *Hosting {
+	return &Hosting{notifier: notifier, hostTimeout: hostTimeout, sessions: map[string]*session{}}
+}
+
+// Create заводит сессию для матча и назначает первого хоста
+func (h *Hosting) Create(matchID string, members map[string]float64) error {
+	h.mu.Lock()
+
+	s := &session{matchID: matchID, members: members, updated: time.Now(), suspects: map[string]bool{}}
+	h.sessions[matchID] = s
+
+	assignments, err := h.elect(s)
+	h.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	h.notify(assignments)
+
+	return nil
+}
+
+// UploadState сохраняет состояние матча от текущего хоста.
+// Состояние от хоста из прошлой эпохи отбрасывается
+func (h *Hosting) UploadState(matchID, nodeID string, epoch uint64, tick uint32, state []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.sessions[matchID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	if s.host != nodeID || s.epoch != epoch {
+		return ErrNotHost
+	}
+
+	s.state = state
+	s.stateTick = tick
+	s.updated = time.Now()
+	s.suspects = map[string]bool{}
+
+	return nil
+}
+
+// Leave убирает участника; если ушел хост, происходит миграция
+func (h *Hosting) Leave(matchID, nodeID string) error {
+	h.mu.Lock()
+
+	s, ok := h.sessions[matchID]
+	if !ok {
+		h.mu.Unlock()
+		return ErrSessionNotFound
+	}
+
+	delete(s.members, nodeID)
+
+	if len(s.members) == 0 {
+		delete(h.sessions, matchID)
+		h.mu.Unlock()
+		return nil
+	}
+
+	var assignments map[string]Assignment
+	var err error
+
+	if s.host == nodeID {
+		assignments, err = h.elect(s)
+	}
+
+	h.mu.Unlock()
+
+	h.notify(assignments)
+
+	return err
+}
+
+// ReportHostLost - участник не может достучаться до хоста.
+// Миграция начинается, когда об этом сообщило большинство остальных участников.
+// Если остальной участник один (матч на двоих), его сообщения мало: хост
+// еще и должен не присылать состояние дольше половины hostTimeout
+func (h *Hosting) ReportHostLost(matchID, reporter string) error {
+	h.mu.Lock()
+
+	s, ok := h.sessions[matchID]
+	if !ok {
+		h.mu.Unlock()
+		return ErrSessionNotFound
+	}
+
+	if _, ok := s.members[reporter]; !ok || reporter == s.host {
+		h.mu.Unlock()
+		return ErrNotReporter
+	}
+
+	s.suspects[reporter] = true
+
+	var assignments map[string]Assignment
+	var err error
+
+	others := len(s.members) - 1
+	lost := 2*len(s.suspects) > others
+
+	if others < 2 {
+		lost = lost && time.Since(s.updated) > h.hostTimeout/2
+	}
+
+	if lost {
+		slog.Warn("host lost by members' reports", logging.Room(matchID), logging.Node(s.host))
+		delete(s.members, s.host)
+		assignments, err = h.elect(s)
+	}
+
+	h.mu.Unlock()
+
+	h.notify(assignments)
+
+	return err
+}
+
+// Expire переназначает хостов, не присылавших состояние дольше hostTimeout
+func (h *Hosting) Expire(now time.Time) {
+	h.mu.Lock()
+
+	all := map[string]Assignment{}
+
+	for matchID, s := range h.sessions {
+		if now.Sub(s.updated) <= h.hostTimeout {
+			continue
+		}
+
+		slog.Warn("host timed out", logging.Room(matchID), logging.Node(s.host))
+		delete(s.members, s.host)
+
+		assignments, err := h.elect(s)
+		if err != nil {
+			slog.Info("closing hosted match", logging.Room(matchID), logging.Err(err))
+			delete(h.sessions, matchID)
+			continue
+		}
+
+		for nodeID, a := range assignments {
+			all[nodeID] = a
+		}
+	}
+
+	h.mu.Unlock()
+
+	h.notify(all)
+}
+
+// Run периодически проверяет хостов, пока не закрыт stop
+func (h *Hosting) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			h.Expire(now)
+		}
+	}
+}
+
+// elect выбирает хоста с наибольшей пригодностью (при равенстве - с меньшим ID)
+// и начинает новую эпоху. Вызывается под h.mu
+func (h *Hosting) elect(s *session) (map[string]Assignment, error) {
+	if len(s.members) == 0 {
+		return nil, ErrNoCandidates
+	}
+
+	members := make([]string, 0, len(s.members))
+	for nodeID := range s.members {
+		members = append(members, nodeID)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if s.members[members[i]] != s.members[members[j]] {
+			return s.members[members[i]] > s.members[members[j]]
+		}
+
+		return members[i] < members[j]
+	})
+
+	s.host = members[0]
+	s.epoch++
+	s.updated = time.Now()
+	s.suspects = map[string]bool{}
+
+	slog.Info("host elected", logging.Room(s.matchID), logging.Node(s.host), "epoch", s.epoch, "state_tick", s.stateTick)
+
+	assignments := make(map[string]Assignment, len(members))
+	for _, nodeID := range members {
+		a := Assignment{MatchID: s.matchID, Host: s.host, Epoch: s.epoch, Members: members}
+
+		if nodeID == s.host {
+			a.State = s.state
+		}
+
+		assignments[nodeID] = a
+	}
+
+	return assignments, nil
+}
+
+func (h *Hosting) notify(assignments map[string]Assignment) {
+	for nodeID, a := range assignments {
+		if err := h.notifier.NotifyHost(nodeID, a); err != nil {
+			slog.Warn("cannot notify about host", logging.Node(nodeID), logging.Room(a.MatchID), logging.Err(err))
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "sync"
+
+// mailbox хранит сообщения для узлов, пока те их не заберут опросом
+type mailbox[T any] struct {
+	mu    sync.Mutex
+	items map[string][]T
+}
+
+func newMailbox[T any]() *mailbox[T] {
+	return &mailbox[T]{items: map[string][]T{}}
+}
+
+func (m *mailbox[T]) put(nodeID string, item T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items[nodeID] = append(m.items[nodeID], item)
+}
+
+func (m *mailbox[T]) take(nodeID string) []T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := m.items[nodeID]
+	delete(m.items, nodeID)
+
+	return items
+}
+
+// latest забирает только последнее сообщение, более старые устарели
+func (m *mailbox[T]) latest(nodeID string) (T, bool) {
+	items := m.take(nodeID)
+
+	if len(items) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	return items[len(items)-1], true
+}
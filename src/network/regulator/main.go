@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/health"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/ratelimit"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+	"github.com/matelq/p2pmp/src/network/regulator/hosting"
+	"github.com/matelq/p2pmp/src/network/regulator/matchmaking"
+
+	"google.golang.org/grpc"
+)
+
+// Условия готовности регулятора для grpc.health.v1
+const (
+	readyHTTP = "http"
+	// readyLoops - циклы матчмейкинга и назначения хостов запущены
+	readyLoops = "loops"
+)
+
+type ticketRequest struct {
+	NodeID     string           `json:"nodeId"`
+	Skill      float64          `json:"skill"`
+	RTTMillis  map[string]int64 `json:"rttMillis"`
+	P2PCapable bool             `json:"p2pCapable"`
+	// HostScore - пригодность узла к роли хоста (канал, CPU), больше - лучше
+	HostScore float64 `json:"hostScore"`
+}
+
+// notifier раздает результаты матчмейкинга и назначения хостов по почтовым ящикам узлов.
+// Для P2P-матчей сразу заводится сессия режима хоста
+type notifier struct {
+	matches *mailbox[matchmaking.Assignment]
+	hosts   *mailbox[hosting.Assignment]
+	hosting *hosting.Hosting
+
+	mu     sync.Mutex
+	scores map[string]float64
+}
+
+func (n *notifier) Notify(nodeID string, a matchmaking.Assignment) error {
+	n.matches.put(nodeID, a)
+
+	// Сессию хоста создаем один раз на матч - при уведомлении первого участника
+	if a.Transport != matchmaking.TransportP2P || nodeID != a.Members[0] {
+		return nil
+	}
+
+	n.mu.Lock()
+	members := make(map[string]float64, len(a.Members))
+	for _, member := range a.Members {
+		members[member] = n.scores[member]
+		delete(n.scores, member)
+	}
+	n.mu.Unlock()
+
+	return n.hosting.Create(a.MatchID, members)
+}
+
+func (n *notifier) NotifyHost(nodeID string, a hosting.Assignment) error {
+	n.hosts.put(nodeID, a)
+
+	return nil
+}
+
+func (n *notifier) setScore(nodeID string, score float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.scores[nodeID] = score
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("cannot write response", logging.Err(err))
+	}
+}
+
+func main() {
+	addr := flag.String("address", ":3002", "Address that the regulator HTTP server is hosted on.")
+	healthAddr := flag.String("health-address", ":3005", "Address that the gRPC health service is hosted on.")
+	accountsURL := flag.String("accounts", "http://89.169.34.96:3003", "URL of the transmitter accounts API that session tokens are checked against in /auth.")
+	directorySpec := flag.String("directory", "", "Shared directory where transmitters announce their load: redis://[:password@]host:port. Without it bootstrap finds no instances.")
+	traceExporter := flag.String("trace", tracing.ExporterNone, "Where to export traces: none, stdout or otlp (OTEL_EXPORTER_OTLP_ENDPOINT).")
+	httpRate := flag.Float64("http-rate", 50, "Requests per second allowed from one IP after a burst. Signaling polls count too, so leave room for several nodes behind one NAT.")
+	httpBurst := flag.Int("http-burst", 100, "Requests one IP may make at once.")
+	authRate := flag.Float64("auth-rate", 0.5, "Authentication attempts per second allowed from one IP after a burst. Every attempt calls the accounts API.")
+	authBurst := flag.Int("auth-burst", 5, "Authentication attempts one IP may make at once.")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error.")
+	logFormat := flag.String("log-format", logging.FormatText, "Log output format: text or json.")
+	flag.Parse()
+
+	if err := logging.Setup(*logLevel, *logFormat); err != nil {
+		panic(err)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), "regulator", *traceExporter)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer shutdown(context.Background()) //nolint:errcheck
+
+	locations, err := directory.Open(*directorySpec, DefaultDirectoryTimeout)
+
+	if err != nil {
+		panic(err)
+	}
+
+	n := &notifier{
+		matches: newMailbox[matchmaking.Assignment](),
+		hosts:   newMailbox[hosting.Assignment](),
+		scores:  map[string]float64{},
+	}
+	n.hosting = hosting.New(n, hosting.DefaultHostTimeout)
+	queue := matchmaking.NewQueue(matchmaking.DefaultConfig(), n)
+
+	readiness := health.NewReadiness(readyHTTP, readyLoops)
+	go startHealth(*healthAddr, readiness)
+
+	go queue.Run(time.Second, nil)
+	go n.hosting.Run(time.Second, nil)
+	readiness.Set(readyLoops, true)
+
+	creds := newCredentials(*accountsURL, DefaultCredentialTTL)
+
+	requests := ratelimit.NewLimiter(*httpRate, *httpBurst)
+	auths := ratelimit.NewLimiter(*authRate, *authBurst)
+	go requests.Run(time.Minute, nil)
+	go auths.Run(time.Minute, nil)
+
+	handleAuth(creds, auths)
+	handleMatchmaking(queue, n, creds)
+	handleHosting(n, creds)
+	handleSignaling(creds)
+	handleBootstrap(locations)
+
+	listener, err := net.Listen("tcp", *addr)
+
+	if err != nil {
+		panic(err)
+	}
+
+	slog.Info("launching regulator HTTP server", "address", *addr)
+	readiness.Set(readyHTTP, true)
+
+	// nolint: gosec
+	if err := http.Serve(listener, requests.Handler(http.DefaultServeMux)); err != nil {
+		panic(err)
+	}
+}
+
+func startHealth(addr string, readiness *health.Readiness) {
+	listener, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		panic(err)
+	}
+
+	grpcServer := grpc.NewServer(tracing.ServerOptions()...)
+	readiness.Register(grpcServer)
+
+	slog.Info("launching gRPC health server", "address", addr)
+
+	if err := grpcServer.Serve(listener); err != nil {
+		panic(err)
+	}
+}
+
+func handleMatchmaking(queue *matchmaking.Queue, n *notifier, creds *credentials) {
+	// Узел встает в очередь, передавая рейтинг и RTT до relay
+	http.HandleFunc("/matchmaking/enqueue", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		var req ticketRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !sameNode(w, r, nodeID, req.NodeID) {
+			return
+		}
+
+		ticket := matchmaking.Ticket{NodeID: req.NodeID, Skill: req.Skill, P2PCapable: req.P2PCapable, RTT: map[string]time.Duration{}}
+		for relay, ms := range req.RTTMillis {
+			ticket.RTT[relay] = time.Duration(ms) * time.Millisecond
+		}
+
+		if err := queue.Enqueue(ticket); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		n.setScore(req.NodeID, req.HostScore)
+		slog.InfoContext(r.Context(), "ticket enqueued", logging.Node(req.NodeID), "skill", req.Skill, "p2p_capable", req.P2PCapable)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	http.HandleFunc("/matchmaking/cancel", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		if sameNode(w, r, nodeID, r.URL.Query().Get("node")) {
+			queue.Cancel(nodeID)
+		}
+	}))
+
+	// Узел опрашивает, подобран ли ему матч; 204 - еще в очереди
+	http.HandleFunc("/matchmaking/assignment", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		if !sameNode(w, r, nodeID, r.URL.Query().Get("node")) {
+			return
+		}
+
+		a, ok := n.matches.latest(nodeID)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		writeJSON(w, a)
+	}))
+}
@@ -0,0 +1,281 @@
+// Матчмейкинг: узлы встают в очередь с рейтингом и измеренными RTT до
+// доступных relay/регионов и группируются в матчи с учетом размера группы,
+// потолка задержки и ослабления требований по мере ожидания.
+// Для каждого матча выбирается план связи: P2P или через relay
+package matchmaking
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+var (
+	ErrAlreadyQueued = errors.New("matchmaking: node is already queued")
+	ErrNoRelays      = errors.New("matchmaking: ticket has no measured relays")
+)
+
+type Transport string
+
+const (
+	TransportRelay Transport = "relay"
+	TransportP2P   Transport = "p2p"
+)
+
+// Ticket - заявка узла на поиск матча
+type Ticket struct {
+	NodeID string
+	Skill  float64
+	// RTT до каждого доступного relay (адрес transmitter/commuter или регион)
+	RTT map[string]time.Duration
+	// P2PCapable - узел смог получить внешние ICE-кандидаты и готов к P2P
+	P2PCapable bool
+
+	enqueued time.Time
+}
+
+// Assignment - результат подбора, отправляется каждому участнику
+type Assignment struct {
+	MatchID   string
+	Members   []string
+	Relay     string
+	Transport Transport
+}
+
+// Notifier доставляет узлу выбранный матч и план связи
+type Notifier interface {
+	Notify(nodeID string, a Assignment) error
+}
+
+type Config struct {
+	MinPlayers   int
+	MaxPartySize int
+	// SkillWindow и LatencyCeiling - стартовые требования к группе
+	SkillWindow    float64
+	LatencyCeiling time.Duration
+	// За каждую секунду ожидания окно рейтинга и потолок задержки расширяются,
+	// но не дальше MaxSkillWindow и MaxLatencyCeiling
+	SkillRelaxPerSecond   float64
+	LatencyRelaxPerSecond time.Duration
+	MaxSkillWindow        float64
+	MaxLatencyCeiling     time.Duration
+	// FillTimeout - сколько ждать заполнения группы до MaxPartySize,
+	// после этого матч собирается из MinPlayers и больше
+	FillTimeout time.Duration
+	// P2PMaxPlayers - максимальный размер группы, которую имеет смысл соединять напрямую
+	P2PMaxPlayers int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MinPlayers:            2,
+		MaxPartySize:          10,
+		SkillWindow:           100,
+		LatencyCeiling:        80 * time.Millisecond,
+		SkillRelaxPerSecond:   20,
+		LatencyRelaxPerSecond: 10 * time.Millisecond,
+		MaxSkillWindow:        1000,
+		MaxLatencyCeiling:     200 * time.Millisecond,
+		FillTimeout:           5 * time.Second,
+		P2PMaxPlayers:         4,
+	}
+}
+
+type Queue struct {
+	mu       sync.Mutex
+	config   Config
+	notifier Notifier
+	tickets  map[string]*Ticket
+	next     int
+}
+
+func NewQueue(config Config, notifier Notifier) *Queue {
+	return &Queue{config: config, notifier: notifier, tickets: map[string]*Ticket{}}
+}
+
+func (q *Queue) Enqueue(t Ticket) error {
+	if len(t.RTT) == 0 {
+		return ErrNoRelays
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.tickets[t.NodeID]; ok {
+		return ErrAlreadyQueued
+	}
+
+	t.enqueued = time.Now()
+	q.tickets[t.NodeID] = &t
+
+	return nil
+}
+
+func (q *Queue) Cancel(nodeID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.tickets, nodeID)
+}
+
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.tickets)
+}
+
+// Run периодически формирует матчи, пока не закрыт stop
+func (q *Queue) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			q.Tick(now)
+		}
+	}
+}
+
+// Tick формирует все возможные на данный момент матчи и рассылает их участникам.
+// Первыми обслуживаются дольше всех ждущие узлы
+func (q *Queue) Tick(now time.Time) []Assignment {
+	q.mu.Lock()
+
+	waiting := make([]*Ticket, 0, len(q.tickets))
+	for _, t := range q.tickets {
+		waiting = append(waiting, t)
+	}
+
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].enqueued.Before(waiting[j].enqueued) })
+
+	var assignments []Assignment
+	taken := map[string]bool{}
+
+	for _, anchor := range waiting {
+		if taken[anchor.NodeID] {
+			continue
+		}
+
+		group, relay := q.group(anchor, waiting, taken, now)
+		if len(group) < q.config.MinPlayers {
+			continue
+		}
+
+		if len(group) < q.config.MaxPartySize && now.Sub(anchor.enqueued) < q.config.FillTimeout {
+			continue
+		}
+
+		q.next++
+		a := Assignment{MatchID: fmt.Sprintf("mm-%d", q.next), Relay: relay, Transport: q.transport(group)}
+
+		for _, t := range group {
+			taken[t.NodeID] = true
+			a.Members = append(a.Members, t.NodeID)
+			delete(q.tickets, t.NodeID)
+		}
+
+		assignments = append(assignments, a)
+	}
+
+	q.mu.Unlock()
+
+	for _, a := range assignments {
+		for _, nodeID := range a.Members {
+			// Если узел не узнает о матче, бэкенд исключит его по таймауту подключения
+			if err := q.notifier.Notify(nodeID, a); err != nil {
+				slog.Warn("cannot notify about match", logging.Node(nodeID), logging.Room(a.MatchID), logging.Err(err))
+			}
+		}
+	}
+
+	return assignments
+}
+
+// group подбирает группу для anchor: для каждого relay собирает подходящих
+// по рейтингу и задержке и выбирает relay с самой большой группой
+func (q *Queue) group(anchor *Ticket, waiting []*Ticket, taken map[string]bool, now time.Time) ([]*Ticket, string) {
+	waited := now.Sub(anchor.enqueued).Seconds()
+	window := math.Min(q.config.SkillWindow+q.config.SkillRelaxPerSecond*waited, q.config.MaxSkillWindow)
+	ceiling := min(q.config.LatencyCeiling+time.Duration(waited*float64(q.config.LatencyRelaxPerSecond)), q.config.MaxLatencyCeiling)
+
+	var best []*Ticket
+	bestRelay := ""
+	bestRTT := time.Duration(math.MaxInt64)
+
+	for relay, rtt := range anchor.RTT {
+		if rtt > ceiling {
+			continue
+		}
+
+		candidates := []*Ticket{anchor}
+		for _, t := range waiting {
+			if t == anchor || taken[t.NodeID] || math.Abs(t.Skill-anchor.Skill) > window {
+				continue
+			}
+
+			if r, ok := t.RTT[relay]; ok && r <= ceiling {
+				candidates = append(candidates, t)
+			}
+		}
+
+		// Из кандидатов берем ближайших по рейтингу, пока разброс рейтинга
+		// всей группы, а не только отрыв от anchor, помещается в окно
+		sort.SliceStable(candidates[1:], func(i, j int) bool {
+			return math.Abs(candidates[1+i].Skill-anchor.Skill) < math.Abs(candidates[1+j].Skill-anchor.Skill)
+		})
+
+		group := []*Ticket{anchor}
+		lowest, highest := anchor.Skill, anchor.Skill
+
+		for _, t := range candidates[1:] {
+			if q.config.MaxPartySize > 0 && len(group) >= q.config.MaxPartySize {
+				break
+			}
+
+			if math.Max(highest, t.Skill)-math.Min(lowest, t.Skill) > window {
+				continue
+			}
+
+			group = append(group, t)
+			lowest, highest = math.Min(lowest, t.Skill), math.Max(highest, t.Skill)
+		}
+
+		candidates = group
+
+		worst := time.Duration(0)
+		for _, t := range candidates {
+			worst = max(worst, t.RTT[relay])
+		}
+
+		if len(candidates) > len(best) || (len(candidates) == len(best) && worst < bestRTT) {
+			best, bestRelay, bestRTT = candidates, relay, worst
+		}
+	}
+
+	return best, bestRelay
+}
+
+// transport выбирает P2P для небольших групп, в которых все узлы готовы к прямому соединению
+func (q *Queue) transport(group []*Ticket) Transport {
+	if len(group) > q.config.P2PMaxPlayers {
+		return TransportRelay
+	}
+
+	for _, t := range group {
+		if !t.P2PCapable {
+			return TransportRelay
+		}
+	}
+
+	return TransportP2P
+}
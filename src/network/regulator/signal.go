@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+	"github.com/matelq/p2pmp/src/network/node/p2p"
+)
+
+// Сигнальный сервер для установки P2P-соединений: узлы обмениваются
+// SDP и ICE-кандидатами через почтовые ящики (клиент - p2p.HTTPSignaler).
+// Отправитель сигнала и владелец ящика - узел учетных данных запроса
+func handleSignaling(creds *credentials) {
+	signals := newMailbox[p2p.Signal]()
+
+	// Трассируется только отправка: опросы идут постоянно и засорили бы трассы
+	http.Handle("/signal/send", tracing.Handler(creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		var signal p2p.Signal
+		if err := json.NewDecoder(r.Body).Decode(&signal); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !sameNode(w, r, nodeID, signal.From) {
+			return
+		}
+
+		signals.put(signal.To, signal)
+	}), "signal.send"))
+
+	http.HandleFunc("/signal/poll", creds.authenticated(func(w http.ResponseWriter, r *http.Request, nodeID string) {
+		if !sameNode(w, r, nodeID, r.URL.Query().Get("node")) {
+			return
+		}
+
+		pending := signals.take(nodeID)
+		if pending == nil {
+			pending = []p2p.Signal{}
+		}
+
+		writeJSON(w, pending)
+	}))
+}
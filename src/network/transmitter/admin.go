@@ -0,0 +1,330 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// Админский HTTP API transmitter на отдельном порту. Все запросы требуют
+// заголовка "Authorization: Bearer <токен>" с токеном из -admin-token
+
+type nodeInfo struct {
+	ID           string    `json:"id"`
+	Address      string    `json:"address"`
+	Session      string    `json:"session"`
+	Connected    time.Time `json:"connected"`
+	Streams      int       `json:"streams"`
+	Room         string    `json:"room,omitempty"`
+	Spectator    bool      `json:"spectator"`
+	RelayedBytes uint64    `json:"relayedBytes"`
+	// RTTMillis и JitterMillis - по пульсу туннеля
+	RTTMillis    float64 `json:"rttMillis"`
+	JitterMillis float64 `json:"jitterMillis"`
+}
+
+type roomInfo struct {
+	ID         string   `json:"id"`
+	Players    []string `json:"players"`
+	Spectators []string `json:"spectators"`
+}
+
+type kickRequest struct {
+	Reason string `json:"reason"`
+}
+
+type banRequest struct {
+	Reason string `json:"reason"`
+	// Duration - длительность в формате time.ParseDuration, например "24h"
+	Duration string `json:"duration"`
+}
+
+type broadcastRequest struct {
+	Text string `json:"text"`
+	// Room - комната получателей; пустая - все подключенные узлы
+	Room string `json:"room,omitempty"`
+}
+
+type drainResponse struct {
+	Draining bool `json:"draining"`
+	Nodes    int  `json:"nodes"`
+}
+
+// Nodes возвращает подключенные узлы, упорядоченные по ID
+func (r *Registry) Nodes() []nodeInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nodes := make([]nodeInfo, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		nodes = append(nodes, r.nodeInfo(node))
+	}
+
+	slices.SortFunc(nodes, func(a, b nodeInfo) int { return strings.Compare(a.ID, b.ID) })
+
+	return nodes
+}
+
+func (r *Registry) Node(nodeID string) (nodeInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	node, ok := r.nodes[nodeID]
+	if !ok {
+		return nodeInfo{}, false
+	}
+
+	return r.nodeInfo(node), true
+}
+
+// nodeInfo вызывается под r.mu
+func (r *Registry) nodeInfo(node *nodeSession) nodeInfo {
+	info := nodeInfo{
+		ID:           node.id,
+		Address:      node.addr.String(),
+		Session:      node.sessionID,
+		Connected:    node.connected,
+		Streams:      node.session.NumStreams(),
+		RelayedBytes: node.relayed.Load(),
+	}
+
+	if node.heartbeat != nil {
+		stats := node.heartbeat.Stats()
+		info.RTTMillis = float64(stats.RTT) / float64(time.Millisecond)
+		info.JitterMillis = float64(stats.Jitter) / float64(time.Millisecond)
+	}
+
+	for roomID, room := range r.rooms {
+		if spectator, ok := room[node.id]; ok {
+			info.Room, info.Spectator = roomID, spectator
+			break
+		}
+	}
+
+	return info
+}
+
+// NodeIDs возвращает ID всех подключенных узлов
+func (r *Registry) NodeIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.nodes))
+	for id := range r.nodes {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// RoomList возвращает открытые комнаты, упорядоченные по ID
+func (r *Registry) RoomList() []roomInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rooms := make([]roomInfo, 0, len(r.rooms))
+	for roomID := range r.rooms {
+		rooms = append(rooms, r.roomInfo(roomID))
+	}
+
+	slices.SortFunc(rooms, func(a, b roomInfo) int { return strings.Compare(a.ID, b.ID) })
+
+	return rooms
+}
+
+func (r *Registry) Room(roomID string) (roomInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rooms[roomID]; !ok {
+		return roomInfo{}, false
+	}
+
+	return r.roomInfo(roomID), true
+}
+
+// roomInfo вызывается под r.mu
+func (r *Registry) roomInfo(roomID string) roomInfo {
+	info := roomInfo{ID: roomID, Players: []string{}, Spectators: []string{}}
+
+	for nodeID, spectator := range r.rooms[roomID] {
+		if spectator {
+			info.Spectators = append(info.Spectators, nodeID)
+		} else {
+			info.Players = append(info.Players, nodeID)
+		}
+	}
+
+	slices.Sort(info.Players)
+	slices.Sort(info.Spectators)
+
+	return info
+}
+
+func adminHandler(token string, drain *drainer) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /admin/nodes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, registry.Nodes())
+	})
+
+	mux.HandleFunc("GET /admin/nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		node, ok := registry.Node(r.PathValue("id"))
+		if !ok {
+			http.Error(w, errNotConnected.Error(), http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, node)
+	})
+
+	mux.HandleFunc("POST /admin/nodes/{id}/kick", func(w http.ResponseWriter, r *http.Request) {
+		var req kickRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := registry.Kick(r.PathValue("id"), adminReason(req.Reason)); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Бан действует и на узел, который сейчас не подключен
+	mux.HandleFunc("POST /admin/nodes/{id}/ban", func(w http.ResponseWriter, r *http.Request) {
+		var req banRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, "duration must be a positive duration like 24h", http.StatusBadRequest)
+			return
+		}
+
+		if err := registry.Ban(r.PathValue("id"), adminReason(req.Reason), duration); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /admin/rooms", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, registry.RoomList())
+	})
+
+	mux.HandleFunc("GET /admin/rooms/{id}", func(w http.ResponseWriter, r *http.Request) {
+		room, ok := registry.Room(r.PathValue("id"))
+		if !ok {
+			http.Error(w, "room does not exist", http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, room)
+	})
+
+	// Системное сообщение в чат комнаты или всем подключенным узлам
+	mux.HandleFunc("POST /admin/broadcast", func(w http.ResponseWriter, r *http.Request) {
+		var req broadcastRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if strings.TrimSpace(req.Text) == "" {
+			http.Error(w, "text is empty", http.StatusBadRequest)
+			return
+		}
+
+		recipients := registry.NodeIDs()
+
+		if req.Room != "" {
+			room, ok := registry.Room(req.Room)
+			if !ok {
+				http.Error(w, "room does not exist", http.StatusNotFound)
+				return
+			}
+
+			recipients = append(room.Players, room.Spectators...)
+		}
+
+		slog.Info("admin broadcast", logging.Room(req.Room), "recipients", len(recipients))
+
+		if err := chats.System(req.Text, recipients); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /admin/drain", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, drainResponse{Draining: registry.Draining(), Nodes: registry.Count()})
+	})
+
+	// Вывод из работы идет в фоне, как по SIGTERM, но процесс продолжает работать
+	mux.HandleFunc("POST /admin/drain", func(w http.ResponseWriter, r *http.Request) {
+		slog.Warn("admin requested drain", "nodes", registry.Count())
+		go drain.Drain()
+
+		writeJSON(w, http.StatusAccepted, drainResponse{Draining: true, Nodes: registry.Count()})
+	})
+
+	return authenticated(token, mux)
+}
+
+// authenticated пропускает только запросы с токеном token: администратора
+// или общим токеном экземпляров
+func authenticated(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			slog.Warn("rejecting unauthenticated request", "address", r.RemoteAddr, "path", r.URL.Path)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func adminReason(reason string) string {
+	if reason == "" {
+		return "by administrator"
+	}
+
+	return reason
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotConnected):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		slog.Error("admin request failed", logging.Err(err))
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("cannot write response", logging.Err(err))
+	}
+}
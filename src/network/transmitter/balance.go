@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// DefaultAnnounceInterval - как часто экземпляр сообщает свою нагрузку в каталог
+const DefaultAnnounceInterval = 5 * time.Second
+
+// balance - нагрузка экземпляра в общем каталоге, создается в main при -directory
+var balance *balancer
+
+// balancer объявляет нагрузку экземпляра в каталоге, по ней регулятор
+// выбирает transmitter для новых узлов. Заполненный экземпляр перенаправляет
+// новые узлы на другие прямо в рукопожатии
+type balancer struct {
+	locations directory.Directory
+	// self - объявление без нагрузки: ID, адреса, регион и вместимость
+	self     directory.Instance
+	interval time.Duration
+}
+
+func newBalancer(locations directory.Directory, self directory.Instance, interval time.Duration) *balancer {
+	self.Kind = directory.KindTransmitter
+
+	return &balancer{locations: locations, self: self, interval: interval}
+}
+
+// Run объявляет нагрузку каждые interval, пока не закрыт stop
+func (b *balancer) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.announce()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *balancer) announce() {
+	instance := b.self
+	instance.Load, instance.Draining = registry.Count(), registry.Draining()
+
+	// Объявление живет несколько интервалов: один пропуск не убирает экземпляр из выбора
+	if err := b.locations.Announce(instance, 3*b.interval); err != nil {
+		slog.Warn("cannot announce load", logging.Err(err))
+	}
+}
+
+// redirect возвращает адрес другого transmitter, если этот заполнен. Если
+// свободных нет, узел остается здесь: перегрузка лучше отказа
+func (b *balancer) redirect() (string, bool) {
+	if registry.Count() < b.self.Capacity {
+		return "", false
+	}
+
+	instances, err := b.locations.Instances(directory.KindTransmitter)
+	if err != nil {
+		slog.Warn("cannot list transmitters for redirect", logging.Err(err))
+		return "", false
+	}
+
+	others := make([]directory.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if instance.ID != b.self.ID {
+			others = append(others, instance)
+		}
+	}
+
+	instance, ok := directory.Pick(others, b.self.Region)
+	if !ok {
+		return "", false
+	}
+
+	return instance.Address, true
+}
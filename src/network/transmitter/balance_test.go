@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/directory"
+)
+
+// withNodes подменяет реестр на время теста реестром с n подключенными узлами
+func withNodes(t *testing.T, n int) {
+	t.Helper()
+
+	previous := registry
+	registry = NewRegistry()
+	t.Cleanup(func() { registry = previous })
+
+	for i := range n {
+		id := fmt.Sprintf("node-%d", i)
+		registry.nodes[id] = &nodeSession{id: id}
+	}
+}
+
+func TestBalancerRedirect(t *testing.T) {
+	self := directory.Instance{ID: "self", Address: "self:3001", Region: "eu", Capacity: 2}
+
+	tests := []struct {
+		name   string
+		nodes  int
+		others []directory.Instance
+		// want - адрес перенаправления; пустой - узел остается здесь
+		want string
+	}{
+		{"below capacity", 1, []directory.Instance{{ID: "a", Address: "a:3001", Region: "eu", Capacity: 10}}, ""},
+		{"full", 2, []directory.Instance{{ID: "a", Address: "a:3001", Region: "eu", Capacity: 10}}, "a:3001"},
+		{"overloaded", 5, []directory.Instance{{ID: "a", Address: "a:3001", Region: "eu", Capacity: 10}}, "a:3001"},
+		{"alone", 2, nil, ""},
+		{"others full", 2, []directory.Instance{{ID: "a", Address: "a:3001", Region: "eu", Capacity: 1, Load: 1}}, ""},
+		{"others draining", 2, []directory.Instance{{ID: "a", Address: "a:3001", Region: "eu", Capacity: 10, Draining: true}}, ""},
+		{"same region", 2, []directory.Instance{
+			{ID: "a", Address: "a:3001", Region: "us", Capacity: 10},
+			{ID: "b", Address: "b:3001", Region: "eu", Capacity: 10},
+		}, "b:3001"},
+		{"other region", 2, []directory.Instance{{ID: "a", Address: "a:3001", Region: "us", Capacity: 10}}, "a:3001"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withNodes(t, test.nodes)
+
+			locations := directory.NewMemory()
+			b := newBalancer(locations, self, time.Minute)
+
+			// Свое объявление устарело и показывает свободные места: на себя не перенаправляем
+			stale := b.self
+			stale.Load = 0
+
+			if err := locations.Announce(stale, time.Minute); err != nil {
+				t.Fatalf("Announce: %v", err)
+			}
+
+			for _, instance := range test.others {
+				instance.Kind = directory.KindTransmitter
+				if err := locations.Announce(instance, time.Minute); err != nil {
+					t.Fatalf("Announce: %v", err)
+				}
+			}
+
+			address, ok := b.redirect()
+			if ok != (test.want != "") || address != test.want {
+				t.Fatalf("redirect = %q, %v, want %q", address, ok, test.want)
+			}
+		})
+	}
+}
+
+func TestBalancerAnnounce(t *testing.T) {
+	withNodes(t, 3)
+
+	locations := directory.NewMemory()
+	b := newBalancer(locations, directory.Instance{ID: "self", Address: "self:3001", Capacity: 10}, time.Minute)
+	b.announce()
+
+	instances, err := locations.Instances(directory.KindTransmitter)
+	if err != nil {
+		t.Fatalf("Instances: %v", err)
+	}
+
+	want := directory.Instance{ID: "self", Kind: directory.KindTransmitter, Address: "self:3001", Capacity: 10, Load: 3}
+	if len(instances) != 1 || instances[0] != want {
+		t.Fatalf("Instances = %+v, want [%+v]", instances, want)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/matelq/p2pmp/src/backend/match"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+)
+
+// chats - чат матчей в режиме relay, создается в main
+var chats *chat.Relay
+
+func pushChat(nodeID string, m chat.Message) error {
+	node, err := registry.session(nodeID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(node.ctx, DefaultPushTimeout)
+	defer cancel()
+
+	_, err = node.client.PushChat(ctx, m.Contract())
+
+	return err
+}
+
+// deliverChat доставляет проверенное сообщение узлу, в том числе на другом экземпляре (chat.Deliver).
+// Зрители получают чат матча с той же задержкой, что и снимки
+func deliverChat(nodeID string, m chat.Message) error {
+	size := len(chat.Marshal(m))
+
+	deliver := func() error {
+		if err := routeChat(nodeID, m); err != nil {
+			return err
+		}
+
+		relayed(nodeID, "chat", size)
+
+		return nil
+	}
+
+	if registry.Spectator(nodeID) {
+		spectators.Push(nodeID, "chat", deliver)
+		return nil
+	}
+
+	return deliver()
+}
+
+// sendChat - обработка SendChat: отправитель - узел туннеля, получатели - участники его матча
+func sendChat(nodeID string, m chat.Message) error {
+	roomID, ok := registry.RoomOf(nodeID)
+	if !ok {
+		return fmt.Errorf("%s: %w", nodeID, match.ErrNotInMatch)
+	}
+
+	m.From = nodeID
+
+	return chats.Route(m, registry.RoomMembers(roomID))
+}
+
+// loadWords читает список запрещенных слов, по одному в строке; пустой путь - без фильтра
+func loadWords(path string) (chat.Filter, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var words []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			words = append(words, word)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return chat.NewWordFilter(words), nil
+}
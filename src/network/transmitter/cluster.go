@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+)
+
+// Несколько экземпляров transmitter: каждый записывает свои узлы в общий
+// каталог, а сообщения узлам, подключенным к другому экземпляру, пересылает
+// тому по HTTP на -cluster-address. Запросы между экземплярами требуют
+// общего токена -cluster-token
+
+// DefaultForwardTimeout ограничивает пересылку одного сообщения
+const DefaultForwardTimeout = 2 * time.Second
+
+// Виды пересылаемых сообщений
+const (
+	kindSnapshot = "snapshot"
+	kindEnvelope = "envelope"
+	kindChat     = "chat"
+)
+
+// forwarded - сообщение узлу на другом экземпляре
+type forwarded struct {
+	Node string `json:"node"`
+	Kind string `json:"kind"`
+	// Data - снимок, конверт (envelope.Marshal) или сообщение чата (chat.Marshal)
+	Data      []byte `json:"data"`
+	PlayerID  uint32 `json:"playerId,omitempty"`
+	LastInput uint32 `json:"lastInput,omitempty"`
+}
+
+// delivered - ответ экземпляра, доставившего снимок: тик, подтвержденный узлом
+type delivered struct {
+	Ack uint32 `json:"ack"`
+}
+
+// peers - клиент пересылки, создается в main, если задан -cluster-token
+var peers *clusterClient
+
+type clusterClient struct {
+	token  string
+	client *http.Client
+}
+
+func newClusterClient(token string) *clusterClient {
+	return &clusterClient{token: token, client: &http.Client{Timeout: DefaultForwardTimeout, Transport: tracing.Transport()}}
+}
+
+func (c *clusterClient) forward(instance string, message forwarded) (uint32, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, fmt.Sprintf("http://%s/cluster/deliver", instance), bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var res delivered
+		err := json.NewDecoder(resp.Body).Decode(&res)
+
+		return res.Ack, err
+	case http.StatusNoContent:
+		return 0, nil
+	case http.StatusNotFound:
+		return 0, fmt.Errorf("%s on %s: %w", message.Node, instance, errNotConnected)
+	default:
+		return 0, fmt.Errorf("transmitter %s responded %s", instance, resp.Status)
+	}
+}
+
+func routeSnapshot(nodeID string, data []byte, playerID, lastInput uint32) (uint32, error) {
+	return route(forwarded{Node: nodeID, Kind: kindSnapshot, Data: data, PlayerID: playerID, LastInput: lastInput})
+}
+
+func routeEnvelope(nodeID string, e envelope.Envelope) error {
+	_, err := route(forwarded{Node: nodeID, Kind: kindEnvelope, Data: e.Marshal()})
+	return err
+}
+
+func routeChat(nodeID string, m chat.Message) error {
+	_, err := route(forwarded{Node: nodeID, Kind: kindChat, Data: chat.Marshal(m)})
+	return err
+}
+
+// route доставляет сообщение своему узлу напрямую, а чужому - через
+// экземпляр, за которым узел записан в каталоге. Для снимка возвращает
+// тик, подтвержденный узлом
+func route(message forwarded) (uint32, error) {
+	instance, local, err := registry.Locate(message.Node)
+	if err != nil {
+		return 0, err
+	}
+
+	if local {
+		return deliverLocal(message)
+	}
+
+	if peers == nil {
+		return 0, fmt.Errorf("node %s is on transmitter %s, but forwarding is disabled: no cluster token", message.Node, instance)
+	}
+
+	ack, err := peers.forward(instance, message)
+	if err != nil {
+		return 0, err
+	}
+
+	forwardedMessages.WithLabelValues(message.Kind).Inc()
+
+	return ack, nil
+}
+
+// deliverLocal отправляет сообщение узлу по его туннелю
+func deliverLocal(message forwarded) (uint32, error) {
+	switch message.Kind {
+	case kindSnapshot:
+		return pushSnapshot(message.Node, message.Data, message.PlayerID, message.LastInput)
+	case kindEnvelope:
+		e, err := envelope.Unmarshal(message.Data)
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, pushEnvelope(message.Node, e)
+	case kindChat:
+		m, err := chat.Unmarshal(message.Data)
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, pushChat(message.Node, m)
+	default:
+		return 0, fmt.Errorf("unknown forwarded message kind %q", message.Kind)
+	}
+}
+
+// clusterHandler принимает сообщения от других экземпляров. Узел, который
+// ушел и отсюда, не ищется дальше: иначе пересылка может зациклиться
+func clusterHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /cluster/deliver", func(w http.ResponseWriter, r *http.Request) {
+		var message forwarded
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !registry.Local(message.Node) {
+			http.Error(w, errNotConnected.Error(), http.StatusNotFound)
+			return
+		}
+
+		ack, err := deliverLocal(message)
+		if err != nil {
+			slog.Warn("cannot deliver forwarded message", logging.Node(message.Node), "kind", message.Kind, logging.Err(err))
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		if ack == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, delivered{Ack: ack})
+	})
+
+	return authenticated(token, tracing.Handler(mux, "cluster"))
+}
+
+// SetDirectory подключает общий каталог; instance - адрес -cluster-address
+// этого экземпляра, по которому его находят другие. Вызывается до приема туннелей
+func (r *Registry) SetDirectory(locations directory.Directory, instance string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locations, r.instance = locations, instance
+}
+
+// Local проверяет, подключен ли узел к этому экземпляру
+func (r *Registry) Local(nodeID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.nodes[nodeID]
+
+	return ok
+}
+
+// Locate возвращает экземпляр узла; local - узел подключен сюда
+func (r *Registry) Locate(nodeID string) (instance string, local bool, err error) {
+	if r.Local(nodeID) {
+		return r.instance, true, nil
+	}
+
+	instance, err = r.locations.Lookup(nodeID)
+	if errors.Is(err, directory.ErrNotFound) {
+		return "", false, fmt.Errorf("%s: %w", nodeID, errNotConnected)
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return instance, instance == r.instance, nil
+}
+
+// register записывает узел в каталог за этим экземпляром
+func (r *Registry) register(node *nodeSession) {
+	if err := r.locations.Register(node.id, r.instance, directory.DefaultTTL); err != nil {
+		slog.WarnContext(node.ctx, "cannot register node in directory", logging.Err(err))
+	}
+}
+
+// unregister удаляет узел из каталога и из комнат rooms, которые он покинул,
+// а также из комнаты другого экземпляра, если узел состоит в ней
+func (r *Registry) unregister(node *nodeSession, rooms []string) {
+	// Узел, уже переподключившийся к другому экземпляру, остается в своей комнате
+	instance, err := r.locations.Lookup(node.id)
+	if (err == nil && instance == r.instance) || errors.Is(err, directory.ErrNotFound) {
+		roomID, err := r.locations.RoomOf(node.id)
+
+		switch {
+		case err == nil && !slices.Contains(rooms, roomID):
+			rooms = append(rooms, roomID)
+		case err != nil && !errors.Is(err, directory.ErrNotFound):
+			slog.WarnContext(node.ctx, "cannot look up room in directory", logging.Err(err))
+		}
+	} else if err != nil {
+		slog.WarnContext(node.ctx, "cannot look up node in directory", logging.Err(err))
+	}
+
+	if err := r.locations.Unregister(node.id, r.instance); err != nil {
+		slog.WarnContext(node.ctx, "cannot unregister node in directory", logging.Err(err))
+	}
+
+	for _, roomID := range rooms {
+		if err := r.locations.LeaveRoom(roomID, node.id); err != nil {
+			slog.WarnContext(node.ctx, "cannot leave room in directory", logging.Room(roomID), logging.Err(err))
+		}
+	}
+}
+
+// RefreshLocations продлевает записи подключенных узлов в каталоге, пока не закрыт stop
+func (r *Registry) RefreshLocations(stop <-chan struct{}) {
+	ticker := time.NewTicker(directory.DefaultTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		nodes := make([]*nodeSession, 0, len(r.nodes))
+		for _, node := range r.nodes {
+			nodes = append(nodes, node)
+		}
+		r.mu.Unlock()
+
+		for _, node := range nodes {
+			r.register(node)
+		}
+	}
+}
+
+func startCluster(addr, token string) {
+	slog.Info("launching cluster HTTP server", "address", addr)
+
+	// nolint: gosec
+	if err := http.ListenAndServe(addr, limits.clusterHandler(clusterHandler(token))); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"google.golang.org/grpc"
+)
+
+// DefaultDrainTimeout - сколько узлам дается на переход к другому transmitter
+const DefaultDrainTimeout = 30 * time.Second
+
+// pushMigrate просит узел перейти на address до deadline. Узел отвечает сразу,
+// а переподключается в фоне
+func pushMigrate(nodeID, address string, deadline time.Time) error {
+	node, err := registry.session(nodeID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultPushTimeout)
+	defer cancel()
+
+	_, err = node.client.Migrate(ctx, &contracts.MigrateRequest{Address: address, DeadlineUnixMillis: deadline.UnixMilli()})
+
+	return err
+}
+
+// drainer плавно выводит transmitter из работы: перестает принимать туннели
+// и gRPC-вызовы, просит узлы перейти на target и ждет их ухода до timeout,
+// после чего закрывает оставшиеся сессии
+type drainer struct {
+	listener   net.Listener
+	grpcServer *grpc.Server
+	// target - адрес transmitter, на который уходят узлы; пустой - узел выбирает сам
+	target  string
+	timeout time.Duration
+
+	once sync.Once
+	done chan struct{}
+}
+
+func newDrainer(listener net.Listener, grpcServer *grpc.Server, target string, timeout time.Duration) *drainer {
+	return &drainer{listener: listener, grpcServer: grpcServer, target: target, timeout: timeout, done: make(chan struct{})}
+}
+
+// Drain выполняет вывод из работы один раз и блокируется до его завершения
+func (d *drainer) Drain() {
+	d.once.Do(func() {
+		go d.drain()
+	})
+
+	<-d.done
+}
+
+func (d *drainer) drain() {
+	defer close(d.done)
+
+	deadline := time.Now().Add(d.timeout)
+	slog.Warn("draining", "nodes", registry.Count(), "target", d.target, "deadline", deadline)
+
+	registry.Drain()
+	readiness.Drain()
+
+	// Регулятор перестает выбирать этот экземпляр сразу, не дожидаясь очередного объявления
+	if balance != nil {
+		balance.announce()
+	}
+
+	if err := d.listener.Close(); err != nil {
+		slog.Warn("cannot close tunnel listener", logging.Err(err))
+	}
+
+	// GracefulStop ждет завершения идущих вызовов, поэтому ограничен тем же сроком
+	stopped := make(chan struct{})
+	go func() {
+		d.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	for _, nodeID := range registry.NodeIDs() {
+		if err := pushMigrate(nodeID, d.target, deadline); err != nil {
+			slog.Warn("cannot ask node to migrate", logging.Node(nodeID), logging.Err(err))
+		}
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+wait:
+	for registry.Count() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	if nodes := registry.CloseAll(); nodes > 0 {
+		slog.Warn("drain deadline passed, closed remaining tunnels", "nodes", nodes)
+	}
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		d.grpcServer.Stop()
+	}
+
+	slog.Info("drained")
+}
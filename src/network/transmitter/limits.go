@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Ограничения входящего трафика: соединения с одного IP на порту туннелей,
+// частота и размер вызовов узла, частота сообщений матча по типу. Кто
+// слишком часто упирается в ограничения, попадает в штрафной бокс - бан
+// узла и его IP через Registry.Ban
+
+// MaxMessageSize - самый большой вызов или сообщение матча; больше только
+// снимки, а их отправляет сам transmitter
+const MaxMessageSize = 64 << 10
+
+type rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+type limitConfig struct {
+	// ConnsPerIP - одновременные туннели с одного IP
+	ConnsPerIP int
+	// Conns - новые соединения с одного IP
+	Conns rate
+	// RPC - все вызовы узла вместе
+	RPC rate
+	// HTTP - запросы с одного IP к API аккаунтов и админке
+	HTTP rate
+	// Login - вход и регистрация с одного IP: каждая попытка стоит bcrypt
+	Login rate
+	// Cluster - запросы с одного IP на порт кластера, пересылка идет по
+	// запросу на сообщение, поэтому запас большой
+	Cluster rate
+	Penalty ratelimit.PenaltyConfig
+}
+
+func defaultLimitConfig() limitConfig {
+	return limitConfig{
+		ConnsPerIP: 8,
+		Conns:      rate{PerSecond: 1, Burst: 10},
+		RPC:        rate{PerSecond: 200, Burst: 400},
+		HTTP:       rate{PerSecond: 10, Burst: 20},
+		Login:      rate{PerSecond: 0.2, Burst: 5},
+		Cluster:    rate{PerSecond: 2000, Burst: 4000},
+		Penalty:    ratelimit.DefaultPenaltyConfig(),
+	}
+}
+
+// envelopeRates - частота сообщений матча, которые узел шлет transmitter.
+// Узел шлет один ввод за тик независимо от частоты кадров рендера
+// (gateway.RunInput), запас покрывает пачки после сетевых задержек.
+// Подтверждения снимков приходят ответом на PushSnapshot, а сообщения
+// lockstep и хоста идут напрямую между узлами, поэтому их здесь нет
+var envelopeRates = map[envelope.Type]rate{
+	envelope.TypeInput: {PerSecond: 2 * game.TickRate, Burst: 2 * game.TickRate},
+	// Чат дополнительно ограничивает chat.Relay
+	envelope.TypeChat: {PerSecond: 5, Burst: 10},
+}
+
+// methodTypes - вызовы transmitter.proto, которые несут сообщения матча,
+// у каждого типа должна быть частота в envelopeRates
+var methodTypes = map[string]envelope.Type{
+	"/common.transmitter.Transmitter/SendInput": envelope.TypeInput,
+	"/common.transmitter.Transmitter/SendChat":  envelope.TypeChat,
+}
+
+// Где и почему отклонен входящий трафик, для метрики rejected_total
+const (
+	rejectConnection = "connection"
+	rejectRPC        = "rpc"
+	rejectEnvelope   = "envelope"
+
+	reasonConns  = "ip_connections"
+	reasonRate   = "rate"
+	reasonSize   = "size"
+	reasonType   = "type"
+	reasonBanned = "banned"
+)
+
+// limits - ограничения, создаются в main по флагам
+var limits *limiter
+
+type limiter struct {
+	conns    *ratelimit.Conns
+	connRate *ratelimit.Limiter
+	rpc      *ratelimit.Limiter
+	// envelopes - по ограничителю на тип, заполняется при создании и дальше только читается
+	envelopes map[envelope.Type]*ratelimit.Limiter
+	penalty   *ratelimit.PenaltyBox
+	// http, login и cluster - запросы к HTTP API по IP
+	http    *ratelimit.Limiter
+	login   *ratelimit.Limiter
+	cluster *ratelimit.Limiter
+}
+
+func newLimiter(config limitConfig) *limiter {
+	l := &limiter{
+		conns:     ratelimit.NewConns(config.ConnsPerIP),
+		connRate:  ratelimit.NewLimiter(config.Conns.PerSecond, config.Conns.Burst),
+		rpc:       ratelimit.NewLimiter(config.RPC.PerSecond, config.RPC.Burst),
+		envelopes: map[envelope.Type]*ratelimit.Limiter{},
+		penalty:   ratelimit.NewPenaltyBox(config.Penalty),
+		http:      ratelimit.NewLimiter(config.HTTP.PerSecond, config.HTTP.Burst),
+		login:     ratelimit.NewLimiter(config.Login.PerSecond, config.Login.Burst),
+		cluster:   ratelimit.NewLimiter(config.Cluster.PerSecond, config.Cluster.Burst),
+	}
+
+	for t, r := range envelopeRates {
+		l.envelopes[t] = ratelimit.NewLimiter(r.PerSecond, r.Burst)
+	}
+
+	return l
+}
+
+// AcceptConn проверяет новое соединение на порту туннелей. Если соединение
+// принято, release нужно вызвать после его закрытия
+func (l *limiter) AcceptConn(addr net.Addr) (release func(), ok bool) {
+	ip := host(addr)
+
+	if !l.connRate.Allow(ip, time.Now()) {
+		l.reject(ip, "", rejectConnection, reasonRate)
+		return nil, false
+	}
+
+	if !l.conns.Acquire(ip) {
+		l.reject(ip, "", rejectConnection, reasonConns)
+		return nil, false
+	}
+
+	return func() { l.conns.Release(ip) }, true
+}
+
+// AllowEnvelope проверяет сообщение матча типа t размером size от узла
+func (l *limiter) AllowEnvelope(nodeID string, t envelope.Type, size int) error {
+	if size > MaxMessageSize {
+		l.reject("", nodeID, rejectEnvelope, reasonSize)
+		return status.Errorf(codes.ResourceExhausted, "%s message is larger than %d bytes", t, MaxMessageSize)
+	}
+
+	limiter, ok := l.envelopes[t]
+	if !ok {
+		l.reject("", nodeID, rejectEnvelope, reasonType)
+		return status.Errorf(codes.InvalidArgument, "%s messages are not accepted", t)
+	}
+
+	if !limiter.Allow(nodeID, time.Now()) {
+		l.reject("", nodeID, rejectEnvelope, reasonRate)
+		return status.Errorf(codes.ResourceExhausted, "too many %s messages", t)
+	}
+
+	return nil
+}
+
+// allowCall проверяет вызов gRPC. Вызовы через туннель считаются по узлу,
+// прямые вызовы на :3000 - по IP
+func (l *limiter) allowCall(ctx context.Context, method string, req any) error {
+	nodeID, ip := caller(ctx)
+
+	key := nodeID
+	if key == "" {
+		key = ip
+
+		if registry.BannedAddress(ip) {
+			l.reject(ip, "", rejectRPC, reasonBanned)
+			return status.Error(codes.PermissionDenied, "address is banned")
+		}
+	}
+
+	if !l.rpc.Allow(key, time.Now()) {
+		l.reject(ip, nodeID, rejectRPC, reasonRate)
+		return status.Error(codes.ResourceExhausted, "too many calls")
+	}
+
+	t, ok := methodTypes[method]
+	if !ok || nodeID == "" {
+		return nil
+	}
+
+	size := 0
+	if message, ok := req.(proto.Message); ok {
+		size = proto.Size(message)
+	}
+
+	return l.AllowEnvelope(nodeID, t, size)
+}
+
+// reject учитывает отказ и нарушение. Узел, набравший штрафной бокс,
+// банится вместе с IP, адрес без узла - только по IP
+func (l *limiter) reject(ip, nodeID, path, reason string) {
+	rejected.WithLabelValues(path, reason).Inc()
+
+	key := nodeID
+	if key == "" {
+		key = ip
+	}
+
+	if !l.penalty.Strike(key, time.Now()) {
+		return
+	}
+
+	if nodeID != "" {
+		registry.Ban(nodeID, "flooding: "+path+" "+reason, l.penalty.Duration()) //nolint:errcheck
+		return
+	}
+
+	slog.Warn("address is flooding, banning it", "address", ip, "path", path, "reason", reason, "duration", l.penalty.Duration())
+	registry.BanAddress(ip, l.penalty.Duration())
+}
+
+// Forget удаляет состояние отключившегося узла
+func (l *limiter) Forget(nodeID string) {
+	l.rpc.Forget(nodeID)
+
+	for _, limiter := range l.envelopes {
+		limiter.Forget(nodeID)
+	}
+}
+
+// Run раз в минуту удаляет состояние адресов, которые давно не приходили
+func (l *limiter) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			l.connRate.Prune(now)
+			l.rpc.Prune(now)
+			l.penalty.Prune(now)
+			l.http.Prune(now)
+			l.login.Prune(now)
+			l.cluster.Prune(now)
+		}
+	}
+}
+
+// accountsHandler ограничивает API аккаунтов по IP, вход и регистрацию - строже
+func (l *limiter) accountsHandler(accounts http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /account/login", l.login.Handler(accounts))
+	mux.Handle("POST /account/register", l.login.Handler(accounts))
+	mux.Handle("/", accounts)
+
+	return l.http.Handler(mux)
+}
+
+// adminHandler ограничивает админку по IP, в том числе подбор токена
+func (l *limiter) adminHandler(admin http.Handler) http.Handler {
+	return l.http.Handler(admin)
+}
+
+// clusterHandler ограничивает порт кластера по IP
+func (l *limiter) clusterHandler(cluster http.Handler) http.Handler {
+	return l.cluster.Handler(cluster)
+}
+
+// limitOptions - перехватчики ограничений и предел размера для gRPC-серверов transmitter.
+// Вызовы больше MaxMessageSize отклоняет сам gRPC
+func limitOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(MaxMessageSize),
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := limits.allowCall(ctx, info.FullMethod, req); err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := limits.allowCall(ss.Context(), info.FullMethod, nil); err != nil {
+				return err
+			}
+
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// caller возвращает узел, если вызов пришел через его туннель, и IP вызывающего
+func caller(ctx context.Context) (nodeID, ip string) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", ""
+	}
+
+	if addr, ok := p.Addr.(nodeAddr); ok {
+		return addr.nodeID, host(addr.Addr)
+	}
+
+	return "", host(p.Addr)
+}
+
+// tunnelListener отдает gRPC-серверу потоки туннеля с адресом, подписанным
+// ID узла, чтобы ограничения вызовов считались по узлу, а не по адресу
+type tunnelListener struct {
+	*yamux.Session
+	nodeID string
+}
+
+func (l tunnelListener) Accept() (net.Conn, error) {
+	conn, err := l.Session.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return tunnelConn{Conn: conn, addr: nodeAddr{Addr: conn.RemoteAddr(), nodeID: l.nodeID}}, nil
+}
+
+type tunnelConn struct {
+	net.Conn
+	addr nodeAddr
+}
+
+func (c tunnelConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+type nodeAddr struct {
+	net.Addr
+	nodeID string
+}
@@ -2,30 +2,122 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/hashicorp/yamux"
 	"github.com/matelq/p2pmp/examples/yamux/common"
+	"github.com/matelq/p2pmp/src/backend/account"
+	"github.com/matelq/p2pmp/src/backend/match"
+	"github.com/matelq/p2pmp/src/backend/stats"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/handshake"
+	"github.com/matelq/p2pmp/src/network/common/health"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	nodepb "github.com/matelq/p2pmp/src/network/common/node"
+	"github.com/matelq/p2pmp/src/network/common/tracing"
+	transmitterpb "github.com/matelq/p2pmp/src/network/common/transmitter"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-func handleConn(conn *grpc.ClientConn, n int) {
-	defer conn.Close()
+var registry = NewRegistry()
+
+// Условия готовности transmitter для grpc.health.v1
+const (
+	readyGRPC    = "grpc"
+	readyTunnels = "tunnels"
+	// readyBackend - аккаунты, чат и ретрансляция снимков подключены
+	readyBackend = "backend"
+)
+
+var readiness = health.NewReadiness(readyGRPC, readyTunnels, readyBackend)
+
+// tunnelServer обслуживает вызовы, которые узел делает через свой туннель:
+// сервис transmitter.proto и проверки состояния, по которым узел замечает зависший transmitter раньше TCP
+var tunnelServer = newTunnelServer()
+
+// heartbeats - пульс туннелей, задается флагами
+var heartbeats = heartbeat.DefaultConfig()
+
+// rateLimits - ограничения входящего трафика, задается флагами, см. limits.go
+var rateLimits = defaultLimitConfig()
+
+// matchConfig - настройки матчей, задается флагами
+var matchConfig = match.DefaultConfig()
+
+// snapshots доставляет снимки бэкенда узлам (match.Sender), создается в main
+var snapshots *relay
+
+// matches - матчи в комнатах этого экземпляра, создается в main.
+// Registry - и комнаты матчей, и исполнитель наказаний античита
+var matches *match.Manager
+
+// DefaultPushTimeout ограничивает вызов узла через его туннель
+const DefaultPushTimeout = 2 * time.Second
+
+// pushSnapshot отправляет снимок узлу и возвращает тик, который узел подтвердил
+func pushSnapshot(nodeID string, data []byte, playerID, lastInput uint32) (uint32, error) {
+	node, err := registry.session(nodeID)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(node.ctx, DefaultPushTimeout)
+	defer cancel()
+
+	ack, err := node.client.PushSnapshot(ctx, &contracts.WorldSnapshot{Data: data, LastProcessedInput: lastInput, PlayerId: playerID})
+	if err != nil {
+		return 0, err
+	}
+
+	return ack.Tick, nil
+}
+
+func pushEnvelope(nodeID string, e envelope.Envelope) error {
+	node, err := registry.session(nodeID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(node.ctx, DefaultPushTimeout)
+	defer cancel()
+
+	_, err = node.client.PushEnvelope(ctx, &contracts.Envelope{Type: uint32(e.Type), From: e.From, Payload: e.Payload})
+
+	return err
+}
+
+func handleConn(node *nodeSession) {
+	defer registry.Remove(node)
+	defer node.session.Close()
+	defer node.clientConn.Close()
 
 	for {
-		client := common.NewClientServerClient(conn)
-		res, err := client.CallFuncOnClient(context.Background(), &common.Text{Data: fmt.Sprintf("Message from server to client %d", n)})
+		client := common.NewClientServerClient(node.clientConn)
+		res, err := client.CallFuncOnClient(node.ctx, &common.Text{Data: fmt.Sprintf("Message from server to client %s", node.id)})
 
+		// узел отключился или был выгнан
 		if err != nil {
-			panic(err)
+			slog.InfoContext(node.ctx, "node is gone", logging.Err(err))
+			return
 		}
 
-		log.Printf("Response: %s", res.Data)
+		slog.DebugContext(node.ctx, "response", "data", res.Data)
 		time.Sleep(time.Second * 2)
 	}
 }
@@ -35,23 +127,39 @@ type ServerServerImpl struct {
 }
 
 func (server ServerServerImpl) CallFuncOnServer(context context.Context, text *common.Text) (*common.Text, error) {
-	log.Printf("CallFuncOnServer called: %s", text.Data)
+	slog.DebugContext(context, "CallFuncOnServer called", "data", text.Data)
 
 	return &common.Text{Data: fmt.Sprintf("Echo: %s", text.Data)}, nil
 }
 
-func startSever() {
+func newServer() *grpc.Server {
+	options := append(metrics.ServerOptions(), tracing.ServerOptions()...)
+	grpcServer := grpc.NewServer(append(options, limitOptions()...)...)
+	serverServerImpl := &ServerServerImpl{}
+	common.RegisterServerServerServer(grpcServer, serverServerImpl)
+	readiness.Register(grpcServer)
+
+	return grpcServer
+}
+
+func newTunnelServer() *grpc.Server {
+	options := append(metrics.ServerOptions(), tracing.ServerOptions()...)
+	grpcServer := grpc.NewServer(append(options, limitOptions()...)...)
+	transmitterpb.RegisterTransmitterServer(grpcServer, transmitterServer{})
+	readiness.Register(grpcServer)
+
+	return grpcServer
+}
+
+func startSever(grpcServer *grpc.Server) {
 	listener, err := net.Listen("tcp", ":3000")
 
 	if err != nil {
 		panic(err)
 	}
 
-	grpcServer := grpc.NewServer()
-	serverServerImpl := &ServerServerImpl{}
-	common.RegisterServerServerServer(grpcServer, serverServerImpl)
-
-	log.Println("launching TPC GRPC server...")
+	slog.Info("launching gRPC server", "address", listener.Addr().String())
+	readiness.Set(readyGRPC, true)
 	err = grpcServer.Serve(listener)
 
 	if err != nil {
@@ -59,46 +167,338 @@ func startSever() {
 	}
 }
 
+// serveTunnels принимает туннели узлов, пока listener не закрыт
+func serveTunnels(listener net.Listener, accounts *account.Service) {
+	for {
+		conn, err := listener.Accept()
+
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+
+		if err != nil {
+			panic(err)
+		}
+
+		if registry.Banned(conn.RemoteAddr()) {
+			slog.Warn("rejecting banned address", "address", conn.RemoteAddr().String())
+			conn.Close()
+			continue
+		}
+
+		release, ok := limits.AcceptConn(conn.RemoteAddr())
+		if !ok {
+			slog.Debug("rejecting connection over limit", "address", conn.RemoteAddr().String())
+			conn.Close()
+			continue
+		}
+
+		go func() {
+			defer release()
+			accept(conn, accounts)
+		}()
+	}
+}
+
 func main() {
-	go startSever()
+	accountsPath := flag.String("accounts", "accounts.db", "Path to the accounts database. Ignored with -directory: accounts and sessions are kept in the shared directory.")
+	accountsAddr := flag.String("accounts-address", ":3003", "Address that the accounts HTTP API is hosted on.")
+	metricsAddr := flag.String("metrics-address", ":9100", "Address that the Prometheus /metrics endpoint is hosted on.")
+	traceExporter := flag.String("trace", tracing.ExporterNone, "Where to export traces: none, stdout or otlp (OTEL_EXPORTER_OTLP_ENDPOINT).")
+	chatWords := flag.String("chat-words", "", "Path to a file with words hidden in chat, one per line.")
+	adminAddr := flag.String("admin-address", "localhost:3004", "Address that the admin HTTP API is hosted on.")
+	adminToken := flag.String("admin-token", os.Getenv("P2PMP_ADMIN_TOKEN"), "Bearer token of the admin API, P2PMP_ADMIN_TOKEN by default. The API is disabled without a token.")
+	drainTarget := flag.String("drain-target", "", "Address of the transmitter that nodes move to when this one drains. Empty lets nodes choose.")
+	drainTimeout := flag.Duration("drain-timeout", DefaultDrainTimeout, "How long nodes are given to move away on SIGTERM or admin drain.")
+	flag.DurationVar(&matchConfig.SpectatorDelay, "spectator-delay", matchConfig.SpectatorDelay, "How long everything sent to spectators is held back, so they cannot tip players off.")
+	resultsPath := flag.String("results", "results.db", "Path to the database of finished match results. Empty disables saving results.")
+	flag.DurationVar(&heartbeats.Interval, "heartbeat-interval", heartbeats.Interval, "How often tunnels are checked with a heartbeat.")
+	flag.IntVar(&heartbeats.Misses, "heartbeat-misses", heartbeats.Misses, "Missed heartbeats in a row after which a tunnel is closed.")
+	directorySpec := flag.String("directory", "", "Shared node directory of all transmitter instances: redis://[:password@]host:port. Empty runs a single instance.")
+	clusterAddr := flag.String("cluster-address", ":3006", "Address that other transmitter instances forward messages to.")
+	clusterInstance := flag.String("cluster-instance", "", "Address of -cluster-address reachable by other instances, e.g. 10.0.0.5:3006. Required with -directory.")
+	publicAddr := flag.String("public-address", "", "Tunnel address that nodes redirected here connect to. Defaults to the -cluster-instance host with port 3001.")
+	region := flag.String("region", "", "Region of this instance; nodes are preferably balanced within their region.")
+	capacity := flag.Int("capacity", directory.DefaultCapacity, "Nodes this instance accepts before redirecting new ones to other instances.")
+	clusterToken := flag.String("cluster-token", os.Getenv("P2PMP_CLUSTER_TOKEN"), "Bearer token shared by transmitter instances, P2PMP_CLUSTER_TOKEN by default. Required with -directory.")
+	flag.IntVar(&rateLimits.ConnsPerIP, "conns-per-ip", rateLimits.ConnsPerIP, "Open tunnels allowed from one IP.")
+	flag.Float64Var(&rateLimits.Conns.PerSecond, "conn-rate", rateLimits.Conns.PerSecond, "New tunnel connections per second allowed from one IP after a burst.")
+	flag.IntVar(&rateLimits.Conns.Burst, "conn-burst", rateLimits.Conns.Burst, "New tunnel connections one IP may open at once.")
+	flag.Float64Var(&rateLimits.RPC.PerSecond, "rpc-rate", rateLimits.RPC.PerSecond, "gRPC calls per second allowed from one node after a burst.")
+	flag.IntVar(&rateLimits.RPC.Burst, "rpc-burst", rateLimits.RPC.Burst, "gRPC calls one node may make at once.")
+	flag.Float64Var(&rateLimits.HTTP.PerSecond, "http-rate", rateLimits.HTTP.PerSecond, "Accounts and admin API requests per second allowed from one IP after a burst.")
+	flag.IntVar(&rateLimits.HTTP.Burst, "http-burst", rateLimits.HTTP.Burst, "Accounts and admin API requests one IP may make at once.")
+	flag.Float64Var(&rateLimits.Login.PerSecond, "login-rate", rateLimits.Login.PerSecond, "Login and register attempts per second allowed from one IP after a burst.")
+	flag.IntVar(&rateLimits.Login.Burst, "login-burst", rateLimits.Login.Burst, "Login and register attempts one IP may make at once.")
+	flag.Float64Var(&rateLimits.Cluster.PerSecond, "cluster-rate", rateLimits.Cluster.PerSecond, "Cluster requests per second allowed from one IP after a burst.")
+	flag.IntVar(&rateLimits.Cluster.Burst, "cluster-burst", rateLimits.Cluster.Burst, "Cluster requests one IP may make at once.")
+	flag.IntVar(&rateLimits.Penalty.Strikes, "penalty-strikes", rateLimits.Penalty.Strikes, "Rejections within -penalty-window after which a node and its IP are banned.")
+	flag.DurationVar(&rateLimits.Penalty.Window, "penalty-window", rateLimits.Penalty.Window, "Window in which rejections are counted for the penalty box.")
+	flag.DurationVar(&rateLimits.Penalty.Duration, "penalty-duration", rateLimits.Penalty.Duration, "How long flooding nodes and IPs stay banned.")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error.")
+	logFormat := flag.String("log-format", logging.FormatText, "Log output format: text or json.")
+	flag.Parse()
 
-	log.Println("launching TPC server...")
+	if err := logging.Setup(*logLevel, *logFormat); err != nil {
+		panic(err)
+	}
 
-	listener, err := net.Listen("tcp", ":3001")
+	shutdown, err := tracing.Setup(context.Background(), "transmitter", *traceExporter)
 
 	if err != nil {
 		panic(err)
 	}
 
-	defer listener.Close()
+	defer shutdown(context.Background()) //nolint:errcheck
 
-	n := 0
+	accounts, closeAccounts, err := openAccounts(*accountsPath, *directorySpec)
 
-	for {
-		log.Println("waiting TCP connections...")
+	if err != nil {
+		panic(err)
+	}
 
-		conn, err := listener.Accept()
+	defer closeAccounts()
+
+	filter, err := loadWords(*chatWords)
+
+	if err != nil {
+		panic(err)
+	}
+
+	chats = chat.NewRelay(chat.DefaultConfig(), filter, deliverChat)
+
+	spectators = newSpectatorFeed(matchConfig.SpectatorDelay)
+	snapshots = newRelay(registry, spectators, routeSnapshot, func(nodeID string, tick uint32) error { return matches.Ack(nodeID, tick) })
+	matches = match.NewManager(matchConfig, snapshots, registry, registry)
+
+	if *resultsPath != "" {
+		results, err := stats.OpenBolt(*resultsPath)
 
 		if err != nil {
 			panic(err)
 		}
 
-		yamuxSession, err := yamux.Client(conn, yamux.DefaultConfig())
+		defer results.Close()
+
+		matches.SetStore(results)
+	}
+
+	limits = newLimiter(rateLimits)
+	go limits.Run(nil)
+
+	locations, err := directory.Open(*directorySpec, DefaultForwardTimeout)
+
+	if err != nil {
+		panic(err)
+	}
+
+	if *directorySpec != "" {
+		if *clusterInstance == "" || *clusterToken == "" {
+			panic("-cluster-instance and -cluster-token are required with -directory")
+		}
+
+		registry.SetDirectory(locations, *clusterInstance)
+		peers = newClusterClient(*clusterToken)
+
+		address := *publicAddr
+		if address == "" {
+			host, _, err := net.SplitHostPort(*clusterInstance)
+			if err != nil {
+				panic(err)
+			}
+
+			address = net.JoinHostPort(host, "3001")
+		}
+
+		balance = newBalancer(locations, directory.Instance{ID: *clusterInstance, Address: address, Region: *region, Capacity: *capacity}, DefaultAnnounceInterval)
+
+		go startCluster(*clusterAddr, *clusterToken)
+		go registry.RefreshLocations(nil)
+		go balance.Run(nil)
+	}
+
+	registry.OnDisconnect(func(nodeID, _ string) { matches.Leave(nodeID) })
+	registry.OnDisconnect(func(nodeID, _ string) { snapshots.Forget(nodeID) })
+	registry.OnDisconnect(func(nodeID, _ string) { chats.Forget(nodeID) })
+	registry.OnDisconnect(func(nodeID, _ string) { limits.Forget(nodeID) })
+
+	listener, err := net.Listen("tcp", ":3001")
+
+	if err != nil {
+		panic(err)
+	}
+
+	grpcServer := newServer()
+	drain := newDrainer(listener, grpcServer, *drainTarget, *drainTimeout)
+
+	go startSever(grpcServer)
+	go startMetrics(*metricsAddr)
+	go startAccounts(*accountsAddr, accounts)
+	go startAdmin(*adminAddr, *adminToken, drain)
+	go spectators.Run(nil)
+
+	readiness.Set(readyBackend, true)
 
+	slog.Info("launching TCP server", "address", listener.Addr().String())
+	readiness.Set(readyTunnels, true)
+
+	go serveTunnels(listener, accounts)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	<-ctx.Done()
+	// Повторный сигнал завершает процесс сразу
+	stop()
+
+	drain.Drain()
+	// Узлы ушли, оставшиеся матчи завершаются с сохранением итогов
+	matches.Shutdown()
+}
+
+func startMetrics(addr string) {
+	if err := metrics.Serve(addr); err != nil {
+		panic(err)
+	}
+}
+
+// openAccounts открывает аккаунты: без общего каталога - база path и сессии
+// в памяти, с каталогом - аккаунты и сессии в его Redis, чтобы узел входил на
+// одном экземпляре, а рукопожатие проходил на любом
+func openAccounts(path, directorySpec string) (*account.Service, func() error, error) {
+	if directorySpec == "" {
+		store, err := account.OpenBolt(path)
 		if err != nil {
-			panic(err)
+			return nil, nil, err
 		}
 
-		log.Println("launching gRPC server over TCP connection")
+		return account.NewService(store, account.DefaultSessionTTL), store.Close, nil
+	}
+
+	conn, err := directory.OpenRedis(directorySpec, DefaultForwardTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shared := account.NewRedis(conn)
+	accounts := account.NewService(shared, account.DefaultSessionTTL)
+	accounts.SetSessions(shared)
+
+	return accounts, conn.Close, nil
+}
+
+func startAccounts(addr string, accounts *account.Service) {
+	slog.Info("launching accounts HTTP server", "address", addr)
 
-		clientConn, err := grpc.NewClient(":3000", grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return yamuxSession.Open() }))
+	// nolint: gosec
+	if err := http.ListenAndServe(addr, limits.accountsHandler(account.Handler(accounts))); err != nil {
+		panic(err)
+	}
+}
+
+func startAdmin(addr, token string, drain *drainer) {
+	if token == "" {
+		slog.Warn("admin API is disabled: no admin token")
+		return
+	}
+
+	slog.Info("launching admin HTTP server", "address", addr)
+
+	// nolint: gosec
+	if err := http.ListenAndServe(addr, limits.adminHandler(adminHandler(token, drain))); err != nil {
+		panic(err)
+	}
+}
 
+// accept проводит рукопожатие: ID узла - это ID аккаунта, чей токен предъявлен
+func accept(conn net.Conn, accounts *account.Service) {
+	nodeID, session, err := handshake.Accept(conn, handshake.DefaultTimeout, func(token string) (string, error) {
+		a, err := accounts.Authenticate(token)
 		if err != nil {
-			panic(err)
+			return "", err
+		}
+
+		if registry.BannedNode(a.ID) {
+			return "", fmt.Errorf("node %s is banned", a.ID)
+		}
+
+		if registry.Draining() {
+			return "", errDraining
 		}
 
-		go handleConn(clientConn, n)
-		n++
+		if balance != nil {
+			if address, ok := balance.redirect(); ok {
+				slog.Info("transmitter is full, redirecting node", logging.Node(a.ID), "to", address)
+				redirectedNodes.Inc()
+
+				return "", &handshake.RedirectError{Address: address}
+			}
+		}
+
+		return a.ID, nil
+	})
+
+	var redirect *handshake.RedirectError
+	if err != nil && !errors.As(err, &redirect) {
+		slog.Warn("rejecting connection", "address", conn.RemoteAddr().String(), logging.Err(err))
 	}
+
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	ctx := logging.With(context.Background(), logging.Node(nodeID), logging.Session(session), logging.Transport(metrics.TransportRelay))
+
+	yamuxSession, err := yamux.Client(conn, yamux.DefaultConfig())
+
+	if err != nil {
+		slog.WarnContext(ctx, "cannot start yamux session", logging.Err(err))
+		conn.Close()
+		return
+	}
+
+	slog.InfoContext(ctx, "node connected", "address", conn.RemoteAddr().String())
+
+	// Обратный туннель: transmitter - клиент gRPC-сервера на узле, вызовы к узлу трассируются так же
+	options := append(metrics.DialOptions(), tracing.DialOptions()...)
+	options = append(options, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return yamuxSession.Open() }))
+	clientConn, err := grpc.NewClient(":3000", options...)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot create gRPC client", logging.Err(err))
+		yamuxSession.Close()
+		return
+	}
+
+	node := &nodeSession{
+		id:         nodeID,
+		addr:       conn.RemoteAddr(),
+		session:    yamuxSession,
+		clientConn: clientConn,
+		client:     nodepb.NewNodeClient(clientConn),
+		ctx:        ctx,
+		sessionID:  session,
+		connected:  time.Now(),
+	}
+	node.heartbeat = heartbeat.New(heartbeats, node.ping, func() {
+		slog.WarnContext(ctx, "node missed heartbeats, closing tunnel", "misses", heartbeats.Misses)
+		registry.Disconnect(node, "heartbeat timeout")
+	})
+
+	registry.Add(node)
+	go node.heartbeat.Run(yamuxSession.CloseChan())
+
+	// Узел сразу попадает в матч со свободным местом, зрителем он становится через Spectate
+	if joined, err := matches.Assign(nodeID); err != nil {
+		slog.WarnContext(ctx, "cannot assign node to a match", logging.Err(err))
+	} else {
+		slog.InfoContext(ctx, "node joined match", logging.Room(joined.ID))
+	}
+
+	// Вызовы узла к transmitter идут по потокам, которые открывает узел
+	go tunnelServer.Serve(tunnelListener{Session: yamuxSession, nodeID: nodeID}) //nolint:errcheck
+
+	handleConn(node)
 }
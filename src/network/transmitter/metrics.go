@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Метрики transmitter, отдаются на /metrics вместе с общими из пакета metrics
+
+var (
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "connected_nodes",
+		Help:      "Nodes with an open tunnel.",
+	}, func() float64 { return float64(registry.Count()) })
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "yamux_streams",
+		Help:      "Active yamux streams over all node tunnels.",
+	}, func() float64 { return float64(registry.Streams()) })
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "rooms",
+		Help:      "Open rooms (matches).",
+	}, func() float64 { return float64(registry.Rooms()) })
+
+	relayedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "relayed_bytes_total",
+		Help:      "Bytes relayed to nodes by room and message kind.",
+	}, []string{"room", "kind"})
+
+	forwardedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "forwarded_messages_total",
+		Help:      "Messages forwarded to nodes connected to other transmitter instances by message kind.",
+	}, []string{"kind"})
+
+	rejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "rejected_total",
+		Help:      "Inbound connections, calls and match messages rejected by rate limits by path and reason.",
+	}, []string{"path", "reason"})
+
+	redirectedNodes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "transmitter",
+		Name:      "redirected_nodes_total",
+		Help:      "Nodes redirected to other transmitter instances during the handshake because this one is full.",
+	})
+)
+
+// relayed учитывает отправленные узлу байты в комнате, где он состоит
+func relayed(nodeID, kind string, n int) {
+	registry.addRelayed(nodeID, n)
+
+	roomID, ok := registry.RoomOf(nodeID)
+	if !ok {
+		return
+	}
+
+	relayedBytes.WithLabelValues(roomID, kind).Add(float64(n))
+}
+
+// forgetRoom удаляет счетчики закрытой комнаты, чтобы не копить ряды по всем матчам
+func forgetRoom(roomID string) {
+	relayedBytes.DeletePartialMatch(prometheus.Labels{"room": roomID})
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/yamux"
+	"github.com/matelq/p2pmp/src/backend/anticheat"
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/heartbeat"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	"github.com/matelq/p2pmp/src/network/common/metrics"
+	nodepb "github.com/matelq/p2pmp/src/network/common/node"
+	"google.golang.org/grpc"
+)
+
+var _ anticheat.Enforcer = (*Registry)(nil)
+
+var (
+	errNotConnected = errors.New("node is not connected")
+	errDraining     = errors.New("transmitter is draining, connect to another one")
+)
+
+// nodeSession - подключенный узел: TCP-туннель, yamux-сессия поверх него
+// и gRPC-клиент, которым transmitter вызывает сервер на узле
+type nodeSession struct {
+	id         string
+	addr       net.Addr
+	session    *yamux.Session
+	clientConn *grpc.ClientConn
+	// client - сервис узла (node.proto) поверх clientConn
+	client nodepb.NodeClient
+	// ctx - контекст логов подключения: узел, сессия и транспорт
+	ctx       context.Context
+	sessionID string
+	connected time.Time
+	// relayed - байты, отправленные узлу через transmitter
+	relayed   atomic.Uint64
+	heartbeat *heartbeat.Monitor
+	// reason - почему туннель закрыт, для OnDisconnect; под Registry.mu
+	reason string
+}
+
+// ping отправляет пульс через yamux: ответ приходит от самой сессии узла,
+// поэтому пульс проверяет весь путь до узла, а не только TCP
+func (n *nodeSession) ping(seq uint32, sent time.Time) error {
+	go func() {
+		if _, err := n.session.Ping(); err == nil {
+			n.heartbeat.Pong(seq, sent)
+		}
+	}()
+
+	return nil
+}
+
+// Registry - реестр подключенных узлов и банов.
+// Реализует anticheat.Enforcer, чтобы бэкенд мог выгонять нарушителей
+type Registry struct {
+	mu    sync.Mutex
+	nodes map[string]*nodeSession
+	// bans - до какого момента заблокированы узел (по ID) или его IP
+	bans map[string]time.Time
+	// rooms - участники комнат (матчей) и признак зрителя, см. rooms.go
+	rooms map[string]map[string]bool
+	// spectating - комната, которую смотрит узел-зритель, чтобы не искать
+	// зрителя по всем комнатам на каждой отправке
+	spectating map[string]string
+	// draining - новые туннели не принимаются, см. Drain
+	draining     bool
+	onDisconnect []func(nodeID, reason string)
+	// locations - общий каталог узлов и комнат всех экземпляров, см. cluster.go
+	locations directory.Directory
+	// instance - адрес этого экземпляра для пересылки от других
+	instance string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		nodes:      map[string]*nodeSession{},
+		bans:       map[string]time.Time{},
+		rooms:      map[string]map[string]bool{},
+		spectating: map[string]string{},
+		// Без общего каталога экземпляр работает один
+		locations: directory.NewMemory(),
+	}
+}
+
+// Add регистрирует узел. ID узла - ID аккаунта, поэтому повторный вход
+// с того же аккаунта вытесняет прежнее подключение
+func (r *Registry) Add(node *nodeSession) {
+	r.mu.Lock()
+	old, ok := r.nodes[node.id]
+	r.nodes[node.id] = node
+	r.countLinks()
+	r.mu.Unlock()
+
+	r.register(node)
+
+	if ok {
+		slog.InfoContext(old.ctx, "node reconnected, closing previous connection", "address", node.addr.String(), "previous_address", old.addr.String())
+		old.clientConn.Close()
+		old.session.Close()
+	}
+}
+
+// OnDisconnect подписывает f на отключение узлов: потерю туннеля, пропуск
+// пульса, кик и вывод из работы. Узел, переподключившийся с новым туннелем,
+// отключенным не считается. Подписываться нужно до приема туннелей
+func (r *Registry) OnDisconnect(f func(nodeID, reason string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onDisconnect = append(r.onDisconnect, f)
+}
+
+// Remove удаляет узел, если в реестре все еще это подключение, а не более новое
+func (r *Registry) Remove(node *nodeSession) {
+	r.mu.Lock()
+
+	if current, ok := r.nodes[node.id]; ok && current != node {
+		r.mu.Unlock()
+		return
+	}
+
+	delete(r.nodes, node.id)
+	delete(r.spectating, node.id)
+	r.countLinks()
+
+	var left []string
+	for roomID, room := range r.rooms {
+		if _, ok := room[node.id]; ok {
+			delete(room, node.id)
+			left = append(left, roomID)
+		}
+	}
+
+	reason := node.reason
+	if reason == "" {
+		reason = "connection lost"
+	}
+
+	listeners := r.onDisconnect
+	r.mu.Unlock()
+
+	r.unregister(node, left)
+
+	slog.InfoContext(node.ctx, "node disconnected", "reason", reason)
+
+	for _, f := range listeners {
+		f(node.id, reason)
+	}
+}
+
+// Disconnect закрывает туннель узла с причиной reason, если туннель еще не закрыт по другой
+func (r *Registry) Disconnect(node *nodeSession, reason string) {
+	r.mu.Lock()
+	if node.reason == "" {
+		node.reason = reason
+	}
+	r.mu.Unlock()
+
+	node.clientConn.Close()
+	node.session.Close()
+}
+
+// session возвращает подключение узла к этому экземпляру
+func (r *Registry) session(nodeID string) (*nodeSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	node, ok := r.nodes[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", nodeID, errNotConnected)
+	}
+
+	return node, nil
+}
+
+// Count - число подключенных узлов
+func (r *Registry) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.nodes)
+}
+
+// Streams - число открытых yamux-потоков во всех туннелях
+func (r *Registry) Streams() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	streams := 0
+	for _, node := range r.nodes {
+		streams += node.session.NumStreams()
+	}
+
+	return streams
+}
+
+// addRelayed учитывает байты, отправленные узлу
+func (r *Registry) addRelayed(nodeID string, n int) {
+	r.mu.Lock()
+	node, ok := r.nodes[nodeID]
+	r.mu.Unlock()
+
+	if ok {
+		node.relayed.Add(uint64(n))
+	}
+}
+
+// Drain перестает принимать новые туннели, подключенные узлы остаются
+func (r *Registry) Drain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.draining = true
+}
+
+func (r *Registry) Draining() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.draining
+}
+
+// countLinks обновляет метрику туннелей, вызывается под r.mu
+func (r *Registry) countLinks() {
+	metrics.Links.WithLabelValues(metrics.TransportRelay).Set(float64(len(r.nodes)))
+}
+
+// Kick закрывает туннель узла, узел может переподключиться
+func (r *Registry) Kick(nodeID string, reason string) error {
+	r.mu.Lock()
+	node, ok := r.nodes[nodeID]
+	delete(r.nodes, nodeID)
+	r.countLinks()
+
+	if ok {
+		node.reason = "kicked: " + reason
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%s: %w", nodeID, errNotConnected)
+	}
+
+	slog.WarnContext(node.ctx, "kicking node", "reason", reason)
+
+	node.clientConn.Close()
+
+	return node.session.Close()
+}
+
+// CloseAll закрывает туннели всех узлов и возвращает их число
+func (r *Registry) CloseAll() int {
+	r.mu.Lock()
+	nodes := make([]*nodeSession, 0, len(r.nodes))
+	for _, node := range r.nodes {
+		node.reason = "transmitter is draining"
+		nodes = append(nodes, node)
+	}
+	clear(r.nodes)
+	r.countLinks()
+	r.mu.Unlock()
+
+	for _, node := range nodes {
+		slog.InfoContext(node.ctx, "closing tunnel")
+		node.session.GoAway() //nolint:errcheck
+		node.clientConn.Close()
+		node.session.Close()
+	}
+
+	return len(nodes)
+}
+
+// Ban блокирует узел и его IP на duration и выгоняет его, если он подключен
+func (r *Registry) Ban(nodeID string, reason string, duration time.Duration) error {
+	until := time.Now().Add(duration)
+
+	r.mu.Lock()
+	r.bans[nodeID] = until
+
+	if node, ok := r.nodes[nodeID]; ok {
+		r.bans[host(node.addr)] = until
+	}
+
+	r.mu.Unlock()
+
+	slog.Warn("node is banned", logging.Node(nodeID), "until", until, "reason", reason)
+
+	if err := r.Kick(nodeID, reason); err != nil && !errors.Is(err, errNotConnected) {
+		slog.Warn("cannot kick banned node", logging.Node(nodeID), logging.Err(err))
+	}
+
+	return nil
+}
+
+// BanAddress блокирует IP без узла, например за флуд соединениями
+func (r *Registry) BanAddress(ip string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bans[ip] = time.Now().Add(duration)
+}
+
+// Banned проверяет, заблокирован ли адрес входящего соединения
+func (r *Registry) Banned(addr net.Addr) bool {
+	return r.banned(host(addr))
+}
+
+// BannedAddress проверяет, заблокирован ли IP
+func (r *Registry) BannedAddress(ip string) bool {
+	return r.banned(ip)
+}
+
+// BannedNode проверяет, заблокирован ли узел (аккаунт)
+func (r *Registry) BannedNode(nodeID string) bool {
+	return r.banned(nodeID)
+}
+
+func (r *Registry) banned(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.bans[key]
+	if !ok {
+		return false
+	}
+
+	if time.Now().After(until) {
+		delete(r.bans, key)
+		return false
+	}
+
+	return true
+}
+
+func host(addr net.Addr) string {
+	h, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return h
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/matelq/p2pmp/src/backend/match"
+	"github.com/matelq/p2pmp/src/network/common/directory"
+	"github.com/matelq/p2pmp/src/network/common/envelope"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+var _ match.Rooms = (*Registry)(nil)
+
+// Комнаты - группы узлов одного матча, по ним transmitter рассылает сообщения
+
+func (r *Registry) CreateRoom(roomID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rooms[roomID]; ok {
+		return fmt.Errorf("room %s already exists", roomID)
+	}
+
+	r.rooms[roomID] = map[string]bool{}
+
+	return nil
+}
+
+func (r *Registry) JoinRoom(roomID, nodeID string) error {
+	return r.joinRoom(roomID, nodeID, false)
+}
+
+// SpectateRoom добавляет зрителя: кадры ему отправляются с задержкой (см. spectators.go)
+func (r *Registry) SpectateRoom(roomID, nodeID string) error {
+	return r.joinRoom(roomID, nodeID, true)
+}
+
+func (r *Registry) joinRoom(roomID, nodeID string, spectator bool) error {
+	r.mu.Lock()
+	room, ok := r.rooms[roomID]
+	if ok {
+		room[nodeID] = spectator
+
+		if spectator {
+			r.spectating[nodeID] = roomID
+		} else if r.spectating[nodeID] == roomID {
+			delete(r.spectating, nodeID)
+		}
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("room %s does not exist", roomID)
+	}
+
+	// Узел может быть подключен к другому экземпляру: там комнату найдут по каталогу
+	return r.locations.JoinRoom(roomID, nodeID)
+}
+
+// Spectator проверяет, смотрит ли узел какой-нибудь матч зрителем
+func (r *Registry) Spectator(nodeID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.spectating[nodeID]
+
+	return ok
+}
+
+func (r *Registry) LeaveRoom(roomID, nodeID string) error {
+	r.mu.Lock()
+	if room, ok := r.rooms[roomID]; ok {
+		delete(room, nodeID)
+	}
+
+	if r.spectating[nodeID] == roomID {
+		delete(r.spectating, nodeID)
+	}
+	r.mu.Unlock()
+
+	return r.locations.LeaveRoom(roomID, nodeID)
+}
+
+// CloseRoom удаляет комнату. P2P-соединения между участниками
+// принадлежат узлам, они закрывают их сами, когда покидают матч
+func (r *Registry) CloseRoom(roomID string) error {
+	r.mu.Lock()
+	room, ok := r.rooms[roomID]
+	delete(r.rooms, roomID)
+
+	for nodeID, spectator := range room {
+		if spectator && r.spectating[nodeID] == roomID {
+			delete(r.spectating, nodeID)
+		}
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("room %s does not exist", roomID)
+	}
+
+	slog.Info("closing room", logging.Room(roomID), "nodes", len(room))
+	forgetRoom(roomID)
+
+	return r.locations.CloseRoom(roomID)
+}
+
+// Rooms - число открытых комнат
+func (r *Registry) Rooms() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.rooms)
+}
+
+// RoomOf возвращает комнату, в которой состоит узел. Комнату, открытую
+// на другом экземпляре, ищет в каталоге
+func (r *Registry) RoomOf(nodeID string) (string, bool) {
+	r.mu.Lock()
+	for roomID, room := range r.rooms {
+		if _, ok := room[nodeID]; ok {
+			r.mu.Unlock()
+			return roomID, true
+		}
+	}
+	r.mu.Unlock()
+
+	roomID, err := r.locations.RoomOf(nodeID)
+	if err != nil && !errors.Is(err, directory.ErrNotFound) {
+		slog.Warn("cannot look up room in directory", logging.Node(nodeID), logging.Err(err))
+	}
+
+	return roomID, err == nil
+}
+
+// RoomMembers возвращает ID узлов комнаты, в том числе подключенных к другим экземплярам
+func (r *Registry) RoomMembers(roomID string) []string {
+	r.mu.Lock()
+	room, ok := r.rooms[roomID]
+	members := make([]string, 0, len(room))
+	for nodeID := range room {
+		members = append(members, nodeID)
+	}
+	r.mu.Unlock()
+
+	if ok {
+		return members
+	}
+
+	members, err := r.locations.RoomMembers(roomID)
+	if err != nil {
+		slog.Warn("cannot look up room members in directory", logging.Room(roomID), logging.Err(err))
+	}
+
+	return members
+}
+
+// Publish рассылает конверт всем узлам комнаты, зрителям - с их задержкой
+func (r *Registry) Publish(roomID string, e envelope.Envelope) error {
+	var errs []error
+	for _, nodeID := range r.RoomMembers(roomID) {
+		deliver := func() error {
+			if err := routeEnvelope(nodeID, e); err != nil {
+				return err
+			}
+
+			relayed(nodeID, e.Type.String(), len(e.Payload))
+
+			return nil
+		}
+
+		if r.Spectator(nodeID) {
+			spectators.Push(nodeID, e.Type.String(), deliver)
+			continue
+		}
+
+		if err := deliver(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/matelq/p2pmp/src/backend/game"
+	"github.com/matelq/p2pmp/src/backend/match"
+	"github.com/matelq/p2pmp/src/network/common/chat"
+	"github.com/matelq/p2pmp/src/network/common/contracts"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+	transmitterpb "github.com/matelq/p2pmp/src/network/common/transmitter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// transmitterServer - сервис transmitter.proto для узлов. Вызовы принимаются
+// только через туннель: узел - это владелец туннеля, а не поле запроса
+type transmitterServer struct {
+	transmitterpb.UnimplementedTransmitterServer
+}
+
+// tunnelNode возвращает узел, через чей туннель пришел вызов
+func tunnelNode(ctx context.Context) (string, error) {
+	nodeID, _ := caller(ctx)
+	if nodeID == "" {
+		return "", status.Error(codes.PermissionDenied, "call is accepted only through the node tunnel")
+	}
+
+	return nodeID, nil
+}
+
+func (transmitterServer) CallFuncOnTransmitter(ctx context.Context, text *contracts.Text) (*contracts.Text, error) {
+	slog.DebugContext(ctx, "CallFuncOnTransmitter called", "data", text.Data)
+
+	return &contracts.Text{Data: fmt.Sprintf("Echo: %s", text.Data)}, nil
+}
+
+// SendInput передает ввод игрока в его матч. Зрителям и узлам вне матча отвечает FailedPrecondition
+func (transmitterServer) SendInput(ctx context.Context, in *contracts.PlayerInput) (*contracts.Text, error) {
+	nodeID, err := tunnelNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := matches.Input(nodeID, game.Input{Seq: in.Seq, DirX: in.DirX, DirY: in.DirY}); err != nil {
+		return nil, matchError(err)
+	}
+
+	return &contracts.Text{}, nil
+}
+
+// SendChat отвечает текстом ошибки, если сообщение не принято: игрок видит ее в чате
+func (transmitterServer) SendChat(ctx context.Context, m *contracts.ChatMessage) (*contracts.Text, error) {
+	nodeID, err := tunnelNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := chat.FromContract(m)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = sendChat(nodeID, message)
+
+	switch {
+	case chat.Rejected(err), errors.Is(err, match.ErrNotInMatch):
+		return &contracts.Text{Data: err.Error()}, nil
+	case err != nil:
+		// Сообщение принято, но дошло не до всех
+		slog.InfoContext(ctx, "chat message is not delivered to everyone", logging.Node(nodeID), logging.Err(err))
+	}
+
+	return &contracts.Text{}, nil
+}
+
+// Spectate подключает узел туннеля к матчу зрителем
+func (transmitterServer) Spectate(ctx context.Context, req *contracts.SpectateRequest) (*contracts.Text, error) {
+	nodeID, err := tunnelNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.NodeId != "" && req.NodeId != nodeID {
+		return nil, status.Error(codes.PermissionDenied, "node_id does not match the tunnel")
+	}
+
+	if _, err := matches.Spectate(req.MatchId, nodeID); err != nil {
+		return nil, matchError(err)
+	}
+
+	return &contracts.Text{}, nil
+}
+
+// JoinMatch переводит узел туннеля в матч, который ему подобрал матчмейкинг.
+// При подключении узел попадает в первый матч со свободным местом, отсюда он выходит
+func (transmitterServer) JoinMatch(ctx context.Context, req *contracts.JoinMatchRequest) (*contracts.Text, error) {
+	nodeID, err := tunnelNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.NodeId != "" && req.NodeId != nodeID {
+		return nil, status.Error(codes.PermissionDenied, "node_id does not match the tunnel")
+	}
+
+	if req.MatchId == "" {
+		return nil, status.Error(codes.InvalidArgument, "match_id is required")
+	}
+
+	joined, err := matches.Join(req.MatchId, nodeID)
+	if err != nil {
+		return nil, matchError(err)
+	}
+
+	slog.InfoContext(ctx, "node joined assigned match", logging.Node(nodeID), logging.Room(joined.ID))
+
+	return &contracts.Text{}, nil
+}
+
+// Follow переключает камеру зрителя на игрока
+func (transmitterServer) Follow(ctx context.Context, req *contracts.FollowRequest) (*contracts.Text, error) {
+	nodeID, err := tunnelNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.NodeId != "" && req.NodeId != nodeID {
+		return nil, status.Error(codes.PermissionDenied, "node_id does not match the tunnel")
+	}
+
+	if err := matches.Follow(nodeID, req.PlayerId); err != nil {
+		return nil, matchError(err)
+	}
+
+	return &contracts.Text{}, nil
+}
+
+// matchError переводит ошибки match в коды gRPC
+func matchError(err error) error {
+	switch {
+	case errors.Is(err, match.ErrMatchNotFound), errors.Is(err, match.ErrPlayerNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, match.ErrNotInMatch), errors.Is(err, match.ErrNotSpectating), errors.Is(err, match.ErrSpectator):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, match.ErrMatchFull):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/matelq/p2pmp/src/backend/match"
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+var _ match.Sender = (*relay)(nil)
+
+// push доставляет снимок узлу и возвращает тик, который узел подтвердил (0 - никакой)
+type push func(nodeID string, data []byte, playerID, lastInput uint32) (uint32, error)
+
+type frame struct {
+	data      []byte
+	playerID  uint32
+	lastInput uint32
+	// kind - для учета в relayed: снимок игрока или зрителя
+	kind string
+}
+
+// outbox - неотправленный снимок узла. Новый снимок вытесняет неотправленный:
+// медленный узел получает самый свежий, а игровой цикл не ждет ответа узла
+type outbox struct {
+	frames chan frame
+	done   chan struct{}
+}
+
+func (o *outbox) put(f frame) {
+	for {
+		select {
+		case o.frames <- f:
+			return
+		default:
+		}
+
+		select {
+		case <-o.frames:
+		default:
+		}
+	}
+}
+
+// relay - доставка снимков бэкенда узлам: игрокам сразу, зрителям через spectators.
+// Подтверждения узлов передаются в acked, по ним бэкенд выбирает baseline дельт
+type relay struct {
+	registry *Registry
+	feed     *spectatorFeed
+	push     push
+	acked    func(nodeID string, tick uint32) error
+
+	mu       sync.Mutex
+	outboxes map[string]*outbox
+}
+
+func newRelay(registry *Registry, feed *spectatorFeed, push push, acked func(nodeID string, tick uint32) error) *relay {
+	return &relay{registry: registry, feed: feed, push: push, acked: acked, outboxes: map[string]*outbox{}}
+}
+
+func (r *relay) SendSnapshot(nodeID string, data []byte, playerID, lastInput uint32) error {
+	if r.registry.Spectator(nodeID) {
+		// Отправленный кадр учитывает send, когда узел его принял
+		r.feed.Push(nodeID, "spectator", func() error {
+			r.outbox(nodeID).put(frame{data: data, kind: "spectator"})
+			return nil
+		})
+
+		return nil
+	}
+
+	r.outbox(nodeID).put(frame{data: data, playerID: playerID, lastInput: lastInput, kind: "snapshot"})
+
+	return nil
+}
+
+// Forget останавливает отправку снимков отключившемуся узлу
+func (r *relay) Forget(nodeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if o, ok := r.outboxes[nodeID]; ok {
+		close(o.done)
+		delete(r.outboxes, nodeID)
+	}
+}
+
+func (r *relay) outbox(nodeID string) *outbox {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.outboxes[nodeID]
+	if !ok {
+		o = &outbox{frames: make(chan frame, 1), done: make(chan struct{})}
+		r.outboxes[nodeID] = o
+
+		go r.send(nodeID, o)
+	}
+
+	return o
+}
+
+// send отправляет снимки узла по одному, пока узел не забыт
+func (r *relay) send(nodeID string, o *outbox) {
+	for {
+		var f frame
+
+		select {
+		case <-o.done:
+			return
+		case f = <-o.frames:
+		}
+
+		tick, err := r.push(nodeID, f.data, f.playerID, f.lastInput)
+		if err != nil {
+			slog.Warn("cannot send snapshot", logging.Node(nodeID), "kind", f.kind, logging.Err(err))
+			continue
+		}
+
+		relayed(nodeID, f.kind, len(f.data))
+
+		if tick == 0 {
+			continue
+		}
+
+		if err := r.acked(nodeID, tick); err != nil {
+			slog.Debug("snapshot ack is not accepted", logging.Node(nodeID), "tick", tick, logging.Err(err))
+		}
+	}
+}
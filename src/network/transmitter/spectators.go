@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/matelq/p2pmp/src/network/common/logging"
+)
+
+// spectators - задержка всего, что зрители получают из матча: снимков, таблицы
+// лидеров и чата. Иначе зритель подсказал бы игроку то, чего тот не видит (ghosting).
+// Создается в main с задержкой match.Config.SpectatorDelay
+var spectators *spectatorFeed
+
+// delayed - доставка зрителю, отложенная до due
+type delayed struct {
+	due    time.Time
+	nodeID string
+	// kind - что доставляется, для журнала. Доставленное учитывает в relayed сам send
+	kind string
+	send func() error
+}
+
+// spectatorFeed придерживает доставки зрителям на delay. Задержка одна для всех,
+// поэтому очередь упорядочена по времени отправки
+type spectatorFeed struct {
+	delay time.Duration
+
+	mu    sync.Mutex
+	queue []delayed
+	wake  chan struct{}
+}
+
+func newSpectatorFeed(delay time.Duration) *spectatorFeed {
+	return &spectatorFeed{delay: delay, wake: make(chan struct{}, 1)}
+}
+
+// Push откладывает send на задержку зрителей
+func (f *spectatorFeed) Push(nodeID, kind string, send func() error) {
+	f.mu.Lock()
+	f.queue = append(f.queue, delayed{due: time.Now().Add(f.delay), nodeID: nodeID, kind: kind, send: send})
+	f.mu.Unlock()
+
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run отправляет то, чье время подошло, до закрытия stop
+func (f *spectatorFeed) Run(stop <-chan struct{}) {
+	timer := time.NewTimer(f.delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-f.wake:
+		case <-timer.C:
+		}
+
+		timer.Reset(f.flush(time.Now()))
+	}
+}
+
+// flush отправляет готовое и возвращает, сколько ждать до следующего
+func (f *spectatorFeed) flush(now time.Time) time.Duration {
+	f.mu.Lock()
+	n := 0
+	for n < len(f.queue) && !f.queue[n].due.After(now) {
+		n++
+	}
+
+	ready := f.queue[:n:n]
+	f.queue = f.queue[n:]
+
+	// Пустую очередь будит Push
+	next := time.Minute
+	if len(f.queue) > 0 {
+		next = f.queue[0].due.Sub(now)
+	}
+	f.mu.Unlock()
+
+	for _, d := range ready {
+		if err := d.send(); err != nil {
+			slog.Warn("cannot send to spectator", logging.Node(d.nodeID), "kind", d.kind, logging.Err(err))
+		}
+	}
+
+	return next
+}